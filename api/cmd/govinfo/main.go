@@ -1,28 +1,189 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"os"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 
+	"github.com/tingeytime/govinfo/api/internal/buildinfo"
 	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/db/migrate"
+	"github.com/tingeytime/govinfo/api/internal/events"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/httplog"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+	"github.com/tingeytime/govinfo/api/internal/poller"
 	"github.com/tingeytime/govinfo/api/internal/server"
+	"github.com/tingeytime/govinfo/api/internal/telemetry"
+	"github.com/tingeytime/govinfo/api/internal/webhook"
 )
 
 func main() {
-    cfg := config.Load()
-    cfg.Logger = logger
+	if err := run(context.Background(), config.Load()); err != nil {
+		fmt.Fprintln(os.Stderr, "govinfo:", err)
+		os.Exit(1)
+	}
+}
 
-	logger, err := zap.NewProduction()
+// run starts the API for cfg and blocks until it shuts down, on a signal
+// or when ctx is cancelled.
+func run(ctx context.Context, cfg *config.Config) error {
+	logger, err := config.NewLogger(cfg)
 	if err != nil {
-		log.Fatalf("can't initialize zap logger: %v", err)
+		return fmt.Errorf("initialize logger: %w", err)
 	}
 	defer logger.Sync()
 
-	logger.Info("Starting GovInfo API", zap.String("port", cfg.Port))
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	build := buildinfo.Get()
+	logger.Info("Starting GovInfo API",
+		zap.String("port", cfg.Port),
+		zap.String("version", build.Version),
+		zap.String("commit", build.Commit),
+		zap.String("build_date", build.BuildDate))
+
+	shutdownTracing, err := telemetry.Setup(ctx, cfg.OTLPTracesEndpoint)
+	if err != nil {
+		return fmt.Errorf("tracing setup: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("Could not flush traces", zap.Error(err))
+		}
+	}()
+
+	pool, err := db.Connect(ctx, cfg.DBUrl, db.PoolConfig{
+		MaxConns:        cfg.DBMaxConns,
+		MinConns:        cfg.DBMinConns,
+		MaxConnLifetime: cfg.DBMaxConnLifetime,
+		Tracer:          db.NewQueryTracer(logger, cfg.SlowQueryThreshold),
+	})
+	if err != nil {
+		return fmt.Errorf("database setup: %w", err)
+	}
+	defer pool.Close()
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err = pool.Ping(pingCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+
+	if cfg.MigrateOnStart {
+		ran, err := migrate.Up(ctx, pool)
+		for _, m := range ran {
+			logger.Info("Applied migration", zap.String("migration", m.Name))
+		}
+		if err != nil {
+			return fmt.Errorf("database migration: %w", err)
+		}
+	}
+
+	deps, err := newDeps(cfg, logger, pool)
+	if err != nil {
+		return fmt.Errorf("setup: %w", err)
+	}
+	return server.NewServer(cfg, deps).Run(ctx)
+}
+
+// newDeps wires the GovInfo client, repositories and notification
+// pipeline the server runs on. Nothing here touches the network yet.
+func newDeps(cfg *config.Config, logger *zap.Logger, pool *pgxpool.Pool) (server.Deps, error) {
+	govHTTP := govinfo.NewHTTPClient(govinfo.TransportConfig{
+		MaxIdleConns:        cfg.GovInfoMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.GovInfoMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.GovInfoIdleConnTimeout,
+		Timeout:             cfg.GovInfoHTTPTimeout,
+	})
+	if cfg.HTTPDebugLog {
+		govHTTP.Transport = httplog.Wrap(govHTTP.Transport, logger.With(zap.String("upstream", "govinfo")))
+	}
+	gov, err := govinfo.NewClient(cfg.GovInfoAPIKey, govHTTP,
+		govinfo.WithBaseURL(cfg.GovInfoBaseURL),
+		govinfo.WithCollectionsCacheTTL(cfg.CollectionsCacheTTL),
+		govinfo.WithRateLimit(cfg.GovInfoRPS),
+		govinfo.WithMaxConcurrency(cfg.GovInfoMaxConcurrency),
+		govinfo.WithTimeout(cfg.GovInfoTimeout),
+		govinfo.WithRetries(cfg.GovInfoMaxRetries),
+		govinfo.WithRetryBackoff(cfg.GovInfoRetryBackoff),
+		govinfo.WithRetryMaxBackoff(cfg.GovInfoRetryMaxBackoff),
+		govinfo.WithRetryBudget(cfg.GovInfoRetryBudget),
+		govinfo.WithUserAgent(cfg.GovInfoUserAgent),
+		govinfo.WithMaxResponseBytes(cfg.GovInfoMaxBody),
+		govinfo.WithCircuitBreaker(cfg.GovInfoBreakerThreshold, cfg.GovInfoBreakerCooldown),
+		govinfo.WithConditionalRequests(cfg.GovInfoResponseCacheTTL),
+		govinfo.WithCassette(cfg.GovInfoCacheDir, cfg.GovInfoCacheMode, cfg.GovInfoCacheTTL),
+	)
+	if err != nil {
+		return server.Deps{}, err
+	}
+	subs := db.NewSubscriptionRepo(pool)
+	hooks := db.NewWebhookRepo(pool)
+	packages := db.NewPackageRepo(pool)
+	outbox := db.NewOutboxRepo(pool)
+	state := db.NewCollectionStateRepo(pool)
+	notifications := db.NewNotificationRepo(pool)
 
-	if err := server.Start(cfg, logger); err != nil {
-		logger.Fatal("server failed", zap.Error(err))
+	sms := notify.NewTwilioSender(cfg, logger.With(zap.String("upstream", "twilio")))
+	webhooks := webhook.NewSender(cfg, logger)
+	// Alerts go through the outbox to respect Twilio's send rate;
+	// confirmation codes are sent directly since a person is waiting.
+	notifiers := map[string]notify.Notifier{
+		db.ChannelSMS:     notify.OutboxNotifier{Outbox: outbox},
+		db.ChannelWebhook: webhooks,
 	}
+	// email stays a nil interface when SMTP isn't configured, which
+	// disables the channel.
+	var email notify.EmailSender
+	if cfg.SMTPHost != "" {
+		email = notify.NewSMTPSender(cfg)
+		notifiers[db.ChannelEmail] = notify.EmailNotifier{Sender: email}
+	}
+
+	dispatchOpts := []notify.DispatcherOption{notify.WithAuditLog(notifications)}
+	if cfg.SMSDedupWindow > 0 {
+		var dedup notify.Deduper = notify.DBDeduper{Store: db.NewSMSDedupRepo(pool), Window: cfg.SMSDedupWindow}
+		if cfg.SMSDedupStore == config.SMSDedupMemory {
+			dedup = notify.NewMemoryDeduper(cfg.SMSDedupWindow)
+		}
+		dispatchOpts = append(dispatchOpts, notify.WithDeduper(dedup))
+	}
+
+	dispatcher := notify.NewDispatcher(subs, notifiers, hooks, webhooks, cfg.DispatchWorkers, cfg.DispatchGrace, logger, dispatchOpts...)
+	outboxWorker := notify.NewOutboxWorker(outbox, sms, cfg.SMSOutboxRPS, cfg.SMSOutboxMaxAttempts, logger,
+		notify.WithOutboxAuditLog(notifications))
+	hub := events.NewHub(0)
+	poll := poller.New(gov, poller.Collections(subs, hooks), state, dispatcher, packages, hub, cfg.PollInterval, logger,
+		poller.WithCollectionTimeout(cfg.PollCollectionTimeout),
+		poller.WithEnabledCollections(cfg.EnabledCollections))
+
+	return server.Deps{
+		Logger:          logger,
+		Pool:            pool,
+		Gov:             gov,
+		Subs:            subs,
+		IdempotencyKeys: db.NewIdempotencyRepo(pool),
+		Hooks:           hooks,
+		Packages:        packages,
+		CollectionState: state,
+		Dispatcher:      dispatcher,
+		Outbox:          outbox,
+		Notifications:   notifications,
+		OutboxWorker:    outboxWorker,
+		Poller:          poll,
+		Events:          hub,
+		SMS:             sms,
+		Email:           email,
+	}, nil
 }
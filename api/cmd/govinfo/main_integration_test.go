@@ -0,0 +1,101 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+)
+
+// TestRunServesUntilCancelled needs a disposable Postgres database named
+// by TEST_DATABASE_URL; migrations are applied to it.
+func TestRunServesUntilCancelled(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	gov := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"collections":[]}`))
+	}))
+	defer gov.Close()
+
+	port := freePort(t)
+	for key, val := range map[string]string{
+		"CONFIG_FILE":      "",
+		"DATABASE_URL":     dbURL,
+		"MIGRATE_ON_START": "true",
+		"PORT":             strconv.Itoa(port),
+		"ENV":              config.EnvDevelopment,
+		"API_KEY":          "test-key",
+		"TWILIO_SID":       "AC00000000000000000000000000000000",
+		"TWILIO_TOKEN":     "token",
+		"TWILIO_FROM":      "+12025550100",
+		"GOVINFO_BASE_URL": gov.URL,
+		"POLL_INTERVAL":    "1h",
+		"SHUTDOWN_TIMEOUT": "5s",
+	} {
+		t.Setenv(key, val)
+	}
+	cfg := config.Load()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, cfg) }()
+
+	healthz := "http://127.0.0.1:" + strconv.Itoa(port) + "/healthz"
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := http.Get(healthz)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				break
+			}
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("run returned before serving: %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server never became healthy: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run = %v, want a clean shutdown", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("run did not return after cancel")
+	}
+
+	if resp, err := http.Get(healthz); err == nil {
+		resp.Body.Close()
+		t.Error("server still answering after shutdown")
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
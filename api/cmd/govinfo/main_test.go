@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+)
+
+func TestRunRejectsInvalidConfig(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("API_KEY", "")
+	t.Setenv("API_KEYS", "")
+
+	err := run(context.Background(), config.Load())
+	if err == nil || !strings.Contains(err.Error(), "DATABASE_URL is required") {
+		t.Fatalf("run error = %v, want a missing DATABASE_URL", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package apperr defines the error kinds the HTTP layer maps to status
+// codes. Lower layers wrap or return these so handlers can pass errors
+// straight to httpjson.WriteErr without translating them.
+package apperr
+
+import "errors"
+
+// Error kinds. Each maps to one HTTP status in httpjson.WriteErr.
+var (
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNotFound      = errors.New("not found")
+	ErrNotAcceptable = errors.New("not acceptable")
+	ErrConflict      = errors.New("conflict")
+	ErrGone          = errors.New("gone")
+	ErrTooLarge      = errors.New("request too large")
+	ErrUpstream      = errors.New("upstream error")
+	ErrUnavailable   = errors.New("service unavailable")
+	ErrTimeout       = errors.New("timed out")
+)
+
+// Error pairs a kind with a message that is safe to show clients. Err is
+// the internal cause; it is logged but never sent to the client.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	errs := []error{e.Kind}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// New returns an error of kind with a client-facing message.
+func New(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap is New with an internal cause attached for logging.
+func Wrap(kind error, message string, err error) error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// Message returns the client-facing message of the outermost Error in
+// err, falling back to the kind's own text.
+func Message(err error, kind error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Message != "" {
+		return e.Message
+	}
+	return kind.Error()
+}
@@ -0,0 +1,39 @@
+// Package buildinfo exposes the version metadata stamped into the binary
+// at link time:
+//
+//	go build -ldflags "-X github.com/tingeytime/govinfo/api/internal/buildinfo.version=v1.2.3 \
+//		-X github.com/tingeytime/govinfo/api/internal/buildinfo.commit=$(git rev-parse --short HEAD) \
+//		-X github.com/tingeytime/govinfo/api/internal/buildinfo.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import "runtime/debug"
+
+// Set via -ldflags -X. Unset values keep these defaults.
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "none"
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// Get returns the build metadata. When the commit wasn't stamped, the VCS
+// revision recorded by the Go toolchain is used if there is one.
+func Get() Info {
+	info := Info{Version: version, Commit: commit, BuildDate: buildDate}
+	if info.Commit == "none" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" && s.Value != "" {
+					info.Commit = s.Value
+				}
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLCache is a concurrency-safe map whose entries expire ttl after they
+// were set. Expired entries are treated as misses but stay in memory
+// until Purge drops them, so callers setting unbounded keys must Purge
+// now and then.
+type TTLCache[K comparable, V any] struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[K]entry[V]
+	now   func() time.Time
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:   ttl,
+		items: make(map[K]entry[V]),
+		now:   time.Now,
+	}
+}
+
+// Get returns the value for key if it is present and not expired.
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || !c.now().Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key for the cache's TTL.
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = entry[V]{value: value, expiresAt: c.now().Add(c.ttl)}
+}
+
+// Len returns how many entries are stored, counting expired ones not yet
+// dropped.
+func (c *TTLCache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+// Purge removes every expired entry.
+func (c *TTLCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.items {
+		if !now.Before(e.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+}
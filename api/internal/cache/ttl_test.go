@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheExpires(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewTTLCache[string, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get = %d, %v; want 1, true", v, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("entry still served at its expiry")
+	}
+
+	c.Purge()
+	if len(c.items) != 0 {
+		t.Errorf("Purge left %d expired entries", len(c.items))
+	}
+}
+
+func TestTTLCacheDelete(t *testing.T) {
+	c := NewTTLCache[string, int](time.Minute)
+	c.Set("a", 1)
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("deleted entry still served")
+	}
+}
+
+func TestTTLCacheLen(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewTTLCache[string, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if n := c.Len(); n != 2 {
+		t.Errorf("Len = %d, want 2", n)
+	}
+	now = now.Add(time.Minute)
+	c.Purge()
+	if n := c.Len(); n != 0 {
+		t.Errorf("Len after Purge = %d, want 0", n)
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// API key scopes. Each implies the ones below it: admin can do anything,
+// write can also read.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// DefaultKeyName names the key set by API_KEY, which has every scope.
+const DefaultKeyName = "default"
+
+// impliedScopes lists the scopes each scope grants besides itself.
+var impliedScopes = map[string][]string{
+	ScopeAdmin: {ScopeWrite, ScopeRead},
+	ScopeWrite: {ScopeRead},
+}
+
+// APIKey is one named key accepted by the API and the scopes it grants.
+type APIKey struct {
+	Name   string
+	Key    string
+	Scopes []string
+}
+
+// HasScope reports whether k grants scope, directly or through a broader
+// scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || slices.Contains(impliedScopes[s], scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllAPIKeys returns the named keys from API_KEYS, plus API_KEY as
+// DefaultKeyName with admin scope when it is set.
+func (c *Config) AllAPIKeys() []APIKey {
+	keys := slices.Clone(c.APIKeys)
+	if c.APIKey != "" {
+		keys = append(keys, APIKey{Name: DefaultKeyName, Key: c.APIKey, Scopes: []string{ScopeAdmin}})
+	}
+	return keys
+}
+
+// parseAPIKeys parses API_KEYS: comma-separated name:scopes:key entries,
+// with scopes joined by "+", such as "ci:read:abc,ops:write+admin:def".
+// The key is everything after the second colon.
+func parseAPIKeys(val string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, errors.New("API_KEYS entries must look like name:scope+scope:key")
+		}
+		k := APIKey{Name: parts[0], Key: parts[2]}
+		for _, scope := range strings.Split(parts[1], "+") {
+			switch scope {
+			case ScopeRead, ScopeWrite, ScopeAdmin:
+				k.Scopes = append(k.Scopes, scope)
+			default:
+				return nil, fmt.Errorf("API_KEYS key %q has unknown scope %q; use %s, %s or %s",
+					k.Name, scope, ScopeRead, ScopeWrite, ScopeAdmin)
+			}
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// validateAPIKeys checks that key names and values are unique across
+// API_KEYS and API_KEY.
+func validateAPIKeys(keys []APIKey) []error {
+	var errs []error
+	names := make(map[string]bool)
+	values := make(map[string]bool)
+	for _, k := range keys {
+		if names[k.Name] {
+			errs = append(errs, fmt.Errorf("API_KEYS names key %q more than once", k.Name))
+		}
+		if values[k.Key] {
+			errs = append(errs, fmt.Errorf("API_KEYS key %q reuses another key's value", k.Name))
+		}
+		names[k.Name] = true
+		values[k.Key] = true
+	}
+	return errs
+}
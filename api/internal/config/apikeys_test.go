@@ -0,0 +1,78 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := parseAPIKeys(" ci:read:abc , ops:write+admin:d:e:f ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []APIKey{
+		{Name: "ci", Key: "abc", Scopes: []string{ScopeRead}},
+		{Name: "ops", Key: "d:e:f", Scopes: []string{ScopeWrite, ScopeAdmin}},
+	}
+	if !slices.EqualFunc(keys, want, func(a, b APIKey) bool {
+		return a.Name == b.Name && a.Key == b.Key && slices.Equal(a.Scopes, b.Scopes)
+	}) {
+		t.Errorf("keys = %+v, want %+v", keys, want)
+	}
+
+	for _, bad := range []string{"ci:read", ":read:abc", "ci:read:", "ci:superuser:abc", "ci::abc"} {
+		if _, err := parseAPIKeys(bad); err == nil {
+			t.Errorf("parseAPIKeys(%q) accepted", bad)
+		}
+	}
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	for _, tc := range []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeRead}, ScopeRead, true},
+		{[]string{ScopeRead}, ScopeWrite, false},
+		{[]string{ScopeWrite}, ScopeRead, true},
+		{[]string{ScopeWrite}, ScopeAdmin, false},
+		{[]string{ScopeAdmin}, ScopeWrite, true},
+		{[]string{ScopeAdmin}, ScopeRead, true},
+		{nil, ScopeRead, false},
+	} {
+		if got := (APIKey{Scopes: tc.scopes}).HasScope(tc.scope); got != tc.want {
+			t.Errorf("%v HasScope(%s) = %v, want %v", tc.scopes, tc.scope, got, tc.want)
+		}
+	}
+}
+
+func TestAllAPIKeysAddsDefault(t *testing.T) {
+	c := &Config{APIKey: "secret", APIKeys: []APIKey{{Name: "ci", Key: "abc", Scopes: []string{ScopeRead}}}}
+	keys := c.AllAPIKeys()
+	if len(keys) != 2 || keys[1].Name != DefaultKeyName || keys[1].Key != "secret" || !keys[1].HasScope(ScopeAdmin) {
+		t.Errorf("keys = %+v, want ci plus an admin default key", keys)
+	}
+	if keys := (&Config{}).AllAPIKeys(); len(keys) != 0 {
+		t.Errorf("no keys configured = %+v", keys)
+	}
+}
+
+func TestValidateAPIKeysRejectsDuplicates(t *testing.T) {
+	c := validConfig()
+	c.APIKeys = []APIKey{
+		{Name: "ci", Key: "abc", Scopes: []string{ScopeRead}},
+		{Name: "ci", Key: "def", Scopes: []string{ScopeRead}},
+		{Name: "ops", Key: "secret", Scopes: []string{ScopeAdmin}},
+	}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted duplicate keys")
+	}
+	for _, want := range []string{`names key "ci" more than once`, `key "default" reuses another key's value`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
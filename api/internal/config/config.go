@@ -1,39 +1,446 @@
 package config
 
 import (
-    "log"
-    "os"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
-    "github.com/joho/godotenv"
+// Stores selectable with SMS_DEDUP_STORE.
+const (
+	SMSDedupDatabase = "database"
+	SMSDedupMemory   = "memory"
 )
 
+// Config is the service's settings. Fields holding secrets are tagged
+// sensitive:"true" so Redacted never shows them.
 type Config struct {
-    Port         string
-    DBUrl        string
-    TwilioSID    string
-    TwilioToken  string
-    Logger       *zap.Logger
+	Port        string
+	DBUrl       string `sensitive:"true"`
+	TwilioSID   string
+	TwilioToken string `sensitive:"true"`
+	TwilioFrom  string
+	// TwilioWebhookURL is the public URL Twilio posts inbound SMS to. It
+	// is part of the signed payload, so set it when behind a proxy that
+	// rewrites the host or scheme.
+	TwilioWebhookURL string
+	GovInfoAPIKey    string `sensitive:"true"`
+	// APIKey authorizes callers of the mutating endpoints with every
+	// scope. APIKeys adds named keys with narrower scopes.
+	APIKey  string   `sensitive:"true"`
+	APIKeys []APIKey `sensitive:"true"`
+
+	// SMTP relay for the email channel. Email is disabled without a host.
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string `sensitive:"true"`
+	SMTPFrom string
+
+	TwilioMaxAttempts int
+	// SMS alerts are queued and sent at SMSOutboxRPS, each tried up to
+	// SMSOutboxMaxAttempts times.
+	SMSOutboxRPS         float64
+	SMSOutboxMaxAttempts int
+	// SMSDedupWindow is how long a package texted to a number won't be
+	// texted to it again; zero disables dedup. SMSDedupStore keeps the
+	// claims in the "database" or, for a single instance, in "memory".
+	SMSDedupWindow  time.Duration
+	SMSDedupStore   string
+	DispatchWorkers int
+	// DispatchGrace is how long in-flight sends may run on after a
+	// dispatch is cancelled.
+	DispatchGrace time.Duration
+	// StreamMaxConnections caps concurrent GET /stream/packages clients.
+	StreamMaxConnections int
+	// Webhook deliveries: attempts before dead-lettering, and the
+	// per-attempt timeout.
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+	PollInterval       time.Duration
+	// PollCollectionTimeout bounds each collection's share of a poll.
+	PollCollectionTimeout time.Duration
+	// EnabledCollections limits polling and new subscriptions to these
+	// collection codes. Empty allows every collection GovInfo publishes.
+	EnabledCollections []string
+	// ConfirmationTTL is how long an SMS opt-in code stays valid.
+	ConfirmationTTL time.Duration
+	// IdempotencyKeyTTL is how long an Idempotency-Key replays its
+	// first response.
+	IdempotencyKeyTTL time.Duration
+
+	CollectionsCacheTTL time.Duration
+	GovInfoRPS          float64
+	// GovInfoMaxConcurrency caps requests in flight to GovInfo. Zero
+	// means no cap.
+	GovInfoMaxConcurrency int
+	GovInfoTimeout        time.Duration
+	// GovInfoBaseURL is the GovInfo API root, overridable for staging or
+	// a mock server.
+	GovInfoBaseURL string
+	// GovInfoUserAgent overrides the User-Agent sent to GovInfo.
+	GovInfoUserAgent string
+	// GovInfo retries of read-only requests: how many, the first and
+	// largest backoff, and the total wait allowed per call.
+	GovInfoMaxRetries      int
+	GovInfoRetryBackoff    time.Duration
+	GovInfoRetryMaxBackoff time.Duration
+	GovInfoRetryBudget     time.Duration
+	// GovInfo circuit breaker: consecutive failures before opening, and
+	// how long it stays open. A zero threshold disables it.
+	GovInfoBreakerThreshold int
+	GovInfoBreakerCooldown  time.Duration
+	// GovInfoMaxBody caps JSON responses read from GovInfo, in bytes.
+	GovInfoMaxBody int64
+	// GovInfoResponseCacheTTL keeps ETag'd GovInfo responses for
+	// conditional revalidation. Zero disables conditional requests.
+	GovInfoResponseCacheTTL time.Duration
+
+	// GovInfoCacheDir and GovInfoCacheMode ("record" or "replay") enable
+	// the on-disk response cassette for offline development.
+	GovInfoCacheDir  string
+	GovInfoCacheMode string
+	GovInfoCacheTTL  time.Duration
+
+	// Connection reuse for the GovInfo HTTP transport.
+	GovInfoMaxIdleConns        int
+	GovInfoMaxIdleConnsPerHost int
+	GovInfoIdleConnTimeout     time.Duration
+	GovInfoHTTPTimeout         time.Duration
+
+	// HTTPDebugLog logs every GovInfo and Twilio call, minus bodies and
+	// credentials, at debug level.
+	HTTPDebugLog bool
+
+	DBMaxConns        int32
+	DBMinConns        int32
+	DBMaxConnLifetime time.Duration
+	// SlowQueryThreshold logs queries at least this slow. Zero disables.
+	SlowQueryThreshold time.Duration
+	// MigrateOnStart applies pending migrations before serving.
+	MigrateOnStart bool
+
+	// OTLPTracesEndpoint is the OTLP/HTTP URL traces are exported to.
+	// Tracing is off when it is empty.
+	OTLPTracesEndpoint string
+
+	// TLSCertFile and TLSKeyFile make the server terminate TLS itself.
+	// Both or neither must be set.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// RequestTimeout bounds each request's context, except streaming
+	// routes. Zero disables it.
+	RequestTimeout  time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// ShutdownDrainDelay is how long the server keeps serving after a
+	// shutdown signal, with /readyz failing, before it stops accepting
+	// connections.
+	ShutdownDrainDelay time.Duration
+
+	// RateLimitRPS is the per-client request rate. Zero turns limiting
+	// off, as does ENV=development; see RateLimitEnabled.
+	RateLimitRPS   float64
+	RateLimitBurst int
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For. Only
+	// enable it behind a proxy that sets the header.
+	TrustProxyHeaders bool
+
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+
+	// Env is "development" or "production" and picks the log encoder.
+	Env string
+	// LogLevel is the minimum level the logger emits.
+	LogLevel zapcore.Level
+	level    zap.AtomicLevel
+	// Log sampling: per message and second, keep the first
+	// LogSampleInitial entries and then every LogSampleThereafter-th.
+	// Zero LogSampleInitial disables sampling, the development default.
+	LogSampleInitial    int
+	LogSampleThereafter int
+
+	// Access log levels for 4xx and 5xx responses.
+	AccessLogClientErrorLevel zapcore.Level
+	AccessLogServerErrorLevel zapcore.Level
+	// AccessLogSkipPaths are not access logged unless they fail.
+	AccessLogSkipPaths []string
+
+	// Warnings lists env values that could not be parsed and were
+	// replaced by their defaults. Load has no logger, so callers are
+	// expected to report these once logging is set up.
+	Warnings []Warning
+
+	// file holds values from CONFIG_FILE; env vars take precedence.
+	file map[string]string
+	// fileErr is a CONFIG_FILE read failure, surfaced by Validate since
+	// Load itself has no error return.
+	fileErr error
+	// secretErrs are unreadable <NAME>_FILE secrets, also for Validate.
+	secretErrs []error
+	// apiKeysErr is an unparseable API_KEYS, also for Validate.
+	apiKeysErr error
 }
 
+// Warning describes an env value that Load ignored in favour of a default.
+type Warning struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+}
 
+// Load reads configuration from the environment. When CONFIG_FILE is set,
+// that file supplies values for any variable the environment leaves unset.
+// main builds the logger with NewLogger once Load returns.
 func Load() *Config {
-    // Load .env if it exists (dev only)
-    _ = godotenv.Load()
-
-    return &Config{
-        Port:        getEnv("PORT", "8080"),
-        DBUrl:       os.Getenv("DATABASE_URL"),
-        TwilioSID:   os.Getenv("TWILIO_SID"),
-        TwilioToken: os.Getenv("TWILIO_TOKEN"),
-    }
-}
-
-func getEnv(key, fallback string) string {
-    val := os.Getenv(key)
-    if val == "" {
-        return fallback
-    }
-    return val
+	// Load .env if it exists (dev only)
+	_ = godotenv.Load()
+
+	cfg := &Config{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		cfg.file, cfg.fileErr = readFile(path)
+	}
+	cfg.load()
+	return cfg
+}
+
+// LoadFromFile reads a YAML or JSON config file and layers the environment
+// on top of it, so env vars always win over file values.
+func LoadFromFile(path string) (*Config, error) {
+	_ = godotenv.Load()
+
+	values, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{file: values}
+	cfg.load()
+	return cfg, nil
+}
+
+func (c *Config) load() {
+	c.Port = c.getEnv("PORT", "8080")
+	c.DBUrl = c.getSecret("DATABASE_URL")
+	c.TwilioSID = c.getEnv("TWILIO_SID", "")
+	c.TwilioToken = c.getSecret("TWILIO_TOKEN")
+	c.TwilioFrom = c.getEnv("TWILIO_FROM", "")
+	c.TwilioWebhookURL = c.getEnv("TWILIO_WEBHOOK_URL", "")
+	c.GovInfoAPIKey = c.getSecret("GOVINFO_API_KEY")
+	c.APIKey = c.getSecret("API_KEY")
+	c.APIKeys, c.apiKeysErr = parseAPIKeys(c.getSecret("API_KEYS"))
+
+	c.SMTPHost = c.getEnv("SMTP_HOST", "")
+	c.SMTPPort = c.getInt("SMTP_PORT", 587)
+	c.SMTPUser = c.getEnv("SMTP_USER", "")
+	c.SMTPPass = c.getSecret("SMTP_PASS")
+	c.SMTPFrom = c.getEnv("SMTP_FROM", "")
+
+	c.TwilioMaxAttempts = c.getInt("TWILIO_MAX_ATTEMPTS", 3)
+	c.SMSOutboxRPS = c.getFloat("SMS_OUTBOX_RPS", 1)
+	c.SMSOutboxMaxAttempts = c.getInt("SMS_OUTBOX_MAX_ATTEMPTS", 5)
+	c.SMSDedupWindow = c.getDuration("SMS_DEDUP_WINDOW", 24*time.Hour)
+	c.SMSDedupStore = c.getEnv("SMS_DEDUP_STORE", SMSDedupDatabase)
+	c.DispatchWorkers = c.getInt("DISPATCH_WORKERS", 5)
+	c.DispatchGrace = c.getDuration("DISPATCH_GRACE", 10*time.Second)
+	c.StreamMaxConnections = c.getInt("SSE_MAX_CONNECTIONS", 100)
+	c.WebhookMaxAttempts = c.getInt("WEBHOOK_MAX_ATTEMPTS", 5)
+	c.WebhookTimeout = c.getDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	c.PollInterval = c.getDuration("POLL_INTERVAL", 15*time.Minute)
+	c.PollCollectionTimeout = c.getDuration("POLL_COLLECTION_TIMEOUT", 5*time.Minute)
+	c.EnabledCollections = c.getList("ENABLED_COLLECTIONS", nil)
+	c.ConfirmationTTL = c.getDuration("CONFIRMATION_TTL", 15*time.Minute)
+	c.IdempotencyKeyTTL = c.getDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+
+	c.CollectionsCacheTTL = c.getDuration("COLLECTIONS_CACHE_TTL", time.Hour)
+	c.GovInfoRPS = c.getFloat("GOVINFO_RPS", 5)
+	c.GovInfoMaxConcurrency = c.getInt("GOVINFO_MAX_CONCURRENCY", 16)
+	c.GovInfoTimeout = c.getDuration("GOVINFO_TIMEOUT", 30*time.Second)
+	c.GovInfoBaseURL = c.getEnv("GOVINFO_BASE_URL", "https://api.govinfo.gov")
+	c.GovInfoUserAgent = c.getEnv("GOVINFO_USER_AGENT", "")
+	c.GovInfoMaxRetries = c.getInt("GOVINFO_MAX_RETRIES", 3)
+	c.GovInfoRetryBackoff = c.getDuration("GOVINFO_RETRY_BACKOFF", 500*time.Millisecond)
+	c.GovInfoRetryMaxBackoff = c.getDuration("GOVINFO_RETRY_MAX_BACKOFF", 10*time.Second)
+	c.GovInfoRetryBudget = c.getDuration("GOVINFO_RETRY_BUDGET", 30*time.Second)
+	c.GovInfoBreakerThreshold = c.getInt("GOVINFO_BREAKER_THRESHOLD", 5)
+	c.GovInfoBreakerCooldown = c.getDuration("GOVINFO_BREAKER_COOLDOWN", 30*time.Second)
+	c.GovInfoMaxBody = int64(c.getInt("GOVINFO_MAX_BODY", 10<<20))
+	c.GovInfoResponseCacheTTL = c.getDuration("GOVINFO_RESPONSE_CACHE_TTL", time.Hour)
+	c.GovInfoCacheDir = c.getEnv("GOVINFO_CACHE_DIR", "")
+	c.GovInfoCacheMode = c.getEnv("GOVINFO_CACHE_MODE", "")
+	c.GovInfoCacheTTL = c.getDuration("GOVINFO_CACHE_TTL", 24*time.Hour)
+	c.GovInfoMaxIdleConns = c.getInt("GOVINFO_MAX_IDLE_CONNS", 100)
+	c.GovInfoMaxIdleConnsPerHost = c.getInt("GOVINFO_MAX_IDLE_CONNS_PER_HOST", 20)
+	c.GovInfoIdleConnTimeout = c.getDuration("GOVINFO_IDLE_CONN_TIMEOUT", 90*time.Second)
+	c.GovInfoHTTPTimeout = c.getDuration("GOVINFO_HTTP_TIMEOUT", 5*time.Minute)
+	c.HTTPDebugLog = c.getBool("HTTP_DEBUG_LOG", false)
+
+	c.DBMaxConns = int32(c.getInt("DB_MAX_CONNS", 10))
+	c.DBMinConns = int32(c.getInt("DB_MIN_CONNS", 2))
+	c.DBMaxConnLifetime = c.getDuration("DB_MAX_CONN_LIFETIME", time.Hour)
+	c.SlowQueryThreshold = time.Duration(c.getInt("SLOW_QUERY_MS", 200)) * time.Millisecond
+	c.MigrateOnStart = c.getBool("MIGRATE_ON_START", false)
+
+	c.OTLPTracesEndpoint = c.getEnv("OTLP_TRACES_ENDPOINT", "")
+
+	c.TLSCertFile = c.getEnv("TLS_CERT_FILE", "")
+	c.TLSKeyFile = c.getEnv("TLS_KEY_FILE", "")
+	c.ReadTimeout = c.getDuration("READ_TIMEOUT", 5*time.Second)
+	c.WriteTimeout = c.getDuration("WRITE_TIMEOUT", 10*time.Second)
+	c.RequestTimeout = c.getDuration("REQUEST_TIMEOUT", 30*time.Second)
+	c.IdleTimeout = c.getDuration("IDLE_TIMEOUT", 120*time.Second)
+	c.ShutdownTimeout = c.getDuration("SHUTDOWN_TIMEOUT", 15*time.Second)
+	c.ShutdownDrainDelay = c.getDuration("SHUTDOWN_DRAIN_DELAY", 0)
+
+	c.RateLimitRPS = c.getFloat("RATE_LIMIT_RPS", 10)
+	c.RateLimitBurst = c.getInt("RATE_LIMIT_BURST", 20)
+	c.TrustProxyHeaders = c.getBool("TRUST_PROXY_HEADERS", false)
+
+	c.CORSAllowedOrigins = c.getList("CORS_ALLOWED_ORIGINS", nil)
+	c.CORSAllowedMethods = c.getList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
+	c.CORSAllowedHeaders = c.getList("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", "Idempotency-Key", "X-API-Key", "X-Request-ID"})
+	c.CORSAllowCredentials = c.getBool("CORS_ALLOW_CREDENTIALS", false)
+
+	c.Env = c.getEnv("ENV", EnvProduction)
+	c.LogLevel = c.getLevel("LOG_LEVEL", zapcore.InfoLevel)
+	c.level = zap.NewAtomicLevelAt(c.LogLevel)
+	sampleDefault := 100
+	if c.Env == EnvDevelopment {
+		sampleDefault = 0
+	}
+	c.LogSampleInitial = c.getInt("LOG_SAMPLE_INITIAL", sampleDefault)
+	c.LogSampleThereafter = c.getInt("LOG_SAMPLE_THEREAFTER", sampleDefault)
+	c.AccessLogClientErrorLevel = c.getLevel("ACCESS_LOG_CLIENT_ERROR_LEVEL", zapcore.WarnLevel)
+	c.AccessLogServerErrorLevel = c.getLevel("ACCESS_LOG_SERVER_ERROR_LEVEL", zapcore.ErrorLevel)
+	c.AccessLogSkipPaths = c.getList("ACCESS_LOG_SKIP_PATHS", []string{"/healthz", "/readyz", "/metrics"})
+}
+
+// RateLimitEnabled reports whether requests should be rate limited at
+// all: not when RATE_LIMIT_RPS is zero, and not in development.
+func (c *Config) RateLimitEnabled() bool {
+	return c.RateLimitRPS > 0 && c.Env != EnvDevelopment
+}
+
+// lookup returns the env value for key, falling back to the config file.
+func (c *Config) lookup(key string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return c.file[key]
+}
+
+// getSecret reads a secret that may instead be mounted as a file: when key
+// isn't set in the environment, key_FILE names a file holding the value,
+// with trailing whitespace trimmed. The config file is the last resort.
+func (c *Config) getSecret(key string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	if path := c.lookup(key + "_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			c.secretErrs = append(c.secretErrs, fmt.Errorf("%s_FILE: %w", key, err))
+			return ""
+		}
+		return strings.TrimRight(string(b), " \t\r\n")
+	}
+	return c.file[key]
+}
+
+func (c *Config) getEnv(key, fallback string) string {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	return val
+}
+
+// getList splits a comma-separated value, trimming blanks.
+func (c *Config) getList(key string, fallback []string) []string {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	var out []string
+	for _, part := range strings.Split(val, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func (c *Config) getDuration(key string, fallback time.Duration) time.Duration {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		c.Warnings = append(c.Warnings, Warning{Key: key, Value: val, Default: fallback.String()})
+		return fallback
+	}
+	return d
+}
+
+func (c *Config) getInt(key string, fallback int) int {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		c.Warnings = append(c.Warnings, Warning{Key: key, Value: val, Default: strconv.Itoa(fallback)})
+		return fallback
+	}
+	return n
+}
+
+func (c *Config) getFloat(key string, fallback float64) float64 {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		c.Warnings = append(c.Warnings, Warning{Key: key, Value: val, Default: strconv.FormatFloat(fallback, 'g', -1, 64)})
+		return fallback
+	}
+	return f
+}
+
+func (c *Config) getBool(key string, fallback bool) bool {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		c.Warnings = append(c.Warnings, Warning{Key: key, Value: val, Default: strconv.FormatBool(fallback)})
+		return fallback
+	}
+	return b
+}
+
+func (c *Config) getLevel(key string, fallback zapcore.Level) zapcore.Level {
+	val := c.lookup(key)
+	if val == "" {
+		return fallback
+	}
+	level, err := zapcore.ParseLevel(val)
+	if err != nil {
+		c.Warnings = append(c.Warnings, Warning{Key: key, Value: val, Default: fallback.String()})
+		return fallback
+	}
+	return level
 }
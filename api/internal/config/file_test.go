@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFileEnvWins(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "PORT: 9090\nGOVINFO_TIMEOUT: 5m\nTWILIO_SID: file-sid\nCORS_ALLOWED_ORIGINS: [https://a.example.com, https://b.example.com]\n",
+		"config.json": `{"PORT": 9090, "GOVINFO_TIMEOUT": "5m", "TWILIO_SID": "file-sid", "CORS_ALLOWED_ORIGINS": ["https://a.example.com", "https://b.example.com"]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("PORT", "")
+			t.Setenv("GOVINFO_TIMEOUT", "")
+			t.Setenv("CORS_ALLOWED_ORIGINS", "")
+			t.Setenv("TWILIO_SID", "env-sid")
+
+			cfg, err := LoadFromFile(writeConfigFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Port != "9090" {
+				t.Errorf("Port = %q, want the file's 9090", cfg.Port)
+			}
+			if cfg.GovInfoTimeout != 5*time.Minute {
+				t.Errorf("GovInfoTimeout = %s, want the file's 5m", cfg.GovInfoTimeout)
+			}
+			if want := []string{"https://a.example.com", "https://b.example.com"}; !slices.Equal(cfg.CORSAllowedOrigins, want) {
+				t.Errorf("CORSAllowedOrigins = %v, want %v", cfg.CORSAllowedOrigins, want)
+			}
+			if cfg.TwilioSID != "env-sid" {
+				t.Errorf("TwilioSID = %q, want the env's env-sid", cfg.TwilioSID)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileErrors(t *testing.T) {
+	if _, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file: no error")
+	}
+	if _, err := LoadFromFile(writeConfigFile(t, "bad.json", "{")); err == nil {
+		t.Error("malformed JSON: no error")
+	}
+	if _, err := LoadFromFile(writeConfigFile(t, "nested.yaml", "PORT:\n  value: 1\n")); err == nil {
+		t.Error("nested value: no error")
+	}
+}
+
+func TestLoadUsesConfigFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "config.yaml", "PORT: 9191\n"))
+	t.Setenv("PORT", "")
+	if cfg := Load(); cfg.Port != "9191" {
+		t.Errorf("Port = %q, want 9191 from CONFIG_FILE", cfg.Port)
+	}
+
+	t.Setenv("PORT", "7070")
+	if cfg := Load(); cfg.Port != "7070" {
+		t.Errorf("Port = %q, want 7070 from the env", cfg.Port)
+	}
+}
+
+func TestLoadReportsUnreadableConfigFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	err := Load().Validate()
+	if err == nil || !strings.Contains(err.Error(), "config file") {
+		t.Errorf("Validate = %v, want the config file error", err)
+	}
+}
+
+func TestLoadEnabledCollections(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("ENABLED_COLLECTIONS", " BILLS, FR,,PLAW ")
+	if got := Load().EnabledCollections; strings.Join(got, "|") != "BILLS|FR|PLAW" {
+		t.Errorf("EnabledCollections = %q, want BILLS, FR and PLAW", got)
+	}
+
+	t.Setenv("ENABLED_COLLECTIONS", "")
+	if got := Load().EnabledCollections; got != nil {
+		t.Errorf("EnabledCollections = %q, want nil for every collection", got)
+	}
+}
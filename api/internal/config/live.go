@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Live holds the running Config and swaps in a reloaded one. The process
+// environment can't change after start, so in practice reloads pick up
+// edits to CONFIG_FILE.
+type Live struct {
+	p atomic.Pointer[Config]
+}
+
+// NewLive returns a Live serving cfg until the first Reload.
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.p.Store(cfg)
+	return l
+}
+
+// Current returns the active Config. Callers must not modify it.
+func (l *Live) Current() *Config {
+	return l.p.Load()
+}
+
+// Reload re-reads the configuration and swaps in its runtime-adjustable
+// fields: LOG_LEVEL, RATE_LIMIT_RPS, RATE_LIMIT_BURST and POLL_INTERVAL.
+// It returns the new Config and the keys of any other settings that
+// changed, which only take effect on restart. An invalid configuration is
+// rejected and the current one kept.
+func (l *Live) Reload() (*Config, []string, error) {
+	loaded := Load()
+	if err := loaded.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("reload: %w", err)
+	}
+
+	cur := l.Current()
+	next := *cur
+	next.LogLevel = loaded.LogLevel
+	next.RateLimitRPS = loaded.RateLimitRPS
+	next.RateLimitBurst = loaded.RateLimitBurst
+	next.PollInterval = loaded.PollInterval
+	next.Warnings = loaded.Warnings
+
+	l.p.Store(&next)
+	return &next, staticChanges(cur, loaded), nil
+}
+
+// staticChanges lists settings that differ between a and b but can't be
+// applied without a restart.
+func staticChanges(a, b *Config) []string {
+	fields := []struct {
+		env     string
+		changed bool
+	}{
+		{"ENV", a.Env != b.Env},
+		{"LOG_SAMPLE_INITIAL", a.LogSampleInitial != b.LogSampleInitial},
+		{"LOG_SAMPLE_THEREAFTER", a.LogSampleThereafter != b.LogSampleThereafter},
+		{"PORT", a.Port != b.Port},
+		{"DATABASE_URL", a.DBUrl != b.DBUrl},
+		{"TWILIO_SID", a.TwilioSID != b.TwilioSID},
+		{"TWILIO_TOKEN", a.TwilioToken != b.TwilioToken},
+		{"TWILIO_FROM", a.TwilioFrom != b.TwilioFrom},
+		{"TWILIO_WEBHOOK_URL", a.TwilioWebhookURL != b.TwilioWebhookURL},
+		{"TWILIO_MAX_ATTEMPTS", a.TwilioMaxAttempts != b.TwilioMaxAttempts},
+		{"SMTP_HOST", a.SMTPHost != b.SMTPHost},
+		{"SMTP_PORT", a.SMTPPort != b.SMTPPort},
+		{"SMTP_USER", a.SMTPUser != b.SMTPUser},
+		{"SMTP_PASS", a.SMTPPass != b.SMTPPass},
+		{"SMTP_FROM", a.SMTPFrom != b.SMTPFrom},
+		{"GOVINFO_API_KEY", a.GovInfoAPIKey != b.GovInfoAPIKey},
+		{"API_KEY", a.APIKey != b.APIKey},
+		{"API_KEYS", !reflect.DeepEqual(a.APIKeys, b.APIKeys)},
+		{"SMS_OUTBOX_RPS", a.SMSOutboxRPS != b.SMSOutboxRPS},
+		{"SMS_OUTBOX_MAX_ATTEMPTS", a.SMSOutboxMaxAttempts != b.SMSOutboxMaxAttempts},
+		{"SMS_DEDUP_WINDOW", a.SMSDedupWindow != b.SMSDedupWindow},
+		{"SMS_DEDUP_STORE", a.SMSDedupStore != b.SMSDedupStore},
+		{"CONFIRMATION_TTL", a.ConfirmationTTL != b.ConfirmationTTL},
+		{"COLLECTIONS_CACHE_TTL", a.CollectionsCacheTTL != b.CollectionsCacheTTL},
+		{"POLL_COLLECTION_TIMEOUT", a.PollCollectionTimeout != b.PollCollectionTimeout},
+		{"ENABLED_COLLECTIONS", !reflect.DeepEqual(a.EnabledCollections, b.EnabledCollections)},
+		{"IDEMPOTENCY_KEY_TTL", a.IdempotencyKeyTTL != b.IdempotencyKeyTTL},
+		{"DISPATCH_WORKERS", a.DispatchWorkers != b.DispatchWorkers},
+		{"DISPATCH_GRACE", a.DispatchGrace != b.DispatchGrace},
+		{"SSE_MAX_CONNECTIONS", a.StreamMaxConnections != b.StreamMaxConnections},
+		{"WEBHOOK_MAX_ATTEMPTS", a.WebhookMaxAttempts != b.WebhookMaxAttempts},
+		{"WEBHOOK_TIMEOUT", a.WebhookTimeout != b.WebhookTimeout},
+		{"GOVINFO_RPS", a.GovInfoRPS != b.GovInfoRPS},
+		{"GOVINFO_MAX_CONCURRENCY", a.GovInfoMaxConcurrency != b.GovInfoMaxConcurrency},
+		{"GOVINFO_TIMEOUT", a.GovInfoTimeout != b.GovInfoTimeout},
+		{"OTLP_TRACES_ENDPOINT", a.OTLPTracesEndpoint != b.OTLPTracesEndpoint},
+		{"GOVINFO_BASE_URL", a.GovInfoBaseURL != b.GovInfoBaseURL},
+		{"GOVINFO_MAX_RETRIES", a.GovInfoMaxRetries != b.GovInfoMaxRetries},
+		{"GOVINFO_RETRY_BACKOFF", a.GovInfoRetryBackoff != b.GovInfoRetryBackoff},
+		{"GOVINFO_RETRY_MAX_BACKOFF", a.GovInfoRetryMaxBackoff != b.GovInfoRetryMaxBackoff},
+		{"GOVINFO_RETRY_BUDGET", a.GovInfoRetryBudget != b.GovInfoRetryBudget},
+		{"GOVINFO_USER_AGENT", a.GovInfoUserAgent != b.GovInfoUserAgent},
+		{"GOVINFO_BREAKER_THRESHOLD", a.GovInfoBreakerThreshold != b.GovInfoBreakerThreshold},
+		{"GOVINFO_BREAKER_COOLDOWN", a.GovInfoBreakerCooldown != b.GovInfoBreakerCooldown},
+		{"GOVINFO_MAX_BODY", a.GovInfoMaxBody != b.GovInfoMaxBody},
+		{"GOVINFO_RESPONSE_CACHE_TTL", a.GovInfoResponseCacheTTL != b.GovInfoResponseCacheTTL},
+		{"GOVINFO_CACHE_DIR", a.GovInfoCacheDir != b.GovInfoCacheDir},
+		{"GOVINFO_CACHE_MODE", a.GovInfoCacheMode != b.GovInfoCacheMode},
+		{"GOVINFO_CACHE_TTL", a.GovInfoCacheTTL != b.GovInfoCacheTTL},
+		{"GOVINFO_MAX_IDLE_CONNS", a.GovInfoMaxIdleConns != b.GovInfoMaxIdleConns},
+		{"GOVINFO_MAX_IDLE_CONNS_PER_HOST", a.GovInfoMaxIdleConnsPerHost != b.GovInfoMaxIdleConnsPerHost},
+		{"GOVINFO_IDLE_CONN_TIMEOUT", a.GovInfoIdleConnTimeout != b.GovInfoIdleConnTimeout},
+		{"GOVINFO_HTTP_TIMEOUT", a.GovInfoHTTPTimeout != b.GovInfoHTTPTimeout},
+		{"HTTP_DEBUG_LOG", a.HTTPDebugLog != b.HTTPDebugLog},
+		{"DB_MAX_CONNS", a.DBMaxConns != b.DBMaxConns},
+		{"DB_MIN_CONNS", a.DBMinConns != b.DBMinConns},
+		{"DB_MAX_CONN_LIFETIME", a.DBMaxConnLifetime != b.DBMaxConnLifetime},
+		{"SLOW_QUERY_MS", a.SlowQueryThreshold != b.SlowQueryThreshold},
+		{"MIGRATE_ON_START", a.MigrateOnStart != b.MigrateOnStart},
+		{"TLS_CERT_FILE", a.TLSCertFile != b.TLSCertFile},
+		{"TLS_KEY_FILE", a.TLSKeyFile != b.TLSKeyFile},
+		{"READ_TIMEOUT", a.ReadTimeout != b.ReadTimeout},
+		{"WRITE_TIMEOUT", a.WriteTimeout != b.WriteTimeout},
+		{"REQUEST_TIMEOUT", a.RequestTimeout != b.RequestTimeout},
+		{"IDLE_TIMEOUT", a.IdleTimeout != b.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", a.ShutdownTimeout != b.ShutdownTimeout},
+		{"SHUTDOWN_DRAIN_DELAY", a.ShutdownDrainDelay != b.ShutdownDrainDelay},
+		{"TRUST_PROXY_HEADERS", a.TrustProxyHeaders != b.TrustProxyHeaders},
+		{"CORS_ALLOWED_ORIGINS", !reflect.DeepEqual(a.CORSAllowedOrigins, b.CORSAllowedOrigins)},
+		{"CORS_ALLOWED_METHODS", !reflect.DeepEqual(a.CORSAllowedMethods, b.CORSAllowedMethods)},
+		{"CORS_ALLOWED_HEADERS", !reflect.DeepEqual(a.CORSAllowedHeaders, b.CORSAllowedHeaders)},
+		{"CORS_ALLOW_CREDENTIALS", a.CORSAllowCredentials != b.CORSAllowCredentials},
+		{"ACCESS_LOG_CLIENT_ERROR_LEVEL", a.AccessLogClientErrorLevel != b.AccessLogClientErrorLevel},
+		{"ACCESS_LOG_SERVER_ERROR_LEVEL", a.AccessLogServerErrorLevel != b.AccessLogServerErrorLevel},
+		{"ACCESS_LOG_SKIP_PATHS", !reflect.DeepEqual(a.AccessLogSkipPaths, b.AccessLogSkipPaths)},
+	}
+	var changed []string
+	for _, f := range fields {
+		if f.changed {
+			changed = append(changed, f.env)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStaticChangesNamesRestartOnlySettings(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for _, tc := range []struct{ env, value string }{
+		{"ENV", "staging"},
+		{"LOG_SAMPLE_INITIAL", "7"},
+		{"LOG_SAMPLE_THEREAFTER", "7"},
+		{"PORT", "9999"},
+		{"DATABASE_URL", "postgres://db.example.com/govinfo"},
+		{"TWILIO_SID", "changed"},
+		{"TWILIO_TOKEN", "changed"},
+		{"TWILIO_FROM", "changed"},
+		{"TWILIO_WEBHOOK_URL", "changed"},
+		{"TWILIO_MAX_ATTEMPTS", "7"},
+		{"SMTP_HOST", "changed"},
+		{"SMTP_PORT", "7"},
+		{"SMTP_USER", "changed"},
+		{"SMTP_PASS", "changed"},
+		{"SMTP_FROM", "changed"},
+		{"GOVINFO_API_KEY", "changed"},
+		{"API_KEY", "changed"},
+		{"API_KEYS", "ci:read:abc"},
+		{"SMS_OUTBOX_RPS", "7"},
+		{"SMS_OUTBOX_MAX_ATTEMPTS", "7"},
+		{"SMS_DEDUP_WINDOW", "7s"},
+		{"SMS_DEDUP_STORE", "changed"},
+		{"CONFIRMATION_TTL", "7s"},
+		{"IDEMPOTENCY_KEY_TTL", "7s"},
+		{"COLLECTIONS_CACHE_TTL", "7s"},
+		{"POLL_COLLECTION_TIMEOUT", "7s"},
+		{"ENABLED_COLLECTIONS", "BILLS,FR"},
+		{"DISPATCH_WORKERS", "7"},
+		{"DISPATCH_GRACE", "7s"},
+		{"SSE_MAX_CONNECTIONS", "7"},
+		{"WEBHOOK_MAX_ATTEMPTS", "7"},
+		{"WEBHOOK_TIMEOUT", "7s"},
+		{"GOVINFO_RPS", "7"},
+		{"GOVINFO_MAX_CONCURRENCY", "7"},
+		{"GOVINFO_TIMEOUT", "7s"},
+		{"GOVINFO_BASE_URL", "https://govinfo.example.com"},
+		{"GOVINFO_MAX_RETRIES", "7"},
+		{"GOVINFO_RETRY_BACKOFF", "7s"},
+		{"GOVINFO_RETRY_MAX_BACKOFF", "7s"},
+		{"GOVINFO_RETRY_BUDGET", "7s"},
+		{"OTLP_TRACES_ENDPOINT", "changed"},
+		{"GOVINFO_USER_AGENT", "changed"},
+		{"GOVINFO_BREAKER_THRESHOLD", "7"},
+		{"GOVINFO_BREAKER_COOLDOWN", "7s"},
+		{"GOVINFO_MAX_BODY", "7"},
+		{"GOVINFO_RESPONSE_CACHE_TTL", "7s"},
+		{"GOVINFO_CACHE_DIR", "changed"},
+		{"GOVINFO_CACHE_MODE", "changed"},
+		{"GOVINFO_CACHE_TTL", "7s"},
+		{"GOVINFO_MAX_IDLE_CONNS", "7"},
+		{"GOVINFO_MAX_IDLE_CONNS_PER_HOST", "7"},
+		{"GOVINFO_IDLE_CONN_TIMEOUT", "7s"},
+		{"GOVINFO_HTTP_TIMEOUT", "7s"},
+		{"HTTP_DEBUG_LOG", "true"},
+		{"DB_MAX_CONNS", "7"},
+		{"DB_MIN_CONNS", "7"},
+		{"DB_MAX_CONN_LIFETIME", "7s"},
+		{"SLOW_QUERY_MS", "7"},
+		{"MIGRATE_ON_START", "true"},
+		{"TLS_CERT_FILE", "changed"},
+		{"TLS_KEY_FILE", "changed"},
+		{"READ_TIMEOUT", "7s"},
+		{"WRITE_TIMEOUT", "7s"},
+		{"REQUEST_TIMEOUT", "7s"},
+		{"IDLE_TIMEOUT", "7s"},
+		{"SHUTDOWN_TIMEOUT", "7s"},
+		{"SHUTDOWN_DRAIN_DELAY", "7s"},
+		{"TRUST_PROXY_HEADERS", "true"},
+		{"CORS_ALLOWED_ORIGINS", "a,b"},
+		{"CORS_ALLOWED_METHODS", "a,b"},
+		{"CORS_ALLOWED_HEADERS", "a,b"},
+		{"CORS_ALLOW_CREDENTIALS", "true"},
+		{"ACCESS_LOG_CLIENT_ERROR_LEVEL", "debug"},
+		{"ACCESS_LOG_SERVER_ERROR_LEVEL", "debug"},
+		{"ACCESS_LOG_SKIP_PATHS", "a,b"},
+	} {
+		t.Run(tc.env, func(t *testing.T) {
+			t.Setenv(tc.env, "")
+			before := Load()
+			t.Setenv(tc.env, tc.value)
+			if got := staticChanges(before, Load()); !slices.Equal(got, []string{tc.env}) {
+				t.Errorf("changing %s to %q reported %v", tc.env, tc.value, got)
+			}
+		})
+	}
+}
+
+func TestStaticChangesSkipsReloadableSettings(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for _, tc := range []struct{ env, value string }{
+		{"LOG_LEVEL", "debug"},
+		{"RATE_LIMIT_RPS", "7"},
+		{"RATE_LIMIT_BURST", "7"},
+		{"POLL_INTERVAL", "7s"},
+	} {
+		t.Run(tc.env, func(t *testing.T) {
+			t.Setenv(tc.env, "")
+			before := Load()
+			t.Setenv(tc.env, tc.value)
+			if got := staticChanges(before, Load()); len(got) != 0 {
+				t.Errorf("changing %s reported %v, want nothing: it applies on reload", tc.env, got)
+			}
+		})
+	}
+}
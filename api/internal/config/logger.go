@@ -0,0 +1,54 @@
+package config
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Environments selectable with ENV.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// NewLogger builds the process logger. Development uses a colored console
+// encoder; anything else gets production JSON. The level is c.AtomicLevel,
+// so changes to it apply to the returned logger. Sampling follows
+// LogSampleInitial and LogSampleThereafter.
+func NewLogger(c *Config) (*zap.Logger, error) {
+	zc := loggerConfig(c)
+	var opts []zap.Option
+	if c.LogSampleInitial > 0 {
+		opts = append(opts, samplingOption(c))
+	}
+	return zc.Build(opts...)
+}
+
+// samplingOption wraps the core in a sampler over one-second ticks.
+func samplingOption(c *Config) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, time.Second, c.LogSampleInitial, c.LogSampleThereafter)
+	})
+}
+
+func loggerConfig(c *Config) zap.Config {
+	var zc zap.Config
+	if c.Env == EnvDevelopment {
+		zc = zap.NewDevelopmentConfig()
+		zc.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	} else {
+		zc = zap.NewProductionConfig()
+	}
+	zc.Level = c.AtomicLevel()
+	// Sampling is applied by NewLogger from our own settings instead.
+	zc.Sampling = nil
+	return zc
+}
+
+// AtomicLevel returns the live log level, starting at LogLevel. It is
+// shared by every logger built from c and by configs reloaded from it.
+func (c *Config) AtomicLevel() zap.AtomicLevel {
+	return c.level
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewLoggerPerEnv(t *testing.T) {
+	for _, tc := range []struct {
+		env, level   string
+		wantEncoding string
+		wantLevel    zapcore.Level
+	}{
+		{EnvDevelopment, "debug", "console", zapcore.DebugLevel},
+		{EnvProduction, "warn", "json", zapcore.WarnLevel},
+		{EnvProduction, "", "json", zapcore.InfoLevel},
+	} {
+		t.Run(tc.env+"/"+tc.level, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("ENV", tc.env)
+			t.Setenv("LOG_LEVEL", tc.level)
+			cfg := Load()
+
+			zc := loggerConfig(cfg)
+			if zc.Encoding != tc.wantEncoding {
+				t.Errorf("encoding = %q, want %q", zc.Encoding, tc.wantEncoding)
+			}
+			if zc.Development != (tc.env == EnvDevelopment) {
+				t.Errorf("development = %v", zc.Development)
+			}
+
+			logger, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			core := logger.Core()
+			if !core.Enabled(tc.wantLevel) || (tc.wantLevel > zapcore.DebugLevel && core.Enabled(tc.wantLevel-1)) {
+				t.Errorf("logger level does not start at %s", tc.wantLevel)
+			}
+
+			cfg.AtomicLevel().SetLevel(zapcore.ErrorLevel)
+			if core.Enabled(zapcore.WarnLevel) {
+				t.Error("level change did not reach the logger")
+			}
+		})
+	}
+}
+
+func TestSamplingDropsRepeatedEntries(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	c := &Config{LogSampleInitial: 2, LogSampleThereafter: 5}
+	logger := zap.New(core, samplingOption(c))
+
+	for range 12 {
+		logger.Info("same message")
+	}
+	logger.Info("other message")
+
+	// Two entries pass, then every fifth: the 7th and the 12th.
+	if n := logs.FilterMessage("same message").Len(); n != 4 {
+		t.Errorf("kept %d of 12 identical entries, want 4", n)
+	}
+	if n := logs.FilterMessage("other message").Len(); n != 1 {
+		t.Errorf("kept %d distinct entries, want 1", n)
+	}
+}
+
+func TestSamplingDisabledInDevelopment(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("LOG_SAMPLE_INITIAL", "")
+	t.Setenv("LOG_SAMPLE_THEREAFTER", "")
+
+	t.Setenv("ENV", EnvDevelopment)
+	if c := Load(); c.LogSampleInitial != 0 {
+		t.Errorf("development LogSampleInitial = %d, want 0", c.LogSampleInitial)
+	}
+	t.Setenv("ENV", EnvProduction)
+	if c := Load(); c.LogSampleInitial <= 0 {
+		t.Errorf("production LogSampleInitial = %d, want sampling on", c.LogSampleInitial)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// redactedValue replaces every set sensitive field in Redacted.
+const redactedValue = "***"
+
+// Redacted returns c's exported settings keyed by camelCase field name,
+// for display. Fields tagged sensitive:"true" read "***" when set, so a
+// new secret is covered by tagging it. Durations are shown as strings
+// such as "15m0s".
+func (c *Config) Redacted() map[string]any {
+	out := make(map[string]any)
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		val := v.Field(i)
+		switch {
+		case field.Tag.Get("sensitive") == "true":
+			if val.IsZero() {
+				out[camelCase(field.Name)] = ""
+			} else {
+				out[camelCase(field.Name)] = redactedValue
+			}
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			out[camelCase(field.Name)] = time.Duration(val.Int()).String()
+		default:
+			out[camelCase(field.Name)] = val.Interface()
+		}
+	}
+	return out
+}
+
+// camelCase lowers a Go field name's leading initialism or word, so
+// SMTPHost becomes smtpHost and APIKey becomes apiKey.
+func camelCase(name string) string {
+	r := []rune(name)
+	n := 0
+	for n < len(r) && unicode.IsUpper(r[n]) {
+		n++
+	}
+	// In SMTPHost the H starts the next word and stays upper case.
+	if n > 1 && n < len(r) {
+		n--
+	}
+	return strings.ToLower(string(r[:n])) + string(r[n:])
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSecrets returns a config with every sensitive field set to a value
+// that names it, so a leak is easy to spot.
+func withSecrets(t *testing.T) (*Config, []string) {
+	t.Helper()
+	c := &Config{Port: "8080", PollInterval: 15 * time.Minute}
+	v := reflect.ValueOf(c).Elem()
+	var secrets []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("sensitive") != "true" {
+			continue
+		}
+		secret := "secret-" + field.Name
+		secrets = append(secrets, secret)
+		switch {
+		case field.Type.Kind() == reflect.String:
+			v.Field(i).SetString(secret)
+		case field.Type == reflect.TypeOf([]APIKey(nil)):
+			v.Field(i).Set(reflect.ValueOf([]APIKey{{Name: "ci", Key: secret, Scopes: []string{ScopeRead}}}))
+		default:
+			t.Fatalf("sensitive field %s has type %s; teach withSecrets to set it", field.Name, field.Type)
+		}
+	}
+	return c, secrets
+}
+
+func TestRedactedHidesSecrets(t *testing.T) {
+	c, secrets := withSecrets(t)
+	if len(secrets) == 0 {
+		t.Fatal("no fields are tagged sensitive")
+	}
+
+	out, err := json.Marshal(c.Redacted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("Redacted output contains %q: %s", secret, out)
+		}
+	}
+
+	got := c.Redacted()
+	for _, key := range []string{"dbUrl", "twilioToken", "govInfoAPIKey", "apiKey", "apiKeys", "smtpPass"} {
+		if got[key] != redactedValue {
+			t.Errorf("%s = %v, want %q", key, got[key], redactedValue)
+		}
+	}
+	if got["port"] != "8080" {
+		t.Errorf("port = %v, want the plain value", got["port"])
+	}
+	if got["pollInterval"] != "15m0s" {
+		t.Errorf("pollInterval = %v, want a duration string", got["pollInterval"])
+	}
+}
+
+func TestRedactedLeavesUnsetSecretsEmpty(t *testing.T) {
+	got := (&Config{}).Redacted()
+	if got["twilioToken"] != "" {
+		t.Errorf("unset twilioToken = %v, want empty so it reads as missing", got["twilioToken"])
+	}
+}
+
+// A secret added without the tag would be shown in the clear.
+func TestSecretLookingFieldsAreSensitive(t *testing.T) {
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Name
+		looksSecret := strings.HasSuffix(name, "Token") || strings.HasSuffix(name, "Pass") ||
+			strings.HasSuffix(name, "Password") || strings.HasSuffix(name, "Secret") ||
+			strings.HasSuffix(name, "APIKey") || name == "APIKeys"
+		if looksSecret && field.Tag.Get("sensitive") != "true" {
+			t.Errorf("Config.%s looks like a secret but isn't tagged sensitive", name)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Port":          "port",
+		"DBUrl":         "dbUrl",
+		"APIKey":        "apiKey",
+		"SMTPHost":      "smtpHost",
+		"GovInfoAPIKey": "govInfoAPIKey",
+		"TLS":           "tls",
+	} {
+		if got := camelCase(name); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretFromFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TWILIO_TOKEN", "")
+	t.Setenv("TWILIO_TOKEN_FILE", writeConfigFile(t, "twilio_token", "s3cret \t\r\n\n"))
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("DATABASE_URL_FILE", writeConfigFile(t, "database_url", "postgres://db/govinfo\n"))
+
+	cfg := Load()
+	if cfg.TwilioToken != "s3cret" {
+		t.Errorf("TwilioToken = %q, want the file's value with trailing whitespace trimmed", cfg.TwilioToken)
+	}
+	if cfg.DBUrl != "postgres://db/govinfo" {
+		t.Errorf("DBUrl = %q, want the file's value", cfg.DBUrl)
+	}
+	if len(cfg.secretErrs) != 0 {
+		t.Errorf("secretErrs = %v, want none", cfg.secretErrs)
+	}
+}
+
+func TestSecretEnvWinsOverFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("API_KEY", "from-env")
+	t.Setenv("API_KEY_FILE", writeConfigFile(t, "api_key", "from-file"))
+
+	if got := Load().APIKey; got != "from-env" {
+		t.Errorf("APIKey = %q, want the env value", got)
+	}
+}
+
+func TestSecretLeadingWhitespaceKept(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("SMTP_PASS", "")
+	t.Setenv("SMTP_PASS_FILE", writeConfigFile(t, "smtp_pass", "  pass word\n"))
+
+	if got := Load().SMTPPass; got != "  pass word" {
+		t.Errorf("SMTPPass = %q, want only trailing whitespace trimmed", got)
+	}
+}
+
+func TestSecretFileNamedInConfigFile(t *testing.T) {
+	secret := writeConfigFile(t, "govinfo_key", "gov-key\n")
+	t.Setenv("GOVINFO_API_KEY", "")
+	t.Setenv("GOVINFO_API_KEY_FILE", "")
+
+	cfg, err := LoadFromFile(writeConfigFile(t, "config.yaml", "GOVINFO_API_KEY: plain\nGOVINFO_API_KEY_FILE: "+secret+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GovInfoAPIKey != "gov-key" {
+		t.Errorf("GovInfoAPIKey = %q, want the _FILE value over the plain file value", cfg.GovInfoAPIKey)
+	}
+}
+
+func TestUnreadableSecretFileFailsValidate(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TWILIO_TOKEN", "")
+	t.Setenv("TWILIO_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	cfg := Load()
+	if cfg.TwilioToken != "" {
+		t.Errorf("TwilioToken = %q, want empty for an unreadable file", cfg.TwilioToken)
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "TWILIO_TOKEN_FILE") {
+		t.Fatalf("Validate = %v, want the TWILIO_TOKEN_FILE read error", err)
+	}
+	if !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Validate = %v, want the file path in the error", err)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// Validate reports every required setting that is missing or malformed.
+// The returned error joins one error per problem so all of them can be
+// fixed in a single pass.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.fileErr != nil {
+		errs = append(errs, c.fileErr)
+	}
+	errs = append(errs, c.secretErrs...)
+	if c.apiKeysErr != nil {
+		errs = append(errs, c.apiKeysErr)
+	}
+
+	required := []struct {
+		env, val string
+	}{
+		{"DATABASE_URL", c.DBUrl},
+		{"TWILIO_SID", c.TwilioSID},
+		{"TWILIO_TOKEN", c.TwilioToken},
+		{"TWILIO_FROM", c.TwilioFrom},
+	}
+	for _, r := range required {
+		if r.val == "" {
+			errs = append(errs, fmt.Errorf("%s is required", r.env))
+		}
+	}
+	if c.APIKey == "" && len(c.APIKeys) == 0 {
+		errs = append(errs, errors.New("API_KEY or API_KEYS is required"))
+	}
+	errs = append(errs, validateAPIKeys(c.AllAPIKeys())...)
+
+	// Port 0 is allowed and asks the kernel for any free port.
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT %q must be a number between 0 and 65535", c.Port))
+	}
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		errs = append(errs, fmt.Errorf("ENV %q must be %s or %s", c.Env, EnvDevelopment, EnvProduction))
+	}
+
+	if c.SMTPHost != "" && c.SMTPFrom == "" {
+		errs = append(errs, errors.New("SMTP_FROM is required when SMTP_HOST is set"))
+	}
+
+	if c.LogSampleInitial < 0 || c.LogSampleThereafter < 0 {
+		errs = append(errs, errors.New("LOG_SAMPLE_INITIAL and LOG_SAMPLE_THEREAFTER must not be negative"))
+	}
+
+	if c.RateLimitRPS < 0 {
+		errs = append(errs, errors.New("RATE_LIMIT_RPS must not be negative"))
+	}
+
+	if c.SMSOutboxRPS <= 0 {
+		errs = append(errs, errors.New("SMS_OUTBOX_RPS must be positive"))
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+
+	if c.RequestTimeout < 0 {
+		errs = append(errs, errors.New("REQUEST_TIMEOUT must not be negative"))
+	}
+	if c.ShutdownDrainDelay < 0 {
+		errs = append(errs, errors.New("SHUTDOWN_DRAIN_DELAY must not be negative"))
+	}
+
+	if c.SMSDedupWindow < 0 {
+		errs = append(errs, errors.New("SMS_DEDUP_WINDOW must not be negative"))
+	}
+	if c.SMSDedupStore != SMSDedupDatabase && c.SMSDedupStore != SMSDedupMemory {
+		errs = append(errs, fmt.Errorf("SMS_DEDUP_STORE %q must be %s or %s", c.SMSDedupStore, SMSDedupDatabase, SMSDedupMemory))
+	}
+
+	if c.IdempotencyKeyTTL <= 0 {
+		errs = append(errs, errors.New("IDEMPOTENCY_KEY_TTL must be positive"))
+	}
+
+	if c.StreamMaxConnections < 1 {
+		errs = append(errs, errors.New("SSE_MAX_CONNECTIONS must be at least 1"))
+	}
+
+	if c.SlowQueryThreshold < 0 {
+		errs = append(errs, errors.New("SLOW_QUERY_MS must not be negative"))
+	}
+
+	if c.GovInfoMaxConcurrency < 0 {
+		errs = append(errs, errors.New("GOVINFO_MAX_CONCURRENCY must not be negative"))
+	}
+	if c.GovInfoMaxRetries < 0 || c.GovInfoRetryBudget < 0 {
+		errs = append(errs, errors.New("GOVINFO_MAX_RETRIES and GOVINFO_RETRY_BUDGET must not be negative"))
+	}
+
+	if c.OTLPTracesEndpoint != "" {
+		if u, err := url.Parse(c.OTLPTracesEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("OTLP_TRACES_ENDPOINT %q must be an http or https URL", c.OTLPTracesEndpoint))
+		}
+	}
+
+	if c.GovInfoCacheMode != "" {
+		switch {
+		case c.GovInfoCacheMode != "record" && c.GovInfoCacheMode != "replay":
+			errs = append(errs, fmt.Errorf("GOVINFO_CACHE_MODE %q must be record or replay", c.GovInfoCacheMode))
+		case c.Env != EnvDevelopment:
+			errs = append(errs, errors.New("GOVINFO_CACHE_MODE is only allowed when ENV is development"))
+		case c.GovInfoCacheDir == "":
+			errs = append(errs, errors.New("GOVINFO_CACHE_DIR is required when GOVINFO_CACHE_MODE is set"))
+		}
+	}
+
+	if c.CORSAllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS cannot be * when CORS_ALLOW_CREDENTIALS is true"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
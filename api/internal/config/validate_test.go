@@ -0,0 +1,110 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig returns a Config that passes Validate.
+func validConfig() *Config {
+	return &Config{
+		Port:                 "8080",
+		DBUrl:                "postgres://localhost/govinfo",
+		TwilioSID:            "AC123",
+		TwilioToken:          "token",
+		TwilioFrom:           "+12025550100",
+		APIKey:               "secret",
+		Env:                  EnvProduction,
+		SMSOutboxRPS:         1,
+		SMSDedupStore:        SMSDedupDatabase,
+		IdempotencyKeyTTL:    time.Hour,
+		StreamMaxConnections: 1,
+	}
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateListsEveryMissingField(t *testing.T) {
+	c := validConfig()
+	c.DBUrl, c.TwilioSID, c.TwilioToken, c.TwilioFrom, c.APIKey = "", "", "", "", ""
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted a config with no required fields")
+	}
+	for _, want := range []string{
+		"DATABASE_URL is required",
+		"TWILIO_SID is required",
+		"TWILIO_TOKEN is required",
+		"TWILIO_FROM is required",
+		"API_KEY or API_KEYS is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidatePort(t *testing.T) {
+	for port, ok := range map[string]bool{
+		"8080":  true,
+		"0":     true,
+		"65535": true,
+		"65536": false,
+		"-1":    false,
+		"http":  false,
+		"":      false,
+	} {
+		c := validConfig()
+		c.Port = port
+		err := c.Validate()
+		if ok && err != nil {
+			t.Errorf("PORT %q: %v", port, err)
+		}
+		if !ok && (err == nil || !strings.Contains(err.Error(), "PORT")) {
+			t.Errorf("PORT %q: error = %v, want a PORT error", port, err)
+		}
+	}
+}
+
+func TestValidateGovInfoMaxConcurrency(t *testing.T) {
+	for n, ok := range map[int]bool{0: true, 16: true, -1: false} {
+		c := validConfig()
+		c.GovInfoMaxConcurrency = n
+		err := c.Validate()
+		if ok && err != nil {
+			t.Errorf("GOVINFO_MAX_CONCURRENCY %d: %v", n, err)
+		}
+		if !ok && (err == nil || !strings.Contains(err.Error(), "GOVINFO_MAX_CONCURRENCY")) {
+			t.Errorf("GOVINFO_MAX_CONCURRENCY %d: error = %v, want a GOVINFO_MAX_CONCURRENCY error", n, err)
+		}
+	}
+}
+
+func TestRateLimitEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		rps  float64
+		env  string
+		want bool
+	}{
+		{10, EnvProduction, true},
+		{0, EnvProduction, false},
+		{10, EnvDevelopment, false},
+	} {
+		c := &Config{RateLimitRPS: tc.rps, Env: tc.env}
+		if got := c.RateLimitEnabled(); got != tc.want {
+			t.Errorf("RATE_LIMIT_RPS=%v ENV=%s: RateLimitEnabled = %v, want %v", tc.rps, tc.env, got, tc.want)
+		}
+	}
+
+	c := validConfig()
+	c.RateLimitRPS = -1
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_RPS") {
+		t.Errorf("negative RATE_LIMIT_RPS: error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CollectionState is what the poller last recorded for a collection.
+// LastPolledAt is the last successful poll; LastError is set when the most
+// recent attempt failed, and LastFound counts the new packages it found.
+type CollectionState struct {
+	CollectionCode string     `json:"collectionCode"`
+	Watermark      time.Time  `json:"watermark"`
+	LastPolledAt   time.Time  `json:"lastPolledAt"`
+	LastAttemptAt  *time.Time `json:"lastAttemptAt,omitempty"`
+	LastFound      int        `json:"lastFound"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+// CollectionStateRepo tracks, per collection, the lastModified watermark
+// of the newest package the poller has seen and how its last poll went.
+type CollectionStateRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewCollectionStateRepo(pool *pgxpool.Pool) *CollectionStateRepo {
+	return &CollectionStateRepo{pool: pool}
+}
+
+// Watermark returns the stored watermark for code. ok is false when the
+// collection has never been polled.
+func (r *CollectionStateRepo) Watermark(ctx context.Context, code string) (watermark time.Time, ok bool, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT watermark FROM collection_state WHERE collection_code = $1`,
+		code).Scan(&watermark)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("db: get watermark for %s: %w", code, err)
+	}
+	return watermark, true, nil
+}
+
+// SetWatermark records watermark for code and stamps the poll time.
+func (r *CollectionStateRepo) SetWatermark(ctx context.Context, code string, watermark time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO collection_state (collection_code, watermark, last_polled_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (collection_code)
+		DO UPDATE SET watermark = EXCLUDED.watermark, last_polled_at = now()`,
+		code, watermark)
+	if err != nil {
+		return fmt.Errorf("db: set watermark for %s: %w", code, err)
+	}
+	return nil
+}
+
+// RecordPoll stores the outcome of a poll of code: found new packages and
+// pollErr, which is nil on success. Collections without a watermark yet
+// are skipped since they have no row.
+func (r *CollectionStateRepo) RecordPoll(ctx context.Context, code string, found int, pollErr error) error {
+	var lastError string
+	if pollErr != nil {
+		lastError = pollErr.Error()
+	}
+	_, err := r.pool.Exec(ctx, `
+		UPDATE collection_state
+		SET last_attempt_at = now(), last_found = $2, last_error = NULLIF($3, '')
+		WHERE collection_code = $1`,
+		code, found, lastError)
+	if err != nil {
+		return fmt.Errorf("db: record poll for %s: %w", code, err)
+	}
+	return nil
+}
+
+// GetState returns the stored state for code, or ErrNotFound when the
+// collection has never been polled.
+func (r *CollectionStateRepo) GetState(ctx context.Context, code string) (*CollectionState, error) {
+	s := CollectionState{CollectionCode: code}
+	err := r.pool.QueryRow(ctx, `
+		SELECT watermark, last_polled_at, last_attempt_at, last_found, coalesce(last_error, '')
+		FROM collection_state
+		WHERE collection_code = $1`,
+		code).Scan(&s.Watermark, &s.LastPolledAt, &s.LastAttemptAt, &s.LastFound, &s.LastError)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db: get state for %s: %w", code, err)
+	}
+	return &s, nil
+}
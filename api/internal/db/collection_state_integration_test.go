@@ -0,0 +1,79 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetStateNeverPolled(t *testing.T) {
+	repo := NewCollectionStateRepo(testPool(t))
+	ctx := context.Background()
+
+	if _, err := repo.GetState(ctx, "BILLS"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetState = %v, want ErrNotFound", err)
+	}
+	// An outcome for a collection without a watermark has no row to land in.
+	if err := repo.RecordPoll(ctx, "BILLS", 0, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetState(ctx, "BILLS"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetState after RecordPoll alone = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetStateTracksPolls(t *testing.T) {
+	repo := NewCollectionStateRepo(testPool(t))
+	ctx := context.Background()
+	watermark := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	before := time.Now().Add(-time.Minute)
+	if err := repo.SetWatermark(ctx, "BILLS", watermark); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RecordPoll(ctx, "BILLS", 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	s, err := repo.GetState(ctx, "BILLS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.CollectionCode != "BILLS" || !s.Watermark.Equal(watermark) {
+		t.Errorf("state = %+v, want BILLS at %v", s, watermark)
+	}
+	if s.LastPolledAt.Before(before) {
+		t.Errorf("LastPolledAt = %v, want it stamped now", s.LastPolledAt)
+	}
+	if s.LastAttemptAt == nil || s.LastFound != 3 || s.LastError != "" {
+		t.Errorf("state = %+v, want a clean attempt that found 3", s)
+	}
+
+	// A failed attempt records the error but keeps the last success.
+	polled := s.LastPolledAt
+	if err := repo.RecordPoll(ctx, "BILLS", 0, errors.New("govinfo: 503")); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = repo.GetState(ctx, "BILLS"); err != nil {
+		t.Fatal(err)
+	}
+	if s.LastError != "govinfo: 503" || s.LastFound != 0 {
+		t.Errorf("state after a failure = %+v", s)
+	}
+	if !s.LastPolledAt.Equal(polled) || !s.Watermark.Equal(watermark) {
+		t.Errorf("failure moved LastPolledAt or the watermark: %+v", s)
+	}
+
+	// The next success clears it.
+	if err := repo.RecordPoll(ctx, "BILLS", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = repo.GetState(ctx, "BILLS"); err != nil {
+		t.Fatal(err)
+	}
+	if s.LastError != "" || s.LastFound != 1 {
+		t.Errorf("state after recovering = %+v", s)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/tingeytime/govinfo/api/internal/db/migrate"
+)
+
+// testPool connects to the disposable database named by
+// TEST_DATABASE_URL, applies migrations and empties every table the
+// repositories write to.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := Connect(ctx, url, PoolConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := migrate.Up(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	_, err = pool.Exec(ctx, `TRUNCATE subscriptions, webhooks, packages, sms_outbox,
+		idempotency_keys, collection_state, sms_dedup, notifications RESTART IDENTITY CASCADE`)
+	if err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return pool
+}
+
+func TestQueryTracerSeesSlowQuery(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	core, logs := observer.New(zapcore.WarnLevel)
+	pool, err := Connect(context.Background(), url, PoolConfig{Tracer: NewQueryTracer(zap.New(core), 20*time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if _, err := pool.Exec(context.Background(), "SELECT pg_sleep($1)", 0.05); err != nil {
+		t.Fatal(err)
+	}
+	entries := logs.FilterMessage("slow query").All()
+	if len(entries) != 1 || entries[0].ContextMap()["sql"] != "SELECT pg_sleep($1)" {
+		t.Errorf("slow query logs = %+v", entries)
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SMSDedupRepo records which packages were recently texted to which
+// numbers in the sms_dedup table, so every instance sees the same window.
+type SMSDedupRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSMSDedupRepo(pool *pgxpool.Pool) *SMSDedupRepo {
+	return &SMSDedupRepo{pool: pool}
+}
+
+// Claim marks packageID as texted to phone for window and reports whether
+// it was free to claim, that is not already sent within a live window.
+// Expired claims are purged first.
+func (r *SMSDedupRepo) Claim(ctx context.Context, phone, packageID string, window time.Duration) (bool, error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM sms_dedup WHERE expires_at <= now()`); err != nil {
+		return false, fmt.Errorf("db: purge sms dedup: %w", err)
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO sms_dedup (phone_number, package_id, expires_at)
+		VALUES ($1, $2, now() + $3::interval)
+		ON CONFLICT (phone_number, package_id) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at
+		WHERE sms_dedup.expires_at <= now()`,
+		phone, packageID, window)
+	if err != nil {
+		return false, fmt.Errorf("db: claim sms dedup: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Release drops a claim, so an alert that failed to send can be tried
+// again.
+func (r *SMSDedupRepo) Release(ctx context.Context, phone, packageID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM sms_dedup WHERE phone_number = $1 AND package_id = $2`, phone, packageID)
+	if err != nil {
+		return fmt.Errorf("db: release sms dedup: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSMSDedupClaim(t *testing.T) {
+	pool := testPool(t)
+	repo := NewSMSDedupRepo(pool)
+	ctx := context.Background()
+
+	claim := func(phone, pkg string) bool {
+		t.Helper()
+		ok, err := repo.Claim(ctx, phone, pkg, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	if !claim("+12025550101", "BILLS-1") {
+		t.Fatal("first claim refused")
+	}
+	if claim("+12025550101", "BILLS-1") {
+		t.Error("second claim within the window granted")
+	}
+	if !claim("+12025550102", "BILLS-1") || !claim("+12025550101", "BILLS-2") {
+		t.Error("claim for another number or package refused")
+	}
+
+	if err := repo.Release(ctx, "+12025550101", "BILLS-1"); err != nil {
+		t.Fatal(err)
+	}
+	if !claim("+12025550101", "BILLS-1") {
+		t.Error("claim after Release refused")
+	}
+
+	// Once the window has passed the pair can be claimed again.
+	if _, err := pool.Exec(ctx, `UPDATE sms_dedup SET expires_at = now() - interval '1 second'`); err != nil {
+		t.Fatal(err)
+	}
+	if !claim("+12025550101", "BILLS-1") {
+		t.Error("claim after the window refused")
+	}
+}
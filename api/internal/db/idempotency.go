@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRecord is the stored outcome of the first request made with
+// a key. Status and Response are zero while that request is in progress.
+type IdempotencyRecord struct {
+	RequestHash    string
+	SubscriptionID string
+	Status         int
+	Response       []byte
+}
+
+// Done reports whether the first request finished and its response can
+// be replayed.
+func (r IdempotencyRecord) Done() bool {
+	return r.Response != nil
+}
+
+// IdempotencyRepo stores Idempotency-Key values in the idempotency_keys
+// table. Keys are unique per scope, such as the route they were sent to.
+type IdempotencyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdempotencyRepo(pool *pgxpool.Pool) *IdempotencyRepo {
+	return &IdempotencyRepo{pool: pool}
+}
+
+// Reserve claims key in scope for a request fingerprinted by requestHash,
+// for ttl. When the key is already held and unexpired, reserved is false
+// and existing holds what was stored for it. Expired keys are purged
+// first, so they can be reused.
+func (r *IdempotencyRepo) Reserve(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (existing IdempotencyRecord, reserved bool, err error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`); err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("db: purge idempotency keys: %w", err)
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO idempotency_keys (scope, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, now() + $4::interval)
+		ON CONFLICT (scope, key) DO NOTHING`,
+		scope, key, requestHash, ttl)
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("db: reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return IdempotencyRecord{}, true, nil
+	}
+
+	var status *int
+	err = r.pool.QueryRow(ctx, `
+		SELECT request_hash, coalesce(subscription_id::text, ''), status, response
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`,
+		scope, key).Scan(&existing.RequestHash, &existing.SubscriptionID, &status, &existing.Response)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released between the insert and this read; let the caller retry.
+		return IdempotencyRecord{}, false, ErrNotFound
+	}
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("db: load idempotency key: %w", err)
+	}
+	if status != nil {
+		existing.Status = *status
+	}
+	return existing, false, nil
+}
+
+// Complete stores the response to replay for a reserved key.
+func (r *IdempotencyRepo) Complete(ctx context.Context, scope, key, subscriptionID string, status int, response []byte) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET subscription_id = NULLIF($3, '')::uuid, status = $4, response = $5
+		WHERE scope = $1 AND key = $2`,
+		scope, key, subscriptionID, status, response)
+	if err != nil {
+		return fmt.Errorf("db: complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release drops a reservation whose request failed, so the client can
+// retry with the same key. Completed keys are left alone.
+func (r *IdempotencyRepo) Release(ctx context.Context, scope, key string) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND key = $2 AND response IS NULL`,
+		scope, key)
+	if err != nil {
+		return fmt.Errorf("db: release idempotency key: %w", err)
+	}
+	return nil
+}
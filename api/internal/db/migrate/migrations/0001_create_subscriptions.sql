@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    phone_number    TEXT NOT NULL,
+    collection_code TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_collection_code_idx
+    ON subscriptions (collection_code);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS collection_state (
+    collection_code TEXT PRIMARY KEY,
+    watermark       TIMESTAMPTZ NOT NULL,
+    last_polled_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+-- Keep the oldest row of any duplicate pair before adding the constraint.
+DELETE FROM subscriptions a
+USING subscriptions b
+WHERE a.phone_number = b.phone_number
+  AND a.collection_code = b.collection_code
+  AND (a.created_at, a.id::text) > (b.created_at, b.id::text);
+
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_phone_collection_key
+    ON subscriptions (phone_number, collection_code);
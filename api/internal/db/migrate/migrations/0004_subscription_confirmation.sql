@@ -0,0 +1,12 @@
+-- Existing subscribers predate double opt-in and stay active; new rows
+-- start pending until confirmed.
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS status                  TEXT NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS confirmation_code       TEXT,
+    ADD COLUMN IF NOT EXISTS confirmation_expires_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS confirmed_at            TIMESTAMPTZ;
+
+ALTER TABLE subscriptions ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX IF NOT EXISTS subscriptions_active_collection_idx
+    ON subscriptions (collection_code) WHERE status = 'active';
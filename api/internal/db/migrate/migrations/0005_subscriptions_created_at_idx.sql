@@ -0,0 +1,3 @@
+-- Keyset pagination for GET /subscriptions.
+CREATE INDEX IF NOT EXISTS subscriptions_created_at_id_idx
+    ON subscriptions (created_at, id);
@@ -0,0 +1,17 @@
+-- Each subscription picks one or more delivery channels, with the contact
+-- details each needs. Existing rows were SMS-only.
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS channels       TEXT[] NOT NULL DEFAULT '{sms}',
+    ADD COLUMN IF NOT EXISTS email          TEXT,
+    ADD COLUMN IF NOT EXISTS webhook_url    TEXT,
+    ADD COLUMN IF NOT EXISTS webhook_secret TEXT;
+
+-- Email- and webhook-only subscriptions have no phone number. NULLs are
+-- distinct, so the (phone_number, collection_code) unique index still
+-- only constrains SMS subscribers.
+ALTER TABLE subscriptions ALTER COLUMN phone_number DROP NOT NULL;
+
+-- An email address gets at most one active subscription per collection.
+-- Rows without an email are NULL and so never conflict.
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_email_collection_key
+    ON subscriptions (email, collection_code) WHERE status = 'active';
@@ -0,0 +1,13 @@
+-- Local copies of packages seen by the poller, for GET /packages/local.
+CREATE TABLE IF NOT EXISTS packages (
+    package_id      TEXT PRIMARY KEY,
+    collection_code TEXT NOT NULL,
+    title           TEXT NOT NULL,
+    date_issued     DATE,
+    last_modified   TIMESTAMPTZ NOT NULL,
+    package_link    TEXT NOT NULL DEFAULT '',
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS packages_collection_date_idx
+    ON packages (collection_code, date_issued);
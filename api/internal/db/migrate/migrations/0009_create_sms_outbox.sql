@@ -0,0 +1,16 @@
+-- Durable queue of alert texts, drained at Twilio's send rate.
+CREATE TABLE IF NOT EXISTS sms_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id UUID,
+    phone_number    TEXT NOT NULL,
+    body            TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sms_outbox_pending_idx
+    ON sms_outbox (next_attempt_at, id) WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- Idempotency-Key values seen on create requests, so a retried request
+-- gets the original response instead of creating another row. A NULL
+-- response means the first request is still being handled.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope           TEXT NOT NULL,
+    key             TEXT NOT NULL,
+    request_hash    TEXT NOT NULL,
+    subscription_id UUID,
+    status          INT,
+    response        JSONB,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at      TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx
+    ON idempotency_keys (expires_at);
@@ -0,0 +1,6 @@
+-- Outcome of the most recent poll of each collection, for the status
+-- endpoint. last_polled_at remains the time of the last successful poll.
+ALTER TABLE collection_state
+    ADD COLUMN IF NOT EXISTS last_attempt_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS last_found      INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_error      TEXT;
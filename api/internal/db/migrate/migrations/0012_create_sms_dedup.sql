@@ -0,0 +1,11 @@
+-- SMS alerts recently sent, keyed by phone number and package, so the same
+-- package is texted to a number at most once per dedup window.
+CREATE TABLE IF NOT EXISTS sms_dedup (
+    phone_number TEXT NOT NULL,
+    package_id   TEXT NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (phone_number, package_id)
+);
+
+CREATE INDEX IF NOT EXISTS sms_dedup_expires_at_idx
+    ON sms_dedup (expires_at);
@@ -0,0 +1,26 @@
+-- Audit trail of alerts sent to subscribers. Rows are only ever
+-- inserted: a queued SMS gets a second row once the outbox sends it or
+-- gives up. There is no foreign key so the trail outlives deleted
+-- subscriptions.
+CREATE TABLE IF NOT EXISTS notifications (
+    id                  BIGSERIAL PRIMARY KEY,
+    subscription_id     UUID NOT NULL,
+    channel             TEXT NOT NULL,
+    package_id          TEXT NOT NULL,
+    collection_code     TEXT NOT NULL,
+    status              TEXT NOT NULL,
+    provider_message_id TEXT NOT NULL DEFAULT '',
+    error               TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_collection_code_idx
+    ON notifications (collection_code, id);
+
+CREATE INDEX IF NOT EXISTS notifications_created_at_idx
+    ON notifications (created_at);
+
+-- Queued texts remember their package so the outbox can audit the send.
+ALTER TABLE sms_outbox
+    ADD COLUMN IF NOT EXISTS package_id      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS collection_code TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Notification statuses. A queued alert is followed by a sent or failed
+// row once the outbox is done with it.
+const (
+	NotificationQueued = "queued"
+	NotificationSent   = "sent"
+	NotificationFailed = "failed"
+)
+
+// Notification is one audited alert to a subscription.
+// ProviderMessageID is the Twilio message SID, the email Message-ID or
+// the webhook delivery ID, when the channel got that far.
+type Notification struct {
+	ID                int64     `json:"id"`
+	SubscriptionID    string    `json:"subscriptionId"`
+	Channel           string    `json:"channel"`
+	PackageID         string    `json:"packageId"`
+	CollectionCode    string    `json:"collectionCode"`
+	Status            string    `json:"status"`
+	ProviderMessageID string    `json:"providerMessageId,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// NotificationFilter narrows List. Zero fields match everything.
+type NotificationFilter struct {
+	CollectionCode string
+	Status         string
+	Since          time.Time
+}
+
+// NotificationRepo stores the alert audit trail in the notifications
+// table.
+type NotificationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewNotificationRepo(pool *pgxpool.Pool) *NotificationRepo {
+	return &NotificationRepo{pool: pool}
+}
+
+// Record appends n to the audit trail. ID and CreatedAt are ignored.
+func (r *NotificationRepo) Record(ctx context.Context, n Notification) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO notifications
+			(subscription_id, channel, package_id, collection_code, status, provider_message_id, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		n.SubscriptionID, n.Channel, n.PackageID, n.CollectionCode, n.Status, n.ProviderMessageID, n.Error)
+	if err != nil {
+		return fmt.Errorf("db: record notification: %w", err)
+	}
+	return nil
+}
+
+// List pages through notifications matching f, newest first. cursor is
+// the nextCursor of the previous page; the returned one is empty after
+// the last page.
+func (r *NotificationRepo) List(ctx context.Context, f NotificationFilter, limit int, cursor string) ([]Notification, string, error) {
+	if limit < 1 {
+		limit = 1
+	}
+	var before int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id < 1 {
+			return nil, "", ErrInvalidCursor
+		}
+		before = id
+	}
+	var since *time.Time
+	if !f.Since.IsZero() {
+		since = &f.Since
+	}
+
+	// One extra row tells us whether another page exists.
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, subscription_id::text, channel, package_id, collection_code, status,
+			provider_message_id, error, created_at
+		FROM notifications
+		WHERE ($1 = '' OR collection_code = $1)
+			AND ($2 = '' OR status = $2)
+			AND ($3::timestamptz IS NULL OR created_at >= $3)
+			AND ($4 = 0 OR id < $4)
+		ORDER BY id DESC
+		LIMIT $5`,
+		f.CollectionCode, f.Status, since, before, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("db: list notifications: %w", err)
+	}
+	notifications, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Notification, error) {
+		var n Notification
+		err := row.Scan(&n.ID, &n.SubscriptionID, &n.Channel, &n.PackageID, &n.CollectionCode, &n.Status,
+			&n.ProviderMessageID, &n.Error, &n.CreatedAt)
+		return n, err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("db: list notifications: %w", err)
+	}
+
+	if len(notifications) <= limit {
+		return notifications, "", nil
+	}
+	notifications = notifications[:limit]
+	return notifications, strconv.FormatInt(notifications[len(notifications)-1].ID, 10), nil
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const (
+	subA = "00000000-0000-0000-0000-00000000000a"
+	subB = "00000000-0000-0000-0000-00000000000b"
+)
+
+func TestNotificationsRecordAndFilter(t *testing.T) {
+	pool := testPool(t)
+	repo := NewNotificationRepo(pool)
+	ctx := context.Background()
+
+	for _, n := range []Notification{
+		{SubscriptionID: subA, Channel: ChannelSMS, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: NotificationSent, ProviderMessageID: "SM1"},
+		{SubscriptionID: subB, Channel: ChannelEmail, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: NotificationFailed, Error: "smtp: rcpt: 550 no such user"},
+		{SubscriptionID: subA, Channel: ChannelSMS, PackageID: "FR-1", CollectionCode: "FR", Status: NotificationQueued},
+	} {
+		if err := repo.Record(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, next, err := repo.List(ctx, NotificationFilter{}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || next != "" || all[0].PackageID != "FR-1" {
+		t.Fatalf("all notifications = %+v, want three newest first", all)
+	}
+
+	failed, _, err := repo.List(ctx, NotificationFilter{CollectionCode: "BILLS", Status: NotificationFailed}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].SubscriptionID != subB || failed[0].Error != "smtp: rcpt: 550 no such user" {
+		t.Errorf("failed BILLS notifications = %+v", failed)
+	}
+
+	// Backdate the first row to check since.
+	if _, err := pool.Exec(ctx, `UPDATE notifications SET created_at = now() - interval '2 days' WHERE provider_message_id = 'SM1'`); err != nil {
+		t.Fatal(err)
+	}
+	recent, _, err := repo.List(ctx, NotificationFilter{Since: time.Now().Add(-24 * time.Hour)}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 {
+		t.Errorf("notifications in the last day = %+v, want 2", recent)
+	}
+}
+
+func TestNotificationsListPages(t *testing.T) {
+	repo := NewNotificationRepo(testPool(t))
+	ctx := context.Background()
+	for range 5 {
+		if err := repo.Record(ctx, Notification{SubscriptionID: subA, Channel: ChannelSMS, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: NotificationSent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[int64]bool{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("paging never ended")
+		}
+		page, next, err := repo.List(ctx, NotificationFilter{}, 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range page {
+			if seen[n.ID] {
+				t.Errorf("notification %d listed twice", n.ID)
+			}
+			seen[n.ID] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != 5 {
+		t.Errorf("paged through %d notifications, want 5", len(seen))
+	}
+}
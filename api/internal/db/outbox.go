@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Outbox statuses.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxMessage is one queued text. Attempts counts sends tried so far.
+// PackageID and CollectionCode name the package an alert is about.
+type OutboxMessage struct {
+	ID             int64
+	SubscriptionID string
+	PhoneNumber    string
+	Body           string
+	PackageID      string
+	CollectionCode string
+	Attempts       int
+	CreatedAt      time.Time
+}
+
+// ErrOutboxNotFailed is returned by Retry for a message that hasn't failed.
+var ErrOutboxNotFailed = errors.New("db: outbox message has not failed")
+
+// OutboxEntry is a queued text as shown to operators, with its delivery
+// state.
+type OutboxEntry struct {
+	ID             int64      `json:"id"`
+	SubscriptionID string     `json:"subscriptionId,omitempty"`
+	PhoneNumber    string     `json:"phoneNumber"`
+	Body           string     `json:"body"`
+	PackageID      string     `json:"packageId,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	SentAt         *time.Time `json:"sentAt,omitempty"`
+}
+
+// OutboxStats summarises the queue.
+type OutboxStats struct {
+	Pending int `json:"pending"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	// OldestPendingAt is when the longest-waiting pending message was
+	// queued.
+	OldestPendingAt *time.Time `json:"oldestPendingAt,omitempty"`
+}
+
+// OutboxRepo stores queued texts in the sms_outbox table.
+type OutboxRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxRepo(pool *pgxpool.Pool) *OutboxRepo {
+	return &OutboxRepo{pool: pool}
+}
+
+// Enqueue queues msg.Body for msg.PhoneNumber. Only those two are
+// required; ID, Attempts and CreatedAt are ignored.
+func (r *OutboxRepo) Enqueue(ctx context.Context, msg OutboxMessage) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO sms_outbox (subscription_id, phone_number, body, package_id, collection_code)
+		VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5)`,
+		msg.SubscriptionID, msg.PhoneNumber, msg.Body, msg.PackageID, msg.CollectionCode)
+	if err != nil {
+		return fmt.Errorf("db: enqueue sms: %w", err)
+	}
+	return nil
+}
+
+// Claim returns the oldest pending message that is due and hides it from
+// other claims for lease, so replicas never send it twice. ok is false
+// when nothing is due. A message for a subscription that is no longer
+// active, or no longer exists, is never claimed.
+func (r *OutboxRepo) Claim(ctx context.Context, lease time.Duration) (msg OutboxMessage, ok bool, err error) {
+	err = r.pool.QueryRow(ctx, `
+		UPDATE sms_outbox
+		SET next_attempt_at = now() + $1::interval
+		WHERE id = (
+			SELECT o.id FROM sms_outbox o
+			WHERE o.status = 'pending' AND o.next_attempt_at <= now()
+			  AND (o.subscription_id IS NULL OR EXISTS (
+				SELECT 1 FROM subscriptions s
+				WHERE s.id = o.subscription_id AND s.status = 'active'))
+			ORDER BY o.next_attempt_at, o.id
+			FOR UPDATE OF o SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, coalesce(subscription_id::text, ''), phone_number, body,
+			package_id, collection_code, attempts, created_at`,
+		lease).Scan(&msg.ID, &msg.SubscriptionID, &msg.PhoneNumber, &msg.Body,
+		&msg.PackageID, &msg.CollectionCode, &msg.Attempts, &msg.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return OutboxMessage{}, false, nil
+	}
+	if err != nil {
+		return OutboxMessage{}, false, fmt.Errorf("db: claim sms: %w", err)
+	}
+	return msg, true, nil
+}
+
+// MarkSent records a successful send of message id.
+func (r *OutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE sms_outbox
+		SET status = 'sent', attempts = attempts + 1, last_error = '', sent_at = now()
+		WHERE id = $1`,
+		id)
+	if err != nil {
+		return fmt.Errorf("db: mark sms %d sent: %w", id, err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and schedules the next one at next.
+func (r *OutboxRepo) MarkRetry(ctx context.Context, id int64, sendErr string, next time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE sms_outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1`,
+		id, sendErr, next)
+	if err != nil {
+		return fmt.Errorf("db: reschedule sms %d: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed records a final failed attempt; the message is not retried.
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, sendErr string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE sms_outbox
+		SET status = 'failed', attempts = attempts + 1, last_error = $2
+		WHERE id = $1`,
+		id, sendErr)
+	if err != nil {
+		return fmt.Errorf("db: mark sms %d failed: %w", id, err)
+	}
+	return nil
+}
+
+// Stats counts messages by status.
+func (r *OutboxRepo) Stats(ctx context.Context) (OutboxStats, error) {
+	var s OutboxStats
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			count(*) FILTER (WHERE status = 'pending'),
+			count(*) FILTER (WHERE status = 'sent'),
+			count(*) FILTER (WHERE status = 'failed'),
+			min(created_at) FILTER (WHERE status = 'pending')
+		FROM sms_outbox`).Scan(&s.Pending, &s.Sent, &s.Failed, &s.OldestPendingAt)
+	if err != nil {
+		return OutboxStats{}, fmt.Errorf("db: outbox stats: %w", err)
+	}
+	return s, nil
+}
+
+// List pages through messages with status, or every message when status
+// is empty, newest first. cursor is the nextCursor of the previous page;
+// the returned one is empty after the last page.
+func (r *OutboxRepo) List(ctx context.Context, status string, limit int, cursor string) ([]OutboxEntry, string, error) {
+	if limit < 1 {
+		limit = 1
+	}
+	var before int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id < 1 {
+			return nil, "", ErrInvalidCursor
+		}
+		before = id
+	}
+
+	// One extra row tells us whether another page exists.
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, coalesce(subscription_id::text, ''), phone_number, body, package_id, status,
+			attempts, last_error, created_at, next_attempt_at, sent_at
+		FROM sms_outbox
+		WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3`,
+		status, before, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("db: list outbox: %w", err)
+	}
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (OutboxEntry, error) {
+		var e OutboxEntry
+		err := row.Scan(&e.ID, &e.SubscriptionID, &e.PhoneNumber, &e.Body, &e.PackageID, &e.Status,
+			&e.Attempts, &e.LastError, &e.CreatedAt, &e.NextAttemptAt, &e.SentAt)
+		return e, err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("db: list outbox: %w", err)
+	}
+
+	if len(entries) <= limit {
+		return entries, "", nil
+	}
+	entries = entries[:limit]
+	return entries, strconv.FormatInt(entries[len(entries)-1].ID, 10), nil
+}
+
+// Retry puts failed message id back in the queue with a fresh set of
+// attempts, due now. It returns ErrNotFound for an unknown id and
+// ErrOutboxNotFailed for a message that is pending or sent. The last
+// error is kept until the next attempt replaces it.
+func (r *OutboxRepo) Retry(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE sms_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = now()
+		WHERE id = $1 AND status = 'failed'`,
+		id)
+	if err != nil {
+		return fmt.Errorf("db: retry sms %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil
+	}
+
+	var status string
+	err = r.pool.QueryRow(ctx, `SELECT status FROM sms_outbox WHERE id = $1`, id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("db: retry sms %d: %w", id, err)
+	}
+	return ErrOutboxNotFailed
+}
@@ -0,0 +1,199 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// seedOutbox queues three texts and leaves one failed, one sent and one
+// pending, returning their ids in that order.
+func seedOutbox(t *testing.T, repo *OutboxRepo) (failed, sent, pending int64) {
+	t.Helper()
+	ctx := context.Background()
+	for _, phone := range []string{"+12025550101", "+12025550102", "+12025550103"} {
+		if err := repo.Enqueue(ctx, OutboxMessage{PhoneNumber: phone, Body: "New bill", PackageID: "BILLS-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	claim := func() int64 {
+		t.Helper()
+		msg, ok, err := repo.Claim(ctx, time.Hour)
+		if err != nil || !ok {
+			t.Fatalf("Claim = %v, %v", ok, err)
+		}
+		return msg.ID
+	}
+	failed, sent = claim(), claim()
+	if err := repo.MarkFailed(ctx, failed, "twilio: 21211 invalid number"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MarkSent(ctx, sent); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _, err := repo.List(ctx, OutboxPending, 10, "")
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("pending messages = %+v, %v", msgs, err)
+	}
+	return failed, sent, msgs[0].ID
+}
+
+func TestOutboxListFailed(t *testing.T) {
+	repo := NewOutboxRepo(testPool(t))
+	failed, _, _ := seedOutbox(t, repo)
+
+	msgs, next, err := repo.List(context.Background(), OutboxFailed, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || next != "" {
+		t.Fatalf("failed messages = %+v, next %q; want just one", msgs, next)
+	}
+	if m := msgs[0]; m.ID != failed || m.Status != OutboxFailed || m.LastError != "twilio: 21211 invalid number" || m.Attempts != 1 {
+		t.Errorf("failed message = %+v", m)
+	}
+}
+
+func TestOutboxListPages(t *testing.T) {
+	repo := NewOutboxRepo(testPool(t))
+	ctx := context.Background()
+	seedOutbox(t, repo)
+
+	var ids []int64
+	cursor := ""
+	for {
+		msgs, next, err := repo.List(ctx, "", 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range msgs {
+			ids = append(ids, m.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(ids) != 3 || ids[0] < ids[1] || ids[1] < ids[2] {
+		t.Errorf("listed ids %v, want all three newest first", ids)
+	}
+
+	if _, _, err := repo.List(ctx, "", 2, "bogus"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("List with a bad cursor = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestOutboxRetry(t *testing.T) {
+	repo := NewOutboxRepo(testPool(t))
+	ctx := context.Background()
+	failed, sent, pending := seedOutbox(t, repo)
+
+	for id, want := range map[int64]error{sent: ErrOutboxNotFailed, pending: ErrOutboxNotFailed, 999999: ErrNotFound} {
+		if err := repo.Retry(ctx, id); !errors.Is(err, want) {
+			t.Errorf("Retry(%d) = %v, want %v", id, err, want)
+		}
+	}
+
+	if err := repo.Retry(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _, err := repo.List(ctx, OutboxPending, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var retried *OutboxEntry
+	for i := range msgs {
+		if msgs[i].ID == failed {
+			retried = &msgs[i]
+		}
+	}
+	if retried == nil || retried.Attempts != 0 || retried.LastError == "" {
+		t.Fatalf("retried message = %+v, want pending with attempts reset and the error kept", retried)
+	}
+
+	// The worker picks it up again alongside the other pending message.
+	claimed := map[int64]bool{}
+	for range 2 {
+		msg, ok, err := repo.Claim(ctx, time.Hour)
+		if err != nil || !ok {
+			t.Fatalf("Claim = %v, %v", ok, err)
+		}
+		claimed[msg.ID] = true
+	}
+	if !claimed[failed] {
+		t.Errorf("claimed %v, want the retried message among them", claimed)
+	}
+
+	// Retrying twice is a conflict once it's pending again.
+	if err := repo.Retry(ctx, failed); !errors.Is(err, ErrOutboxNotFailed) {
+		t.Errorf("second Retry = %v, want ErrOutboxNotFailed", err)
+	}
+}
+
+func TestOutboxHoldsTextsForInactiveSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name         string
+		enqueueFirst bool
+		end          func(subs *SubscriptionRepo, sub Subscription) error
+		wantError    string
+	}{
+		{"stopped after queueing", true, stopNumber, "subscription inactive"},
+		{"stopped before queueing", false, stopNumber, ""},
+		{"deleted after queueing", true, deleteSubscription, "subscription deleted"},
+		{"deleted before queueing", false, deleteSubscription, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := testPool(t)
+			subs, outbox := NewSubscriptionRepo(pool), NewOutboxRepo(pool)
+			sub, err := subs.Create(ctx, Subscription{PhoneNumber: "+12025550101", CollectionCode: "BILLS", Channels: []string{ChannelSMS}}, "", time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			enqueue := func() {
+				t.Helper()
+				if err := outbox.Enqueue(ctx, OutboxMessage{SubscriptionID: sub.ID, PhoneNumber: sub.PhoneNumber, Body: "New bill"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.enqueueFirst {
+				enqueue()
+			}
+			if err := tc.end(subs, sub); err != nil {
+				t.Fatal(err)
+			}
+			if !tc.enqueueFirst {
+				enqueue()
+			}
+
+			if msg, ok, err := outbox.Claim(ctx, time.Hour); err != nil || ok {
+				t.Fatalf("Claim = %+v, %v, %v; want nothing to send", msg, ok, err)
+			}
+			failed, _, err := outbox.List(ctx, OutboxFailed, 10, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantError == "" {
+				if len(failed) != 0 {
+					t.Errorf("failed messages = %+v, want none", failed)
+				}
+				return
+			}
+			if len(failed) != 1 || failed[0].LastError != tc.wantError {
+				t.Errorf("failed messages = %+v, want one failed with %q", failed, tc.wantError)
+			}
+		})
+	}
+}
+
+func stopNumber(subs *SubscriptionRepo, sub Subscription) error {
+	_, err := subs.DeactivateByPhone(context.Background(), sub.PhoneNumber)
+	return err
+}
+
+func deleteSubscription(subs *SubscriptionRepo, sub Subscription) error {
+	return subs.Delete(context.Background(), sub.ID)
+}
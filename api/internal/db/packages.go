@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Package is the stored copy of a GovInfo package summary. DateIssued is
+// YYYY-MM-DD, or empty when GovInfo didn't give a usable date.
+type Package struct {
+	PackageID      string    `json:"packageId"`
+	CollectionCode string    `json:"collectionCode"`
+	Title          string    `json:"title"`
+	DateIssued     string    `json:"dateIssued,omitempty"`
+	LastModified   time.Time `json:"lastModified"`
+	PackageLink    string    `json:"packageLink,omitempty"`
+}
+
+// PackageQuery filters SearchPackages. Zero fields don't filter; From and
+// To bound DateIssued inclusively.
+type PackageQuery struct {
+	CollectionCode string
+	// Title matches case-insensitively anywhere in the title.
+	Title  string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// PackageRepo stores package summaries in the packages table.
+type PackageRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPackageRepo(pool *pgxpool.Pool) *PackageRepo {
+	return &PackageRepo{pool: pool}
+}
+
+const packageColumns = `package_id, collection_code, title,
+	coalesce(to_char(date_issued, 'YYYY-MM-DD'), ''), last_modified, package_link`
+
+func scanPackage(row pgx.Row) (Package, error) {
+	var p Package
+	err := row.Scan(&p.PackageID, &p.CollectionCode, &p.Title, &p.DateIssued, &p.LastModified, &p.PackageLink)
+	return p, err
+}
+
+// Upsert stores pkg, replacing any earlier copy with the same package ID.
+func (r *PackageRepo) Upsert(ctx context.Context, pkg Package) error {
+	var issued *time.Time
+	if t, err := time.Parse(time.DateOnly, pkg.DateIssued); err == nil {
+		issued = &t
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO packages (package_id, collection_code, title, date_issued, last_modified, package_link)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (package_id) DO UPDATE
+		SET collection_code = excluded.collection_code,
+		    title = excluded.title,
+		    date_issued = excluded.date_issued,
+		    last_modified = excluded.last_modified,
+		    package_link = excluded.package_link,
+		    updated_at = now()`,
+		pkg.PackageID, pkg.CollectionCode, pkg.Title, issued, pkg.LastModified, pkg.PackageLink)
+	if err != nil {
+		return fmt.Errorf("db: upsert package %s: %w", pkg.PackageID, err)
+	}
+	return nil
+}
+
+// SearchPackages returns stored packages matching q, newest issued first.
+func (r *PackageRepo) SearchPackages(ctx context.Context, q PackageQuery) ([]Package, error) {
+	if q.Limit < 1 {
+		q.Limit = 1
+	}
+	var from, to *time.Time
+	if !q.From.IsZero() {
+		from = &q.From
+	}
+	if !q.To.IsZero() {
+		to = &q.To
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+packageColumns+`
+		FROM packages
+		WHERE ($1 = '' OR collection_code = $1)
+		  AND ($2 = '' OR title ILIKE '%' || $2 || '%')
+		  AND ($3::date IS NULL OR date_issued >= $3::date)
+		  AND ($4::date IS NULL OR date_issued <= $4::date)
+		ORDER BY date_issued DESC NULLS LAST, package_id
+		LIMIT $5 OFFSET $6`,
+		q.CollectionCode, escapeLike(q.Title), from, to, q.Limit, q.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("db: search packages: %w", err)
+	}
+	pkgs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Package, error) {
+		return scanPackage(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db: search packages: %w", err)
+	}
+	return pkgs, nil
+}
+
+// escapeLike makes s match literally inside an ILIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
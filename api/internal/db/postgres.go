@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolConfig tunes the connection pool. Zero values keep pgxpool's defaults.
+type PoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	// Tracer, when set, sees every query; see NewQueryTracer.
+	Tracer pgx.QueryTracer
+}
+
+// Connect creates a pgx connection pool for url. Connections are opened
+// lazily, so callers that need to fail fast should Ping the pool.
+func Connect(ctx context.Context, url string, pc PoolConfig) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("db: parse database url: %w", err)
+	}
+	if pc.MaxConns > 0 {
+		poolCfg.MaxConns = pc.MaxConns
+	}
+	if pc.MinConns > 0 {
+		poolCfg.MinConns = pc.MinConns
+	}
+	if pc.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = pc.MaxConnLifetime
+	}
+	if pc.Tracer != nil {
+		poolCfg.ConnConfig.Tracer = pc.Tracer
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("db: create pool: %w", err)
+	}
+	return pool, nil
+}
@@ -0,0 +1,430 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotFound is returned when a row addressed by ID does not exist.
+var ErrNotFound = errors.New("db: not found")
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded.
+var ErrInvalidCursor = errors.New("db: invalid cursor")
+
+// ErrAlreadyExists is returned when an insert would duplicate a unique row.
+var ErrAlreadyExists = errors.New("db: already exists")
+
+// DuplicateError is returned when a subscription's phone number or email
+// is already actively subscribed to its collection. Channel says which:
+// ChannelSMS for the phone number, ChannelEmail for the email. It matches
+// ErrAlreadyExists.
+type DuplicateError struct {
+	Channel string
+}
+
+func (e *DuplicateError) Error() string {
+	return "db: " + e.Channel + " contact already subscribed"
+}
+
+func (e *DuplicateError) Is(target error) bool { return target == ErrAlreadyExists }
+
+// uniqueViolation is Postgres's SQLSTATE for a unique index conflict.
+const uniqueViolation = "23505"
+
+// Unique indexes on subscriptions, by the channel whose contact they
+// guard.
+var subscriptionUniqueIndexes = map[string]string{
+	"subscriptions_phone_collection_key": ChannelSMS,
+	"subscriptions_email_collection_key": ChannelEmail,
+}
+
+// duplicateSubscription turns a unique violation on one of
+// subscriptionUniqueIndexes into a *DuplicateError, returning nil for any
+// other error.
+func duplicateSubscription(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return nil
+	}
+	if channel, ok := subscriptionUniqueIndexes[pgErr.ConstraintName]; ok {
+		return &DuplicateError{Channel: channel}
+	}
+	return nil
+}
+
+// Subscription statuses. Only active subscriptions receive alerts.
+const (
+	StatusPending  = "pending"
+	StatusActive   = "active"
+	StatusInactive = "inactive"
+)
+
+// Delivery channels a subscription can use.
+const (
+	ChannelSMS     = "sms"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Subscription signs a contact up for alerts on a collection over one or
+// more channels. Only the contact fields its channels need are set.
+type Subscription struct {
+	ID             string    `json:"id"`
+	PhoneNumber    string    `json:"phoneNumber,omitempty"`
+	Email          string    `json:"email,omitempty"`
+	WebhookURL     string    `json:"webhookUrl,omitempty"`
+	WebhookSecret  string    `json:"-"`
+	CollectionCode string    `json:"collectionCode"`
+	Channels       []string  `json:"channels"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// HasChannel reports whether s delivers over channel.
+func (s Subscription) HasChannel(channel string) bool {
+	return slices.Contains(s.Channels, channel)
+}
+
+// SubscriptionRepo stores subscriptions in the subscriptions table.
+type SubscriptionRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSubscriptionRepo(pool *pgxpool.Pool) *SubscriptionRepo {
+	return &SubscriptionRepo{pool: pool}
+}
+
+const subscriptionColumns = `id::text, coalesce(phone_number, ''), coalesce(email, ''),
+	coalesce(webhook_url, ''), coalesce(webhook_secret, ''), collection_code, channels, status, created_at`
+
+func scanSubscription(row pgx.Row) (Subscription, error) {
+	var s Subscription
+	err := row.Scan(&s.ID, &s.PhoneNumber, &s.Email, &s.WebhookURL, &s.WebhookSecret,
+		&s.CollectionCode, &s.Channels, &s.Status, &s.CreatedAt)
+	return s, err
+}
+
+// Create inserts sub's contact details, collection and channels. With a
+// confirmation code the row is pending until confirmed with it before
+// expiresAt; with an empty code it is active straight away.
+//
+// Creating an SMS subscription that is still pending issues it the new
+// code and channels instead, and an inactive one is put back to pending.
+// It returns a *DuplicateError when the number or email is already
+// actively subscribed to the collection.
+func (r *SubscriptionRepo) Create(ctx context.Context, sub Subscription, code string, expiresAt time.Time) (Subscription, error) {
+	status, expires := StatusActive, any(nil)
+	if code != "" {
+		status, expires = StatusPending, expiresAt
+	}
+
+	// The email index only covers active rows, so a pending duplicate
+	// would otherwise be created and then fail to confirm.
+	if sub.Email != "" {
+		var taken bool
+		err := r.pool.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM subscriptions
+				WHERE email = $1 AND collection_code = $2 AND status = 'active'
+			)`, sub.Email, sub.CollectionCode).Scan(&taken)
+		if err != nil {
+			return Subscription{}, fmt.Errorf("db: create subscription: %w", err)
+		}
+		if taken {
+			return Subscription{}, &DuplicateError{Channel: ChannelEmail}
+		}
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO subscriptions (phone_number, email, webhook_url, webhook_secret, collection_code,
+		                           channels, status, confirmation_code, confirmation_expires_at)
+		VALUES (nullif($1, ''), nullif($2, ''), nullif($3, ''), nullif($4, ''), $5, $6, $7, nullif($8, ''), $9)
+		ON CONFLICT (phone_number, collection_code) DO UPDATE
+		SET email = EXCLUDED.email,
+		    webhook_url = EXCLUDED.webhook_url,
+		    webhook_secret = EXCLUDED.webhook_secret,
+		    channels = EXCLUDED.channels,
+		    status = EXCLUDED.status,
+		    confirmation_code = EXCLUDED.confirmation_code,
+		    confirmation_expires_at = EXCLUDED.confirmation_expires_at
+		WHERE subscriptions.status <> 'active'
+		RETURNING `+subscriptionColumns,
+		sub.PhoneNumber, sub.Email, sub.WebhookURL, sub.WebhookSecret, sub.CollectionCode,
+		sub.Channels, status, code, expires)
+
+	s, err := scanSubscription(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Subscription{}, &DuplicateError{Channel: ChannelSMS}
+	}
+	if dup := duplicateSubscription(err); dup != nil {
+		return Subscription{}, dup
+	}
+	if err != nil {
+		return Subscription{}, fmt.Errorf("db: create subscription: %w", err)
+	}
+	return s, nil
+}
+
+// Batch row outcomes reported by CreateBatch.
+const (
+	BatchCreated   = "created"
+	BatchDuplicate = "duplicate"
+)
+
+// BatchResult holds one BatchRow per subscription passed to CreateBatch,
+// in the same order.
+type BatchResult struct {
+	Rows []BatchRow
+}
+
+// BatchRow is the outcome for one subscription in a batch. Subscription
+// is the stored row and only set when Status is BatchCreated.
+type BatchRow struct {
+	Status       string
+	Subscription Subscription
+}
+
+// CreateBatch inserts subs as active SMS subscriptions in one
+// transaction, skipping opt-in: callers import subscribers who have
+// already consented. A number already subscribed to the collection, in
+// any status or earlier in the same batch, is reported as a duplicate
+// and left untouched, so an unsubscribed number is never re-activated.
+func (r *SubscriptionRepo) CreateBatch(ctx context.Context, subs []Subscription) (BatchResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return BatchResult{}, fmt.Errorf("db: create subscriptions: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, sub := range subs {
+		batch.Queue(`
+			INSERT INTO subscriptions (phone_number, collection_code, channels, status, confirmed_at)
+			VALUES ($1, $2, '{sms}', 'active', now())
+			ON CONFLICT (phone_number, collection_code) DO NOTHING
+			RETURNING `+subscriptionColumns,
+			sub.PhoneNumber, sub.CollectionCode)
+	}
+
+	result := BatchResult{Rows: make([]BatchRow, len(subs))}
+	br := tx.SendBatch(ctx, batch)
+	for i := range subs {
+		created, err := scanSubscription(br.QueryRow())
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			result.Rows[i] = BatchRow{Status: BatchDuplicate}
+		case err != nil:
+			br.Close()
+			return BatchResult{}, fmt.Errorf("db: create subscriptions: row %d: %w", i, err)
+		default:
+			result.Rows[i] = BatchRow{Status: BatchCreated, Subscription: created}
+		}
+	}
+	if err := br.Close(); err != nil {
+		return BatchResult{}, fmt.Errorf("db: create subscriptions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return BatchResult{}, fmt.Errorf("db: create subscriptions: %w", err)
+	}
+	return result, nil
+}
+
+// Confirm activates the pending subscription for phoneNumber or email,
+// whichever is set, that was issued code. It returns ErrNotFound when no
+// pending subscription has that code or the code has expired, and a
+// *DuplicateError when its email got another active subscription to the
+// collection meanwhile.
+func (r *SubscriptionRepo) Confirm(ctx context.Context, phoneNumber, email, code string) (Subscription, error) {
+	row := r.pool.QueryRow(ctx, `
+		UPDATE subscriptions
+		SET status = 'active',
+		    confirmation_code = NULL,
+		    confirmation_expires_at = NULL,
+		    confirmed_at = now()
+		WHERE (phone_number = nullif($1, '') OR email = nullif($2, ''))
+		  AND confirmation_code = $3
+		  AND status = 'pending'
+		  AND confirmation_expires_at > now()
+		RETURNING `+subscriptionColumns,
+		phoneNumber, email, code)
+
+	s, err := scanSubscription(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Subscription{}, ErrNotFound
+	}
+	if dup := duplicateSubscription(err); dup != nil {
+		return Subscription{}, dup
+	}
+	if err != nil {
+		return Subscription{}, fmt.Errorf("db: confirm subscription: %w", err)
+	}
+	return s, nil
+}
+
+// DeactivateByPhone marks every subscription for phoneNumber inactive,
+// fails their texts still waiting in the outbox, and returns how many
+// subscriptions were changed.
+func (r *SubscriptionRepo) DeactivateByPhone(ctx context.Context, phoneNumber string) (int64, error) {
+	var n int64
+	err := r.pool.QueryRow(ctx, `
+		WITH deactivated AS (
+			UPDATE subscriptions
+			SET status = 'inactive', confirmation_code = NULL, confirmation_expires_at = NULL
+			WHERE phone_number = $1 AND status <> 'inactive'
+			RETURNING id
+		), cancelled AS (
+			UPDATE sms_outbox
+			SET status = 'failed', last_error = 'subscription inactive'
+			WHERE status = 'pending' AND subscription_id IN (SELECT id FROM deactivated)
+		)
+		SELECT count(*) FROM deactivated`,
+		phoneNumber).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("db: deactivate subscriptions: %w", err)
+	}
+	return n, nil
+}
+
+// List returns up to limit subscriptions ordered by creation time,
+// starting after cursor. An empty cursor starts from the beginning. The
+// returned cursor fetches the next page and is empty on the last one.
+func (r *SubscriptionRepo) List(ctx context.Context, limit int, cursor string) ([]Subscription, string, error) {
+	if limit < 1 {
+		limit = 1
+	}
+	var after *subscriptionCursor
+	if cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &c
+	}
+
+	// One extra row tells us whether another page exists.
+	var rows pgx.Rows
+	var err error
+	if after == nil {
+		rows, err = r.pool.Query(ctx, `
+			SELECT `+subscriptionColumns+`
+			FROM subscriptions
+			ORDER BY created_at, id
+			LIMIT $1`,
+			limit+1)
+	} else {
+		rows, err = r.pool.Query(ctx, `
+			SELECT `+subscriptionColumns+`
+			FROM subscriptions
+			WHERE (created_at, id) > ($1, $2::uuid)
+			ORDER BY created_at, id
+			LIMIT $3`,
+			after.CreatedAt, after.ID, limit+1)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("db: list subscriptions: %w", err)
+	}
+	subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Subscription, error) {
+		return scanSubscription(row)
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("db: list subscriptions: %w", err)
+	}
+
+	if len(subs) <= limit {
+		return subs, "", nil
+	}
+	subs = subs[:limit]
+	last := subs[len(subs)-1]
+	return subs, encodeCursor(subscriptionCursor{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
+// ListByCollection returns the active subscriptions for a collection.
+func (r *SubscriptionRepo) ListByCollection(ctx context.Context, collectionCode string) ([]Subscription, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE collection_code = $1 AND status = 'active'
+		ORDER BY created_at`,
+		collectionCode)
+	if err != nil {
+		return nil, fmt.Errorf("db: list subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("db: scan subscription: %w", err)
+		}
+		subs = append(subs, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db: list subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// Delete removes the subscription with id and fails its texts still
+// waiting in the outbox. It returns ErrNotFound when no row matches,
+// including when id is not a valid UUID.
+func (r *SubscriptionRepo) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrNotFound
+	}
+
+	var n int
+	err := r.pool.QueryRow(ctx, `
+		WITH deleted AS (
+			DELETE FROM subscriptions WHERE id = $1 RETURNING id
+		), cancelled AS (
+			UPDATE sms_outbox
+			SET status = 'failed', last_error = 'subscription deleted'
+			WHERE status = 'pending' AND subscription_id IN (SELECT id FROM deleted)
+		)
+		SELECT count(*) FROM deleted`,
+		id).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("db: delete subscription: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CountActiveByCollection returns how many active subscriptions code has.
+func (r *SubscriptionRepo) CountActiveByCollection(ctx context.Context, code string) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx,
+		`SELECT count(*) FROM subscriptions WHERE collection_code = $1 AND status = 'active'`,
+		code).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("db: count subscriptions for %s: %w", code, err)
+	}
+	return n, nil
+}
+
+// ListCollections returns every collection code with at least one
+// active subscriber.
+func (r *SubscriptionRepo) ListCollections(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT DISTINCT collection_code FROM subscriptions WHERE status = 'active' ORDER BY collection_code`)
+	if err != nil {
+		return nil, fmt.Errorf("db: list subscribed collections: %w", err)
+	}
+	codes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("db: list subscribed collections: %w", err)
+	}
+	return codes, nil
+}
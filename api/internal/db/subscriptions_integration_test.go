@@ -0,0 +1,139 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestListPagesThroughEverySubscription(t *testing.T) {
+	repo := NewSubscriptionRepo(testPool(t))
+	ctx := context.Background()
+
+	const total = 7
+	want := map[string]bool{}
+	for i := range total {
+		created, err := repo.Create(ctx, Subscription{PhoneNumber: fmt.Sprintf("+1202555%04d", i), CollectionCode: "BILLS", Channels: []string{ChannelSMS}}, "", time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[created.ID] = true
+	}
+
+	var (
+		cursor string
+		pages  int
+		prev   Subscription
+	)
+	seen := map[string]bool{}
+	for {
+		subs, next, err := repo.List(ctx, 3, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, s := range subs {
+			if seen[s.ID] {
+				t.Fatalf("subscription %s listed twice", s.ID)
+			}
+			if prev.ID != "" && s.CreatedAt.Before(prev.CreatedAt) {
+				t.Errorf("%s listed before older %s", prev.ID, s.ID)
+			}
+			seen[s.ID], prev = true, s
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if pages != 3 {
+		t.Errorf("got %d pages of 3, want 3", pages)
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Errorf("subscription %s never listed", id)
+		}
+	}
+}
+
+func TestListRejectsInvalidCursor(t *testing.T) {
+	repo := NewSubscriptionRepo(testPool(t))
+	if _, _, err := repo.List(context.Background(), 10, "garbage"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestCreateRejectsDuplicateActiveEmail(t *testing.T) {
+	repo := NewSubscriptionRepo(testPool(t))
+	ctx := context.Background()
+	email := Subscription{Email: "a@example.com", CollectionCode: "BILLS", Channels: []string{ChannelEmail}}
+
+	first, err := repo.Create(ctx, email, "123456", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Confirm(ctx, "", email.Email, "123456"); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	_, err = repo.Create(ctx, email, "654321", time.Now().Add(time.Hour))
+	var dup *DuplicateError
+	if !errors.As(err, &dup) || dup.Channel != ChannelEmail {
+		t.Fatalf("second create = %v, want an email DuplicateError", err)
+	}
+
+	// Another collection, or the same one under SMS, is fine.
+	other := email
+	other.CollectionCode = "FR"
+	if _, err := repo.Create(ctx, other, "111111", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("other collection: %v", err)
+	}
+	if first.ID == "" {
+		t.Fatal("first subscription has no ID")
+	}
+}
+
+func TestConfirmRejectsEmailActivatedMeanwhile(t *testing.T) {
+	pool := testPool(t)
+	repo := NewSubscriptionRepo(pool)
+	ctx := context.Background()
+
+	// Two pending rows for one address can exist from before the
+	// uniqueness check; only the first to confirm wins.
+	for _, code := range []string{"111111", "222222"} {
+		_, err := pool.Exec(ctx, `
+			INSERT INTO subscriptions (email, collection_code, channels, status, confirmation_code, confirmation_expires_at)
+			VALUES ('b@example.com', 'BILLS', '{email}', 'pending', $1, now() + interval '1 hour')`, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.Confirm(ctx, "", "b@example.com", "111111"); err != nil {
+		t.Fatalf("first confirm: %v", err)
+	}
+	_, err := repo.Confirm(ctx, "", "b@example.com", "222222")
+	var dup *DuplicateError
+	if !errors.As(err, &dup) || dup.Channel != ChannelEmail {
+		t.Fatalf("second confirm = %v, want an email DuplicateError", err)
+	}
+}
+
+func TestCreateRejectsDuplicateActivePhone(t *testing.T) {
+	repo := NewSubscriptionRepo(testPool(t))
+	ctx := context.Background()
+	sms := Subscription{PhoneNumber: "+12025550101", CollectionCode: "BILLS", Channels: []string{ChannelSMS}}
+
+	if _, err := repo.Create(ctx, sms, "", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := repo.Create(ctx, sms, "", time.Time{})
+	var dup *DuplicateError
+	if !errors.As(err, &dup) || dup.Channel != ChannelSMS {
+		t.Fatalf("second create = %v, want an sms DuplicateError", err)
+	}
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDuplicateSubscription(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"phone index", &pgconn.PgError{Code: uniqueViolation, ConstraintName: "subscriptions_phone_collection_key"}, ChannelSMS},
+		{"email index", fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: uniqueViolation, ConstraintName: "subscriptions_email_collection_key"}), ChannelEmail},
+		{"other index", &pgconn.PgError{Code: uniqueViolation, ConstraintName: "subscriptions_pkey"}, ""},
+		{"other error", &pgconn.PgError{Code: "23503", ConstraintName: "subscriptions_email_collection_key"}, ""},
+		{"not postgres", errors.New("boom"), ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := duplicateSubscription(tc.err)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("got %v, want nil", err)
+				}
+				return
+			}
+			var dup *DuplicateError
+			if !errors.As(err, &dup) || dup.Channel != tc.want {
+				t.Fatalf("got %v, want a %s DuplicateError", err, tc.want)
+			}
+			if !errors.Is(err, ErrAlreadyExists) {
+				t.Error("DuplicateError does not match ErrAlreadyExists")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var tracer = otel.Tracer("github.com/tingeytime/govinfo/api/internal/db")
+
+// QueryTracer times every query run through a pool and logs those slower
+// than a threshold. Each query also gets a client span. Only the SQL text
+// is logged or traced, never the arguments.
+//
+// It is also a prometheus.Collector for the query duration histogram, so
+// whoever owns the registry can register it straight from the pool config.
+type QueryTracer struct {
+	logger   *zap.Logger
+	slow     time.Duration
+	duration *prometheus.HistogramVec
+}
+
+// NewQueryTracer returns a tracer that logs queries taking at least slow.
+// A zero slow only records metrics.
+func NewQueryTracer(logger *zap.Logger, slow time.Duration) *QueryTracer {
+	return &QueryTracer{
+		logger: logger,
+		slow:   slow,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database query latency, by outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"status"}),
+	}
+}
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	sql   string
+	start time.Time
+	span  trace.Span
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := tracer.Start(ctx, queryOperation(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.query.text", data.SQL),
+		))
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{sql: data.SQL, start: time.Now(), span: span})
+}
+
+// queryOperation names a query's span after its leading keyword, such as
+// SELECT, keeping span names low-cardinality.
+func queryOperation(sql string) string {
+	if f := strings.Fields(sql); len(f) > 0 {
+		return strings.ToUpper(f[0])
+	}
+	return "query"
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(queryTraceKey{}).(queryTrace)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(qt.start)
+
+	status := "ok"
+	if data.Err != nil {
+		status = "error"
+		qt.span.RecordError(data.Err)
+		qt.span.SetStatus(codes.Error, data.Err.Error())
+	}
+	qt.span.End()
+	t.duration.WithLabelValues(status).Observe(elapsed.Seconds())
+
+	if t.slow > 0 && elapsed >= t.slow {
+		t.logger.Warn("slow query",
+			zap.String("sql", strings.Join(strings.Fields(qt.sql), " ")),
+			zap.Duration("duration", elapsed),
+			zap.Error(data.Err))
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (t *QueryTracer) Describe(ch chan<- *prometheus.Desc) {
+	t.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (t *QueryTracer) Collect(ch chan<- prometheus.Metric) {
+	t.duration.Collect(ch)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestQueryTracerLogsSlowQueries(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	qt := NewQueryTracer(zap.New(core), 20*time.Millisecond)
+	const sql = "SELECT *\n\t\tFROM subscriptions\n\t\tWHERE phone_number = $1"
+
+	ctx := qt.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql, Args: []any{"+12025550101"}})
+	time.Sleep(30 * time.Millisecond)
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	ctx = qt.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	entries := logs.FilterMessage("slow query").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d slow query logs, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["sql"] != "SELECT * FROM subscriptions WHERE phone_number = $1" {
+		t.Errorf("logged sql = %q", fields["sql"])
+	}
+	if d, _ := fields["duration"].(time.Duration); d < 20*time.Millisecond {
+		t.Errorf("logged duration = %v", fields["duration"])
+	}
+	if strings.Contains(fmt.Sprint(fields), "+12025550101") {
+		t.Errorf("query arguments logged: %v", fields)
+	}
+
+	// One series each for the ok and the failed query.
+	if n := testutil.CollectAndCount(qt, "db_query_duration_seconds"); n != 2 {
+		t.Errorf("duration series = %d, want 2", n)
+	}
+}
+
+func TestQueryTracerWithoutThreshold(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	qt := NewQueryTracer(zap.New(core), 0)
+	ctx := qt.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	time.Sleep(5 * time.Millisecond)
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	if logs.Len() != 0 {
+		t.Errorf("logged %d entries with slow-query logging off", logs.Len())
+	}
+	if n := testutil.CollectAndCount(qt, "db_query_duration_seconds"); n != 1 {
+		t.Errorf("duration series = %d, want 1", n)
+	}
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Webhook is a URL registered to receive new-package events for a
+// collection. Secret signs each delivery and is never serialized.
+type Webhook struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	CollectionCode string    `json:"collectionCode"`
+	Secret         string    `json:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// WebhookRepo stores webhook registrations in the webhooks table.
+type WebhookRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookRepo(pool *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{pool: pool}
+}
+
+const webhookColumns = `id::text, url, collection_code, secret, created_at`
+
+func scanWebhook(row pgx.Row) (Webhook, error) {
+	var h Webhook
+	err := row.Scan(&h.ID, &h.URL, &h.CollectionCode, &h.Secret, &h.CreatedAt)
+	return h, err
+}
+
+// Create registers url for events on collectionCode, signed with secret.
+func (r *WebhookRepo) Create(ctx context.Context, url, collectionCode, secret string) (Webhook, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO webhooks (url, collection_code, secret)
+		VALUES ($1, $2, $3)
+		RETURNING `+webhookColumns,
+		url, collectionCode, secret)
+
+	h, err := scanWebhook(row)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("db: create webhook: %w", err)
+	}
+	return h, nil
+}
+
+// ListByCollection returns the webhooks registered for a collection.
+func (r *WebhookRepo) ListByCollection(ctx context.Context, collectionCode string) ([]Webhook, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+webhookColumns+`
+		FROM webhooks
+		WHERE collection_code = $1
+		ORDER BY created_at`,
+		collectionCode)
+	if err != nil {
+		return nil, fmt.Errorf("db: list webhooks: %w", err)
+	}
+	hooks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Webhook, error) {
+		return scanWebhook(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db: list webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// ListCollections returns every collection code with a webhook.
+func (r *WebhookRepo) ListCollections(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT DISTINCT collection_code FROM webhooks ORDER BY collection_code`)
+	if err != nil {
+		return nil, fmt.Errorf("db: list webhook collections: %w", err)
+	}
+	codes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("db: list webhook collections: %w", err)
+	}
+	return codes, nil
+}
+
+// Delete removes the webhook with id. It returns ErrNotFound when no row
+// matches, including when id is not a valid UUID.
+func (r *WebhookRepo) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrNotFound
+	}
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("db: delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
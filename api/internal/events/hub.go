@@ -0,0 +1,92 @@
+// Package events is an in-process pub/sub hub for newly published
+// packages. The poller publishes to it and stream handlers subscribe per
+// collection.
+package events
+
+import (
+	"sync"
+
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+const defaultBuffer = 16
+
+// Hub fans each published package out to the subscribers of its
+// collection. It is safe for concurrent use.
+type Hub struct {
+	buffer int
+
+	mu     sync.Mutex
+	subs   map[string]map[chan govinfo.Package]struct{}
+	closed bool
+}
+
+// NewHub returns a hub whose subscribers each buffer up to buffer
+// packages.
+func NewHub(buffer int) *Hub {
+	if buffer < 1 {
+		buffer = defaultBuffer
+	}
+	return &Hub{buffer: buffer, subs: make(map[string]map[chan govinfo.Package]struct{})}
+}
+
+// Subscribe returns a channel of packages published in collectionCode and
+// a func that unsubscribes. The channel is closed on unsubscribe or when
+// the hub closes. cancel is safe to call more than once.
+func (h *Hub) Subscribe(collectionCode string) (<-chan govinfo.Package, func()) {
+	ch := make(chan govinfo.Package, h.buffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subs[collectionCode] == nil {
+		h.subs[collectionCode] = make(map[chan govinfo.Package]struct{})
+	}
+	h.subs[collectionCode][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[collectionCode][ch]; !ok {
+			return
+		}
+		delete(h.subs[collectionCode], ch)
+		if len(h.subs[collectionCode]) == 0 {
+			delete(h.subs, collectionCode)
+		}
+		close(ch)
+	}
+}
+
+// Publish hands pkg to every subscriber of its collection without
+// blocking. A subscriber whose buffer is full misses pkg; it returns how
+// many did.
+func (h *Hub) Publish(pkg govinfo.Package) (dropped int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[pkg.CollectionCode] {
+		select {
+		case ch <- pkg:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Close closes every subscriber's channel. Later subscriptions get a
+// closed channel straight away.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for code, chans := range h.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(h.subs, code)
+	}
+}
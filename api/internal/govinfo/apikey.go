@@ -0,0 +1,80 @@
+package govinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+// ErrInvalidAPIKey matches errors caused by GovInfo refusing the
+// configured API key: invalid, missing, disabled or over its quota.
+var ErrInvalidAPIKey = errors.New("govinfo: api key rejected")
+
+// APIKeyError is returned when GovInfo's API gateway (api.data.gov)
+// rejects the request's key. Code is the gateway's error code, such as
+// API_KEY_INVALID or OVER_RATE_LIMIT, and may be empty.
+type APIKeyError struct {
+	Path       string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIKeyError) Error() string {
+	msg := fmt.Sprintf("govinfo: %s: api key rejected with status %d", e.Path, e.StatusCode)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is matches ErrInvalidAPIKey and, like StatusError, apperr.ErrUpstream.
+func (e *APIKeyError) Is(target error) bool {
+	return target == ErrInvalidAPIKey || target == apperr.ErrUpstream
+}
+
+// maxErrorBodyBytes bounds how much of an error response is read.
+const maxErrorBodyBytes = 64 << 10
+
+// apiKeyError reads resp's body and reports whether it is the gateway
+// rejecting the key. Every 403 counts; a 429 only with OVER_RATE_LIMIT,
+// since GovInfo itself also answers 429 for short bursts.
+func apiKeyError(path string, resp *http.Response) *APIKeyError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, maxErrorBodyBytes)).Decode(&body)
+
+	code := body.Error.Code
+	if resp.StatusCode == http.StatusTooManyRequests && code != "OVER_RATE_LIMIT" {
+		return nil
+	}
+	if resp.StatusCode == http.StatusForbidden && code != "" && !strings.HasPrefix(code, "API_KEY_") {
+		return nil
+	}
+	return &APIKeyError{Path: path, StatusCode: resp.StatusCode, Code: code, Message: body.Error.Message}
+}
+
+// VerifyAPIKey makes one cheap authenticated call so a bad key can be
+// reported at startup rather than on the first user request. The error
+// matches ErrInvalidAPIKey when GovInfo rejects the key.
+func (c *Client) VerifyAPIKey(ctx context.Context) error {
+	_, err := c.ListCollections(ctx)
+	return err
+}
@@ -0,0 +1,137 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+// ErrUpstreamUnavailable is matched by calls rejected because the circuit
+// breaker is open.
+var ErrUpstreamUnavailable = errors.New("govinfo: upstream unavailable")
+
+// CircuitOpenError is returned instead of calling GovInfo while the
+// breaker is open. It matches ErrUpstreamUnavailable and
+// apperr.ErrUnavailable.
+type CircuitOpenError struct {
+	// Remaining is how long until the breaker lets a probe through.
+	Remaining time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("govinfo: upstream unavailable, circuit open for %s", e.Remaining.Round(time.Millisecond))
+}
+
+// RetryAfter reports when a retry may succeed; httpjson.WriteErr turns it
+// into a Retry-After header.
+func (e *CircuitOpenError) RetryAfter() time.Duration { return e.Remaining }
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrUpstreamUnavailable || target == apperr.ErrUnavailable
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is a consecutive-failure circuit breaker. After threshold
+// failures in a row it opens for cooldown, then lets a single probe
+// through; the probe's outcome closes or reopens it.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	// probing is set while the half-open probe is out.
+	probing bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may go out, returning a *CircuitOpenError
+// when it may not.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		wait := b.openedAt.Add(b.cooldown).Sub(b.now())
+		if wait > 0 {
+			return &CircuitOpenError{Remaining: wait}
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if !b.probing {
+			b.probing = true
+			return nil
+		}
+		// A probe is already out; everyone else waits for its verdict.
+		return &CircuitOpenError{Remaining: time.Second}
+	}
+	return nil
+}
+
+// record feeds the outcome of an allowed call back into the breaker.
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release gives up an allowed call that was cancelled before GovInfo
+// answered. It says nothing about upstream, so the state and failure count
+// are left alone; a half-open breaker just lets the next probe through.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// upstreamFailed reports whether err means GovInfo itself is unhealthy, as
+// opposed to a bad request or the caller giving up.
+func upstreamFailed(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= 500 || se.StatusCode == http.StatusTooManyRequests
+	}
+	// A rejected key won't recover by itself, but an exhausted quota
+	// behaves like any other 429.
+	var ke *APIKeyError
+	if errors.As(err, &ke) {
+		return ke.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
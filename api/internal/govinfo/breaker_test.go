@@ -0,0 +1,94 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreakerReleaseKeepsHalfOpen(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.record(true)
+	b.record(true)
+	if err := b.allow(); err == nil {
+		t.Fatal("allow on an open breaker succeeded")
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe after cooldown: %v", err)
+	}
+	if err := b.allow(); err == nil {
+		t.Fatal("second call got through while the probe was out")
+	}
+
+	b.release()
+	if b.state != breakerHalfOpen {
+		t.Fatalf("state after release = %v, want half-open", b.state)
+	}
+	if b.failures != 2 {
+		t.Fatalf("failures after release = %d, want 2", b.failures)
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("next probe after release: %v", err)
+	}
+
+	b.record(true)
+	if b.state != breakerOpen {
+		t.Fatalf("state after failed probe = %v, want open", b.state)
+	}
+}
+
+func TestBreakerReleaseKeepsFailureCount(t *testing.T) {
+	b := newBreaker(2, time.Minute)
+	b.record(true)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.release()
+	b.record(true)
+	if b.state != breakerOpen {
+		t.Fatalf("state = %v, want open after two failures around a cancelled call", b.state)
+	}
+}
+
+func TestSendCancelledDuringProbeDoesNotCloseBreaker(t *testing.T) {
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c, err := NewClient("key", srv.Client(), WithBaseURL(srv.URL), WithRetries(0), WithCircuitBreaker(1, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+	c.breaker.record(true)
+	now = now.Add(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err = c.send(ctx, apiRequest{method: http.MethodGet, url: srv.URL + "/collections"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("send error = %v, want context.Canceled", err)
+	}
+
+	if c.breaker.state != breakerHalfOpen {
+		t.Fatalf("state = %v, want half-open", c.breaker.state)
+	}
+	if c.breaker.failures != 1 {
+		t.Fatalf("failures = %d, want 1", c.breaker.failures)
+	}
+}
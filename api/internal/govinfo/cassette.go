@@ -0,0 +1,183 @@
+package govinfo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cassette modes for WithCassette.
+const (
+	// CassetteRecord serves fresh recordings from disk and records
+	// everything else from GovInfo.
+	CassetteRecord = "record"
+	// CassetteReplay serves only from disk and never calls GovInfo.
+	CassetteReplay = "replay"
+)
+
+// ErrCassetteMiss is returned in replay mode when a request has no
+// recording.
+var ErrCassetteMiss = errors.New("govinfo: no recorded response")
+
+// WithCassette records GovInfo responses into dir and replays them, for
+// offline development and tests. In record mode recordings older than ttl
+// are fetched again; a zero ttl keeps them forever. Replay ignores ttl.
+// It must never be enabled in production.
+func WithCassette(dir, mode string, ttl time.Duration) Option {
+	return func(c *Client) {
+		if dir == "" || (mode != CassetteRecord && mode != CassetteReplay) {
+			return
+		}
+		hc := *c.httpClient
+		next := hc.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		hc.Transport = &cassette{dir: dir, mode: mode, ttl: ttl, next: next, now: time.Now}
+		c.httpClient = &hc
+	}
+}
+
+// cassette is an http.RoundTripper that stores 2xx responses on disk,
+// one JSON file per request.
+type cassette struct {
+	dir  string
+	mode string
+	ttl  time.Duration
+	next http.RoundTripper
+	now  func() time.Time
+}
+
+type recording struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	RecordedAt time.Time   `json:"recordedAt"`
+}
+
+func (cs *cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("govinfo: cassette: read request body: %w", err)
+		}
+		reqBody = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	path := filepath.Join(cs.dir, cassetteKey(req, reqBody)+".json")
+	rec, err := readRecording(path)
+	switch {
+	case err == nil && (cs.mode == CassetteReplay || cs.ttl <= 0 || cs.now().Sub(rec.RecordedAt) < cs.ttl):
+		return rec.response(req), nil
+	case cs.mode == CassetteReplay:
+		return nil, fmt.Errorf("%w for %s %s", ErrCassetteMiss, req.Method, redactedURL(req))
+	}
+
+	resp, err := cs.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("govinfo: cassette: read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rec = recording{
+		Method:     req.Method,
+		URL:        redactedURL(req),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		RecordedAt: cs.now(),
+	}
+	if err := writeRecording(path, rec); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (rec recording) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}
+}
+
+// cassetteKey hashes the method, URL without the API key, and body, so
+// recordings can be shared without leaking the key.
+func cassetteKey(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + redactedURL(req) + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	q := u.Query()
+	q.Del("api_key")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func readRecording(path string) (recording, error) {
+	var rec recording
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, fmt.Errorf("govinfo: cassette: %s: %w", path, err)
+	}
+	return rec, nil
+}
+
+// writeRecording writes via a temp file so a crash never leaves a
+// truncated recording behind.
+func writeRecording(path string, rec recording) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("govinfo: cassette: encode: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("govinfo: cassette: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".recording-*")
+	if err != nil {
+		return fmt.Errorf("govinfo: cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("govinfo: cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("govinfo: cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("govinfo: cassette: %w", err)
+	}
+	return nil
+}
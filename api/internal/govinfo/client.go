@@ -0,0 +1,413 @@
+package govinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/buildinfo"
+	"github.com/tingeytime/govinfo/api/internal/cache"
+)
+
+const (
+	defaultBaseURL = "https://api.govinfo.gov"
+
+	defaultMaxRetries      = 3
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+	defaultRetryBudget     = 30 * time.Second
+	defaultTimeout         = 30 * time.Second
+
+	userAgentURL = "https://github.com/tingeytime/govinfo"
+)
+
+// defaultUserAgent names this service and its version so GovInfo can
+// tell our traffic apart.
+func defaultUserAgent() string {
+	return "govinfo-api/" + buildinfo.Get().Version + " (+" + userAgentURL + ")"
+}
+
+// Client is a small typed client for the GovInfo API.
+type Client struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+
+	limiter         *rate.Limiter
+	maxRetries      int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	retryBudget     time.Duration
+	timeout         time.Duration
+	maxBodyBytes    int64
+	breaker         *breaker
+	// sem bounds requests in flight upstream; nil means no bound.
+	sem *semaphore.Weighted
+
+	collections *cache.TTLCache[string, []Collection]
+	responses   *cache.TTLCache[string, cachedResponse]
+	// inflight coalesces concurrent identical GETs; see sharedGet.
+	inflight singleflight.Group
+}
+
+// NewClient returns a Client that authenticates with apiKey. A nil
+// httpClient falls back to DefaultHTTPClient. It fails only when the base
+// URL given to WithBaseURL isn't an absolute http or https URL.
+func NewClient(apiKey string, httpClient *http.Client, opts ...Option) (*Client, error) {
+	if httpClient == nil {
+		httpClient = DefaultHTTPClient()
+	}
+	c := &Client{
+		apiKey:          apiKey,
+		baseURL:         defaultBaseURL,
+		httpClient:      httpClient,
+		userAgent:       defaultUserAgent(),
+		limiter:         rate.NewLimiter(rate.Inf, 0),
+		maxRetries:      defaultMaxRetries,
+		retryBackoff:    defaultRetryBackoff,
+		retryMaxBackoff: defaultRetryMaxBackoff,
+		retryBudget:     defaultRetryBudget,
+		timeout:         defaultTimeout,
+		maxBodyBytes:    defaultMaxBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if u, err := url.Parse(c.baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("govinfo: base url %q must be an absolute http or https URL", c.baseURL)
+	}
+	return c, nil
+}
+
+// StatusError is returned when GovInfo answers with a non-2xx status.
+type StatusError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("govinfo: %s: unexpected status %d", e.Path, e.StatusCode)
+}
+
+// Is classifies every StatusError as apperr.ErrUpstream.
+func (e *StatusError) Is(target error) bool {
+	return target == apperr.ErrUpstream
+}
+
+// apiRequest describes one call to GovInfo.
+type apiRequest struct {
+	method string
+	url    string // absolute; query is merged into it
+	query  url.Values
+	body   []byte
+	// header is added to the request. Accept defaults to JSON.
+	header http.Header
+
+	// idempotent marks a non-GET request that only reads, such as a
+	// search, so it may be retried like a GET.
+	idempotent bool
+
+	// stream marks a request whose body the caller streams for as long
+	// as it likes, such as a download. Its concurrency slot is freed once
+	// the response headers arrive, so a slow reader can't starve others.
+	stream bool
+
+	// conditional revalidates the response with If-None-Match /
+	// If-Modified-Since when the client has a response cache. It is
+	// decided per method: only idempotent GETs should set it.
+	conditional bool
+
+	// noStore keeps a conditional request's response out of the cache,
+	// for URLs that are never asked for twice, such as listings keyed by
+	// a timestamp. Concurrent callers still share the call.
+	noStore bool
+}
+
+// getJSON issues a conditional GET against path and decodes the JSON body
+// into dst.
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, dst any) error {
+	return c.doJSON(ctx, apiRequest{
+		method:      http.MethodGet,
+		url:         c.baseURL + path,
+		query:       query,
+		conditional: true,
+	}, dst)
+}
+
+// postQuery sends payload as a JSON body to path, a read-only endpoint
+// such as /search, and decodes the response into dst. Since it only
+// reads, it is retried like a GET.
+func (c *Client) postQuery(ctx context.Context, path string, payload, dst any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("govinfo: encode request: %w", err)
+	}
+	return c.doJSON(ctx, apiRequest{
+		method:     http.MethodPost,
+		url:        c.baseURL + path,
+		body:       body,
+		header:     http.Header{"Content-Type": {"application/json"}},
+		idempotent: true,
+	}, dst)
+}
+
+func (c *Client) doJSON(ctx context.Context, req apiRequest, dst any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if req.conditional {
+		res, err := c.sharedGet(ctx, req)
+		if err != nil {
+			return err
+		}
+		return decodeJSON(res.path, res.contentType, res.body, dst)
+	}
+
+	res, err := c.get(ctx, req)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(res.path, res.contentType, res.body, dst)
+}
+
+// fetched is a response body shared by every caller of sharedGet. It must
+// not be modified.
+type fetched struct {
+	path        string
+	contentType string
+	body        []byte
+}
+
+// sharedGet performs an idempotent GET once for all concurrent callers
+// asking for the same URL, so a burst of requests after a cache expiry
+// costs one upstream call. Only the call in flight is shared: errors, like
+// results, are not remembered once it returns.
+//
+// The upstream call is detached from the caller that started it and runs
+// until the later of that caller's deadline and the client timeout, so one
+// caller giving up early doesn't fail the others. Each caller still
+// returns as soon as its own ctx is done.
+func (c *Client) sharedGet(ctx context.Context, req apiRequest) (fetched, error) {
+	ch := c.inflight.DoChan(cacheKey(req), func() (any, error) {
+		shared := context.WithoutCancel(ctx)
+		deadline, ok := ctx.Deadline()
+		if c.timeout > 0 {
+			if d := time.Now().Add(c.timeout); !ok || d.After(deadline) {
+				deadline, ok = d, true
+			}
+		}
+		if ok {
+			var cancel context.CancelFunc
+			shared, cancel = context.WithDeadline(shared, deadline)
+			defer cancel()
+		}
+		if c.responses != nil && !req.noStore {
+			return c.getConditional(shared, req)
+		}
+		return c.get(shared, req)
+	})
+
+	select {
+	case <-ctx.Done():
+		path := req.url
+		if u, err := url.Parse(req.url); err == nil {
+			path = u.Path
+		}
+		return fetched{}, fmt.Errorf("govinfo: %s: %w", path, ctx.Err())
+	case res := <-ch:
+		if res.Err != nil {
+			return fetched{}, res.Err
+		}
+		return res.Val.(fetched), nil
+	}
+}
+
+// get performs req and reads the whole (size-limited) body, whatever the
+// method.
+func (c *Client) get(ctx context.Context, req apiRequest) (fetched, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return fetched{}, err
+	}
+	defer resp.Body.Close()
+	path := resp.Request.URL.Path
+
+	body, err := io.ReadAll(c.limitBody(resp.Body))
+	if err != nil {
+		return fetched{}, fmt.Errorf("govinfo: %s: read response: %w", path, err)
+	}
+	return fetched{path: path, contentType: resp.Header.Get("Content-Type"), body: body}, nil
+}
+
+// withTimeout bounds ctx by the client's default timeout unless the caller
+// already set a deadline of its own. The timeout must cover reading the
+// body too, so callers apply it around send and the decode, not inside send.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// send performs req, pacing it through the client's limiter and retrying
+// as described in retry.go. A non-2xx final response is
+// returned as a *StatusError, except that 304 is passed through when req
+// carried validators. On success the caller owns the response body.
+//
+// With a circuit breaker configured, calls fail fast with a
+// *CircuitOpenError while it is open.
+func (c *Client) send(ctx context.Context, r apiRequest) (resp *http.Response, err error) {
+	ctx, span := startSpan(ctx, r.method)
+	defer func() { endSpan(span, resp, err) }()
+
+	if c.breaker == nil {
+		return c.sendWithRetry(ctx, r)
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resp, err = c.sendWithRetry(ctx, r)
+	if errors.Is(err, context.Canceled) {
+		c.breaker.release()
+	} else {
+		c.breaker.record(upstreamFailed(err))
+	}
+	return resp, err
+}
+
+func (c *Client) sendWithRetry(ctx context.Context, r apiRequest) (*http.Response, error) {
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return nil, fmt.Errorf("govinfo: build url: %w", err)
+	}
+	path := u.Path
+	q := u.Query()
+	for k, v := range r.query {
+		q[k] = v
+	}
+	q.Set("api_key", "REDACTED")
+	u.RawQuery = q.Encode()
+	redactedURL := u.String()
+	q.Set("api_key", c.apiKey)
+	u.RawQuery = q.Encode()
+	setSpanURL(ctx, redactedURL)
+
+	// budget is what is left of the time this call may spend waiting
+	// between retries.
+	budget := c.retryBudget
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("govinfo: %s: %w", path, err)
+		}
+
+		var reqBody io.Reader
+		if r.body != nil {
+			reqBody = bytes.NewReader(r.body)
+		}
+		req, err := http.NewRequestWithContext(ctx, r.method, u.String(), reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("govinfo: build request: %w", err)
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+		req.Header.Set("Accept", "application/json")
+		for k, v := range r.header {
+			req.Header[k] = v
+		}
+		injectTrace(ctx, req.Header)
+
+		release, err := c.acquire(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("govinfo: %s: %w", path, err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			release()
+			// Transport errors quote the URL, which carries the key.
+			var ue *url.Error
+			if errors.As(err, &ue) {
+				ue.URL = redactedURL
+			}
+			err = fmt.Errorf("govinfo: %s: %w", path, err)
+			if ctx.Err() != nil || !r.retryable() || attempt >= c.maxRetries {
+				return nil, err
+			}
+			delay, ok := c.retryDelay(attempt, 0, &budget)
+			if !ok {
+				return nil, err
+			}
+			if err := sleep(ctx, delay); err != nil {
+				return nil, fmt.Errorf("govinfo: %s: %w", path, err)
+			}
+			continue
+		}
+
+		if (resp.StatusCode >= 200 && resp.StatusCode <= 299) ||
+			(resp.StatusCode == http.StatusNotModified && isConditional(req.Header)) {
+			if r.stream {
+				release()
+			} else {
+				resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			}
+			return resp, nil
+		}
+
+		keyErr := apiKeyError(path, resp)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		release()
+
+		var statusErr error = &StatusError{Path: path, StatusCode: resp.StatusCode}
+		if keyErr != nil {
+			statusErr = keyErr
+		}
+		if !r.retryable() || !retryableStatus(resp.StatusCode) || attempt >= c.maxRetries {
+			return nil, statusErr
+		}
+		delay, ok := c.retryDelay(attempt, retryAfter(resp.Header.Get("Retry-After")), &budget)
+		if !ok {
+			return nil, statusErr
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("govinfo: %s: %w", path, err)
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date. It returns zero when the header is absent or unparseable.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
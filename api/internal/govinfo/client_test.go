@@ -0,0 +1,73 @@
+package govinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client for a test GovInfo served by h. It
+// makes no retries unless opts ask for them.
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	opts = append([]Option{WithBaseURL(srv.URL), WithRetries(0)}, opts...)
+	c, err := NewClient("test-key", srv.Client(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientUsesBaseURL(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"packageId":"BILLS-1"}`))
+	}))
+	defer srv.Close()
+
+	// A path prefix is kept and a trailing slash dropped.
+	c, err := NewClient("test-key", srv.Client(), WithBaseURL(srv.URL+"/mock/"), WithRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.baseURL != srv.URL+"/mock" {
+		t.Errorf("baseURL = %q, want %q", c.baseURL, srv.URL+"/mock")
+	}
+	if _, err := c.GetPackageSummary(context.Background(), "BILLS-1"); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/mock/packages/BILLS-1/summary" {
+		t.Errorf("request path = %q, want it under the base URL", gotPath)
+	}
+}
+
+func TestNewClientDefaultBaseURL(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithBaseURL("")}} {
+		c, err := NewClient("test-key", http.DefaultClient, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.baseURL != defaultBaseURL {
+			t.Errorf("baseURL = %q, want the default %q", c.baseURL, defaultBaseURL)
+		}
+	}
+}
+
+func TestNewClientRejectsBadBaseURL(t *testing.T) {
+	for _, base := range []string{
+		"api.govinfo.gov",
+		"/relative",
+		"ftp://api.govinfo.gov",
+		"https://",
+		"http://[::1",
+	} {
+		if _, err := NewClient("test-key", http.DefaultClient, WithBaseURL(base)); err == nil {
+			t.Errorf("NewClient accepted base URL %q", base)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package govinfo
+
+import (
+	"slices"
+	"sync"
+)
+
+// CollectionCode identifies a GovInfo collection, such as BILLS or FR.
+type CollectionCode string
+
+var (
+	collectionNamesMu sync.RWMutex
+	// collectionNames starts with the codes GovInfo published when this
+	// was written and is replaced by each successful ListCollections
+	// fetch, so new collections are picked up without a release.
+	collectionNames = map[CollectionCode]string{
+		"BILLS":      "Congressional Bills",
+		"BILLSTATUS": "Congressional Bill Status",
+		"BUDGET":     "United States Budget",
+		"CCAL":       "Congressional Calendars",
+		"CDIR":       "Congressional Directory",
+		"CDOC":       "Congressional Documents",
+		"CFR":        "Code of Federal Regulations",
+		"CHRG":       "Congressional Hearings",
+		"CMR":        "Congressionally Mandated Reports",
+		"COMPS":      "Statutes Compilations",
+		"CPD":        "Compilation of Presidential Documents",
+		"CPRT":       "Congressional Committee Prints",
+		"CREC":       "Congressional Record",
+		"CRECB":      "Congressional Record (Bound Edition)",
+		"CRI":        "Congressional Record Index",
+		"CRPT":       "Congressional Reports",
+		"CZIC":       "Coastal Zone Information Center",
+		"ECFR":       "Electronic Code of Federal Regulations",
+		"ECONI":      "Economic Indicators",
+		"ERIC":       "Education Reports from ERIC",
+		"ERP":        "Economic Report of the President",
+		"FR":         "Federal Register",
+		"GAOREPORTS": "GAO Reports and Comptroller General Decisions",
+		"GOVMAN":     "United States Government Manual",
+		"GOVPUB":     "Biennial Report to Congress",
+		"GPO":        "Additional Government Publications",
+		"HJOURNAL":   "Journal of the House of Representatives",
+		"HMAN":       "House Rules and Manual",
+		"HOB":        "History of Bills",
+		"LSA":        "List of CFR Sections Affected",
+		"PAI":        "Privacy Act Issuances",
+		"PLAW":       "Public and Private Laws",
+		"PPP":        "Public Papers of the Presidents of the United States",
+		"SERIALSET":  "Congressional Serial Set",
+		"SJOURNAL":   "Senate Journal",
+		"SMAN":       "Senate Manual",
+		"STATUTE":    "Statutes at Large",
+		"USCODE":     "United States Code",
+		"USCOURTS":   "United States Courts Opinions",
+		"USREPORTS":  "United States Reports",
+	}
+)
+
+// Valid reports whether c is a collection GovInfo is known to publish.
+func (c CollectionCode) Valid() bool {
+	collectionNamesMu.RLock()
+	defer collectionNamesMu.RUnlock()
+	_, ok := collectionNames[c]
+	return ok
+}
+
+// Name returns the collection's human-readable name, or "" if c isn't
+// valid.
+func (c CollectionCode) Name() string {
+	collectionNamesMu.RLock()
+	defer collectionNamesMu.RUnlock()
+	return collectionNames[c]
+}
+
+// KnownCollectionCodes returns every valid code, sorted.
+func KnownCollectionCodes() []CollectionCode {
+	collectionNamesMu.RLock()
+	codes := make([]CollectionCode, 0, len(collectionNames))
+	for c := range collectionNames {
+		codes = append(codes, c)
+	}
+	collectionNamesMu.RUnlock()
+	slices.Sort(codes)
+	return codes
+}
+
+// setKnownCollections replaces the known codes with collections. An empty
+// list is ignored rather than invalidating every code.
+func setKnownCollections(collections []Collection) {
+	if len(collections) == 0 {
+		return
+	}
+	names := make(map[CollectionCode]string, len(collections))
+	for _, c := range collections {
+		names[c.CollectionCode] = c.CollectionName
+	}
+	collectionNamesMu.Lock()
+	collectionNames = names
+	collectionNamesMu.Unlock()
+}
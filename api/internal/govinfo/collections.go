@@ -0,0 +1,40 @@
+package govinfo
+
+import (
+	"context"
+	"slices"
+)
+
+const collectionsCacheKey = "collections"
+
+// Collection is a GovInfo collection such as BILLS or FR.
+type Collection struct {
+	CollectionCode CollectionCode `json:"collectionCode"`
+	CollectionName string         `json:"collectionName"`
+	PackageCount   int            `json:"packageCount"`
+}
+
+// ListCollections returns every collection GovInfo publishes. When the
+// client was built with WithCollectionsCacheTTL, results are served from
+// the cache until they expire. Each fetch also refreshes the codes
+// CollectionCode.Valid accepts.
+func (c *Client) ListCollections(ctx context.Context) ([]Collection, error) {
+	if c.collections != nil {
+		if cached, ok := c.collections.Get(collectionsCacheKey); ok {
+			return slices.Clone(cached), nil
+		}
+	}
+
+	var body struct {
+		Collections []Collection `json:"collections"`
+	}
+	if err := c.getJSON(ctx, "/collections", nil, &body); err != nil {
+		return nil, err
+	}
+	setKnownCollections(body.Collections)
+
+	if c.collections != nil {
+		c.collections.Set(collectionsCacheKey, slices.Clone(body.Collections))
+	}
+	return body.Collections, nil
+}
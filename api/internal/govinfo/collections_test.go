@@ -0,0 +1,68 @@
+package govinfo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const collectionsJSON = `{"collections":[{"collectionCode":"BILLS","collectionName":"Congressional Bills","packageCount":1}]}`
+
+func TestListCollectionsCachedWithinTTL(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, collectionsJSON)
+	}, WithCollectionsCacheTTL(time.Minute))
+
+	for range 3 {
+		got, err := c.ListCollections(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].CollectionCode != "BILLS" {
+			t.Fatalf("collections = %+v", got)
+		}
+		// Callers get their own copy of the cached slice.
+		got[0].CollectionName = "changed"
+	}
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.ListCollections(context.Background())
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("upstream called %d times within the TTL, want 1", n)
+	}
+	got, _ := c.ListCollections(context.Background())
+	if got[0].CollectionName != "Congressional Bills" {
+		t.Error("a caller's change leaked into the cache")
+	}
+}
+
+func TestListCollectionsUncached(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, collectionsJSON)
+	})
+
+	for range 2 {
+		if _, err := c.ListCollections(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upstream called %d times, want 2", n)
+	}
+}
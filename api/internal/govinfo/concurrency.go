@@ -0,0 +1,34 @@
+package govinfo
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// acquire takes one of the client's upstream request slots, waiting until
+// one is free or ctx ends. The returned func gives it back and is safe to
+// call more than once. Without WithMaxConcurrency there is no limit.
+func (c *Client) acquire(ctx context.Context) (release func(), err error) {
+	if c.sem == nil {
+		return func() {}, nil
+	}
+	if err := c.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() { once.Do(func() { c.sem.Release(1) }) }, nil
+}
+
+// releasingBody frees a request slot once the response body is closed, so
+// a slot covers reading the body and not just the round trip.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,123 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// inFlight counts the requests a test GovInfo is serving at once and the
+// most it ever served together.
+type inFlight struct {
+	now, peak atomic.Int32
+}
+
+func (f *inFlight) handler(delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := f.now.Add(1)
+		defer f.now.Add(-1)
+		for {
+			peak := f.peak.Load()
+			if n <= peak || f.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"packageId":"x"}`)
+	}
+}
+
+// summaries fetches n different package summaries at once.
+func summaries(t *testing.T, c *Client, n int) {
+	t.Helper()
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetPackageSummary(context.Background(), fmt.Sprintf("PKG-%d", i)); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestMaxConcurrencyCapsInFlight(t *testing.T) {
+	var f inFlight
+	c := newTestClient(t, f.handler(20*time.Millisecond), WithMaxConcurrency(3))
+
+	summaries(t, c, 20)
+	if peak := f.peak.Load(); peak != 3 {
+		t.Errorf("peak in-flight requests = %d, want 3", peak)
+	}
+}
+
+func TestNoMaxConcurrency(t *testing.T) {
+	var f inFlight
+	c := newTestClient(t, f.handler(100*time.Millisecond), WithMaxConcurrency(0))
+
+	summaries(t, c, 10)
+	if peak := f.peak.Load(); peak < 5 {
+		t.Errorf("peak in-flight requests = %d, want no cap", peak)
+	}
+}
+
+func TestMaxConcurrencyWaitEndsWithContext(t *testing.T) {
+	var hits atomic.Int32
+	released := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-released
+		io.WriteString(w, `{}`)
+	}, WithMaxConcurrency(1))
+	t.Cleanup(func() { close(released) })
+
+	// The first request takes the only slot and holds it.
+	go c.GetPackageSummary(context.Background(), "PKG-1")
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetPackageSummary(ctx, "PKG-2")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the wait cut off by the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v for a slot, want the deadline honoured", elapsed)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("GovInfo saw %d requests, want the waiting one never sent", n)
+	}
+}
+
+func TestMaxConcurrencyFreesSlotOnError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}, WithMaxConcurrency(1))
+
+	for i := range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := c.GetPackageSummary(ctx, fmt.Sprintf("PKG-%d", i))
+		cancel()
+		var se *StatusError
+		if !errors.As(err, &se) {
+			t.Fatalf("call %d: err = %v, want a StatusError rather than a wait for the slot", i, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package govinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxCachedResponses bounds the response cache. Once it holds that many
+// live entries, new responses are not kept until some expire.
+const maxCachedResponses = 1024
+
+// cachedResponse is a response body kept alongside the validators needed
+// to revalidate it.
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	contentType  string
+	body         []byte
+}
+
+func isConditional(h http.Header) bool {
+	return h.Get("If-None-Match") != "" || h.Get("If-Modified-Since") != ""
+}
+
+// getConditional performs req with the validators from any cached
+// response and returns either the fresh body or, on 304, the cached one.
+func (c *Client) getConditional(ctx context.Context, req apiRequest) (fetched, error) {
+	key := cacheKey(req)
+	cached, hit := c.responses.Get(key)
+
+	if hit {
+		header := req.header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		if cached.etag != "" {
+			header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			header.Set("If-Modified-Since", cached.lastModified)
+		}
+		req.header = header
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return fetched{}, err
+	}
+	defer resp.Body.Close()
+	path := resp.Request.URL.Path
+
+	if resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		// Refresh the entry's TTL: GovInfo just confirmed it.
+		c.responses.Set(key, cached)
+		return fetched{path: path, contentType: cached.contentType, body: cached.body}, nil
+	}
+
+	body, err := io.ReadAll(c.limitBody(resp.Body))
+	if err != nil {
+		return fetched{}, fmt.Errorf("govinfo: %s: read response: %w", path, err)
+	}
+	res := fetched{path: path, contentType: resp.Header.Get("Content-Type"), body: body}
+	// Only bodies that decode are worth revalidating; a bad one would
+	// otherwise be replayed on every 304.
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if (etag != "" || lastModified != "") && json.Valid(body) {
+		c.storeResponse(key, cachedResponse{etag: etag, lastModified: lastModified, contentType: res.contentType, body: body})
+	}
+	return res, nil
+}
+
+// storeResponse caches r under key, first dropping expired entries when
+// the cache is full. It gives up if every entry is still live.
+func (c *Client) storeResponse(key string, r cachedResponse) {
+	if c.responses.Len() >= maxCachedResponses {
+		c.responses.Purge()
+		if c.responses.Len() >= maxCachedResponses {
+			return
+		}
+	}
+	c.responses.Set(key, r)
+}
+
+// cacheKey identifies a request by method and URL. The API key is not
+// part of the URL at this point, so it never ends up in cache keys.
+func cacheKey(req apiRequest) string {
+	u := req.url
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	return req.method + " " + u
+}
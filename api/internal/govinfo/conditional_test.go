@@ -0,0 +1,127 @@
+package govinfo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConditionalGetReusesBodyOn304(t *testing.T) {
+	for name, validator := range map[string]struct{ header, request, value string }{
+		"etag":          {"ETag", "If-None-Match", `"v1"`},
+		"last-modified": {"Last-Modified", "If-Modified-Since", "Mon, 01 Jan 2024 00:00:00 GMT"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls, notModified atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if r.Header.Get(validator.request) == validator.value {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set(validator.header, validator.value)
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, collectionsJSON)
+			}, WithConditionalRequests(time.Minute))
+
+			for range 2 {
+				got, err := c.ListCollections(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != 1 || got[0].CollectionCode != "BILLS" {
+					t.Fatalf("collections = %+v, want the cached payload", got)
+				}
+			}
+			if calls.Load() != 2 || notModified.Load() != 1 {
+				t.Errorf("calls = %d, 304s = %d; want 2 and 1", calls.Load(), notModified.Load())
+			}
+		})
+	}
+}
+
+func TestConditionalGetDisabled(t *testing.T) {
+	var validators atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if isConditional(r.Header) {
+			validators.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, collectionsJSON)
+	})
+
+	for range 2 {
+		if _, err := c.ListCollections(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := validators.Load(); n != 0 {
+		t.Errorf("%d requests carried validators without WithConditionalRequests", n)
+	}
+}
+
+func TestConditionalGetSkipsInvalidBodies(t *testing.T) {
+	var validators atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if isConditional(r.Header) {
+			validators.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "{")
+	}, WithConditionalRequests(time.Minute))
+
+	for range 2 {
+		if _, err := c.ListCollections(context.Background()); err == nil {
+			t.Fatal("want a decode error")
+		}
+	}
+	if n := validators.Load(); n != 0 {
+		t.Errorf("a body that doesn't decode was revalidated %d times", n)
+	}
+}
+
+func TestConditionalGetSkipsCollectionUpdates(t *testing.T) {
+	var validators atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if isConditional(r.Header) {
+			validators.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"count":0,"packages":[]}`)
+	}, WithConditionalRequests(time.Minute))
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for range 2 {
+		if _, err := c.ListCollectionUpdates(context.Background(), "BILLS", since, 10, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := validators.Load(); n != 0 || c.responses.Len() != 0 {
+		t.Errorf("%d revalidations and %d cached responses, want the listing left uncached", n, c.responses.Len())
+	}
+}
+
+func TestConditionalGetCacheIsBounded(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"packageId":"BILLS-1"}`)
+	}, WithConditionalRequests(time.Minute))
+
+	for i := range maxCachedResponses + 10 {
+		if _, err := c.GetPackageSummary(context.Background(), "BILLS-"+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := c.responses.Len(); n != maxCachedResponses {
+		t.Errorf("cache holds %d responses, want the cap of %d", n, maxCachedResponses)
+	}
+}
@@ -0,0 +1,113 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// blockingHandler holds each request open until the client goes away,
+// then reports that on aborted. The body is drained first: the server
+// only notices a closed connection once it has read the request.
+func blockingHandler(received chan<- struct{}, aborted chan<- struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		received <- struct{}{}
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func TestCancelAbortsUpstreamCall(t *testing.T) {
+	received, aborted := make(chan struct{}, 1), make(chan struct{})
+	c := newTestClient(t, blockingHandler(received, aborted))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Search(ctx, SearchQuery{Query: "climate"})
+		errc <- err
+	}()
+	<-received
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Search did not return after cancel")
+	}
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Error("upstream request kept running after cancel")
+	}
+}
+
+func TestDefaultTimeoutBoundsCall(t *testing.T) {
+	received, aborted := make(chan struct{}, 1), make(chan struct{})
+	c := newTestClient(t, blockingHandler(received, aborted), WithTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := c.Search(context.Background(), SearchQuery{Query: "climate"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("call took %s with a 50ms timeout", elapsed)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Error("upstream request kept running after the timeout")
+	}
+}
+
+func TestCallerDeadlineOverridesDefaultTimeout(t *testing.T) {
+	c := &Client{timeout: time.Millisecond}
+	parent, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	ctx, done := c.withTimeout(parent)
+	defer done()
+	if dl, _ := ctx.Deadline(); time.Until(dl) < time.Minute {
+		t.Errorf("deadline %s replaced the caller's", dl)
+	}
+
+	ctx, done = c.withTimeout(context.Background())
+	defer done()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("no default deadline applied")
+	}
+}
+
+func TestCancelReturnsSharedGetCaller(t *testing.T) {
+	received, aborted := make(chan struct{}, 1), make(chan struct{})
+	c := newTestClient(t, blockingHandler(received, aborted), WithTimeout(200*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.ListCollections(ctx)
+		errc <- err
+	}()
+	<-received
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("ListCollections did not return after cancel")
+	}
+}
@@ -0,0 +1,76 @@
+package govinfo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+// decodeSnippetLen caps how much of a malformed body DecodeError keeps.
+const decodeSnippetLen = 200
+
+// ErrUpstreamDecode matches errors caused by GovInfo answering 2xx with a
+// body that isn't the JSON asked for, such as an HTML error page from a
+// proxy or a truncated response.
+var ErrUpstreamDecode = errors.New("govinfo: malformed response")
+
+// DecodeError is returned when a GovInfo response can't be decoded.
+// Snippet is the start of the body, for the logs; it must not be passed
+// on to API callers.
+type DecodeError struct {
+	Path        string
+	ContentType string
+	Snippet     string
+	Err         error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("govinfo: %s: decode %q response: %v (body starts %q)", e.Path, e.ContentType, e.Err, e.Snippet)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// Is matches ErrUpstreamDecode and, like StatusError, apperr.ErrUpstream.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrUpstreamDecode || target == apperr.ErrUpstream
+}
+
+// decodeJSON decodes body into dst, failing with a *DecodeError when the
+// content type says it isn't JSON or the body doesn't parse. A missing
+// content type is given the benefit of the doubt.
+func decodeJSON(path, contentType string, body []byte, dst any) error {
+	if contentType != "" && !isJSONContentType(contentType) {
+		return newDecodeError(path, contentType, body, errors.New("not JSON"))
+	}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(dst); err != nil {
+		return newDecodeError(path, contentType, body, err)
+	}
+	return nil
+}
+
+func newDecodeError(path, contentType string, body []byte, err error) *DecodeError {
+	snippet := body
+	if len(snippet) > decodeSnippetLen {
+		snippet = snippet[:decodeSnippetLen]
+		// Don't cut a multi-byte rune in half.
+		for len(snippet) > 0 && !utf8.Valid(snippet) {
+			snippet = snippet[:len(snippet)-1]
+		}
+	}
+	return &DecodeError{Path: path, ContentType: contentType, Snippet: string(snippet), Err: err}
+}
+
+// isJSONContentType accepts application/json and any +json type.
+func isJSONContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
@@ -0,0 +1,77 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+const htmlErrorPage = `<!DOCTYPE html><html><head><title>502 Bad Gateway</title></head><body>upstream proxy error</body></html>`
+
+func TestHTMLBodyIsDecodeError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, htmlErrorPage)
+	})
+
+	_, err := c.GetPackageSummary(context.Background(), "BILLS-1")
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("err = %v, want a *DecodeError", err)
+	}
+	if !errors.Is(err, ErrUpstreamDecode) || !errors.Is(err, apperr.ErrUpstream) {
+		t.Errorf("err = %v, want it to match ErrUpstreamDecode and apperr.ErrUpstream", err)
+	}
+	if de.ContentType != "text/html; charset=utf-8" || !strings.HasPrefix(de.Snippet, "<!DOCTYPE html>") {
+		t.Errorf("DecodeError = %+v", de)
+	}
+	if !strings.HasSuffix(de.Path, "/packages/BILLS-1/summary") {
+		t.Errorf("Path = %q", de.Path)
+	}
+}
+
+func TestTruncatedJSONIsDecodeError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"packageId":"BILLS-1","title":"A bi`)
+	})
+
+	_, err := c.GetPackageSummary(context.Background(), "BILLS-1")
+	if !errors.Is(err, ErrUpstreamDecode) {
+		t.Fatalf("err = %v, want ErrUpstreamDecode", err)
+	}
+}
+
+func TestDecodeJSONContentTypes(t *testing.T) {
+	for _, ct := range []string{"", "application/json", "application/json; charset=utf-8", "application/problem+json"} {
+		var v struct{ A int }
+		if err := decodeJSON("/x", ct, []byte(`{"A":1}`), &v); err != nil || v.A != 1 {
+			t.Errorf("content type %q: %v, %+v", ct, err, v)
+		}
+	}
+	for _, ct := range []string{"text/html", "text/plain", "application/xml", ";;bad"} {
+		var v struct{ A int }
+		if err := decodeJSON("/x", ct, []byte(`{"A":1}`), &v); !errors.Is(err, ErrUpstreamDecode) {
+			t.Errorf("content type %q: err = %v, want ErrUpstreamDecode", ct, err)
+		}
+	}
+}
+
+func TestDecodeErrorSnippetIsCapped(t *testing.T) {
+	// Multi-byte runes straddle the cut.
+	body := []byte(strings.Repeat("é", decodeSnippetLen))
+	de := newDecodeError("/x", "text/html", body, errors.New("not JSON"))
+	if len(de.Snippet) > decodeSnippetLen || !utf8.ValidString(de.Snippet) {
+		t.Errorf("snippet is %d bytes, valid UTF-8 %v; want at most %d and valid",
+			len(de.Snippet), utf8.ValidString(de.Snippet), decodeSnippetLen)
+	}
+	if short := newDecodeError("/x", "text/html", []byte("oops"), nil); short.Snippet != "oops" {
+		t.Errorf("short snippet = %q", short.Snippet)
+	}
+}
@@ -0,0 +1,113 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for a format DownloadPackage
+	// doesn't know how to resolve.
+	ErrUnsupportedFormat = errors.New("govinfo: unsupported download format")
+	// ErrFormatUnavailable is returned when the package isn't published
+	// in the requested format.
+	ErrFormatUnavailable = errors.New("govinfo: format not available for package")
+)
+
+type downloadFormat struct {
+	link        func(DownloadLinks) string
+	contentType string
+	extension   string
+}
+
+var downloadFormats = map[string]downloadFormat{
+	"pdf":  {func(l DownloadLinks) string { return l.PDFLink }, "application/pdf", "pdf"},
+	"xml":  {func(l DownloadLinks) string { return l.XMLLink }, "application/xml", "xml"},
+	"mods": {func(l DownloadLinks) string { return l.ModsLink }, "application/xml", "mods.xml"},
+	"zip":  {func(l DownloadLinks) string { return l.ZipLink }, "application/zip", "zip"},
+}
+
+// DownloadFormat reports the content type and file extension for format,
+// and whether DownloadPackage supports it at all.
+func DownloadFormat(format string) (contentType, extension string, ok bool) {
+	f, ok := downloadFormats[format]
+	return f.contentType, f.extension, ok
+}
+
+// Has reports whether l carries a link for format.
+func (l DownloadLinks) Has(format string) bool {
+	f, ok := downloadFormats[format]
+	return ok && f.link(l) != ""
+}
+
+// DownloadPackage streams packageID in format (pdf, xml, mods or zip),
+// resolving the URL from the package summary's download links. The
+// caller must close the returned body.
+//
+// The body is streamed, so unlike the JSON methods the client's default
+// timeout is not applied; cancel ctx to abort a download.
+func (c *Client) DownloadPackage(ctx context.Context, packageID, format string) (io.ReadCloser, error) {
+	if _, ok := downloadFormats[format]; !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	summary, err := c.GetPackageSummary(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	return c.DownloadFromSummary(ctx, summary, format)
+}
+
+// DownloadFromSummary is DownloadPackage for a summary the caller already
+// has, saving a lookup when several formats of one package are wanted.
+//
+// Requests carry the API key, so only links on the client's base URL are
+// followed; any other link fails with ErrFormatUnavailable.
+func (c *Client) DownloadFromSummary(ctx context.Context, summary *PackageSummary, format string) (io.ReadCloser, error) {
+	f, ok := downloadFormats[format]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	link := f.link(summary.DownloadLinks)
+	if link == "" {
+		return nil, ErrFormatUnavailable
+	}
+	if !c.onBaseURL(link) {
+		return nil, fmt.Errorf("%w: %s link is not on %s", ErrFormatUnavailable, format, c.baseURL)
+	}
+
+	resp, err := c.send(ctx, apiRequest{
+		method: http.MethodGet,
+		url:    link,
+		header: http.Header{"Accept": {f.contentType}},
+		stream: true,
+	})
+	if err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, ErrFormatUnavailable
+		}
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// onBaseURL reports whether link has the same scheme and host as the
+// client's base URL.
+func (c *Client) onBaseURL(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
@@ -0,0 +1,68 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadFromSummaryStaysOnBaseURL(t *testing.T) {
+	var foreignHits atomic.Int32
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignHits.Add(1)
+	}))
+	defer foreign.Close()
+
+	var gotKey string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.URL.Query().Get("api_key")
+		w.Header().Set("Content-Type", "application/pdf")
+		io.WriteString(w, "%PDF")
+	})
+
+	summary := &PackageSummary{DownloadLinks: DownloadLinks{
+		PDFLink: c.baseURL + "/packages/BILLS-1/pdf",
+		XMLLink: foreign.URL + "/packages/BILLS-1/xml",
+	}}
+
+	body, err := c.DownloadFromSummary(context.Background(), summary, "pdf")
+	if err != nil {
+		t.Fatalf("pdf on the base URL: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if string(got) != "%PDF" {
+		t.Errorf("pdf body = %q", got)
+	}
+	if gotKey != "test-key" {
+		t.Errorf("API key sent to GovInfo = %q, want test-key", gotKey)
+	}
+
+	if _, err := c.DownloadFromSummary(context.Background(), summary, "xml"); !errors.Is(err, ErrFormatUnavailable) {
+		t.Errorf("foreign link error = %v, want ErrFormatUnavailable", err)
+	}
+	if n := foreignHits.Load(); n != 0 {
+		t.Errorf("foreign host got %d requests, want 0", n)
+	}
+}
+
+func TestOnBaseURL(t *testing.T) {
+	c := &Client{baseURL: "https://api.govinfo.gov"}
+	for link, want := range map[string]bool{
+		"https://api.govinfo.gov/packages/X/pdf":  true,
+		"https://API.govinfo.gov/packages/X/pdf":  true,
+		"http://api.govinfo.gov/packages/X/pdf":   false,
+		"https://evil.example/packages/X/pdf":     false,
+		"https://api.govinfo.gov.evil.example/x":  false,
+		"https://api.govinfo.gov:8443/packages/X": false,
+		"/packages/X/pdf":                         false,
+	} {
+		if got := c.onBaseURL(link); got != want {
+			t.Errorf("onBaseURL(%q) = %v, want %v", link, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ErrGranuleNotFound is returned when GovInfo has no such granule.
+var ErrGranuleNotFound = errors.New("govinfo: granule not found")
+
+// Granule is one entry in a package's granule listing. Large packages such
+// as the CFR are published as many granules.
+type Granule struct {
+	GranuleID    string `json:"granuleId"`
+	Title        string `json:"title"`
+	GranuleClass string `json:"granuleClass,omitempty"`
+	GranuleLink  string `json:"granuleLink,omitempty"`
+	MD5          string `json:"md5,omitempty"`
+}
+
+// GranuleResults is one page of a package's granules.
+type GranuleResults struct {
+	Count        int       `json:"count"`
+	Message      string    `json:"message,omitempty"`
+	NextPage     string    `json:"nextPage,omitempty"`
+	PreviousPage string    `json:"previousPage,omitempty"`
+	Granules     []Granule `json:"granules"`
+}
+
+// NextOffsetMark returns the cursor for the page after r, or "" when r is
+// the last page.
+func (r *GranuleResults) NextOffsetMark() string {
+	return offsetMarkFromURL(r.NextPage)
+}
+
+// GranuleSummary is the summary record GovInfo publishes for a granule.
+type GranuleSummary struct {
+	GranuleID      string `json:"granuleId"`
+	PackageID      string `json:"packageId"`
+	Title          string `json:"title"`
+	GranuleClass   string `json:"granuleClass,omitempty"`
+	CollectionCode string `json:"collectionCode"`
+	CollectionName string `json:"collectionName,omitempty"`
+	Category       string `json:"category,omitempty"`
+	DateIssued     string `json:"dateIssued"`
+	LastModified   string `json:"lastModified"`
+
+	DownloadLinks DownloadLinks `json:"download"`
+}
+
+// ListGranules returns one page of packageID's granules. Pass "" as
+// offsetMark for the first page.
+func (c *Client) ListGranules(ctx context.Context, packageID string, pageSize int, offsetMark string) (*GranuleResults, error) {
+	path := "/packages/" + url.PathEscape(packageID) + "/granules"
+
+	var res GranuleResults
+	if err := c.getJSON(ctx, path, listQuery(pageSize, offsetMark), &res); err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, ErrPackageNotFound
+		}
+		return nil, cursorError(offsetMark, err)
+	}
+	return &res, nil
+}
+
+// GetGranuleSummary fetches the summary for granuleID within packageID.
+func (c *Client) GetGranuleSummary(ctx context.Context, packageID, granuleID string) (*GranuleSummary, error) {
+	var summary GranuleSummary
+	path := "/packages/" + url.PathEscape(packageID) + "/granules/" + url.PathEscape(granuleID) + "/summary"
+	if err := c.getJSON(ctx, path, nil, &summary); err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, ErrGranuleNotFound
+		}
+		return nil, err
+	}
+	return &summary, nil
+}
@@ -0,0 +1,43 @@
+package govinfo
+
+import (
+	"errors"
+	"io"
+)
+
+// defaultMaxBodyBytes caps JSON responses read into memory.
+const defaultMaxBodyBytes = 10 << 20
+
+// ErrResponseTooLarge is returned when a JSON response, or metadata read
+// whole, exceeds the client's body limit. Downloads are streamed and are
+// not limited.
+var ErrResponseTooLarge = errors.New("govinfo: response body too large")
+
+// limitBody returns a reader over r that fails with ErrResponseTooLarge
+// once more than the client's limit has been read. A limit of zero or
+// less means no limit.
+func (c *Client) limitBody(r io.Reader) io.Reader {
+	if c.maxBodyBytes <= 0 {
+		return r
+	}
+	return &limitedBody{r: io.LimitReader(r, c.maxBodyBytes+1), remaining: c.maxBodyBytes}
+}
+
+// limitedBody reads one byte past the limit so it can tell a body of
+// exactly the limit from one that is longer.
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
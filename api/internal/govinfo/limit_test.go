@@ -0,0 +1,58 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	body := `{"collections":[]}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}, WithMaxResponseBytes(int64(len(body))))
+
+	if _, err := c.ListCollections(context.Background()); err != nil {
+		t.Fatalf("body of exactly the limit: %v", err)
+	}
+
+	body = `{"collections":[` + strings.Repeat(" ", 10) + `]}`
+	if _, err := c.ListCollections(context.Background()); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("body over the limit: err = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+func TestLimitedBodyReadsUpToLimit(t *testing.T) {
+	c := &Client{maxBodyBytes: 4}
+	got, err := io.ReadAll(c.limitBody(strings.NewReader("abcdef")))
+	if !errors.Is(err, ErrResponseTooLarge) || string(got) != "abcd" {
+		t.Errorf("read %q, %v; want abcd and ErrResponseTooLarge", got, err)
+	}
+
+	c.maxBodyBytes = 0
+	if got, err := io.ReadAll(c.limitBody(strings.NewReader("abcdef"))); err != nil || string(got) != "abcdef" {
+		t.Errorf("no limit: read %q, %v", got, err)
+	}
+}
+
+func TestDownloadsAreNotLimited(t *testing.T) {
+	pdf := strings.Repeat("x", 100)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		io.WriteString(w, pdf)
+	}, WithMaxResponseBytes(10))
+
+	summary := &PackageSummary{DownloadLinks: DownloadLinks{PDFLink: c.baseURL + "/packages/BILLS-1/pdf"}}
+	body, err := c.DownloadFromSummary(context.Background(), summary, "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if got, err := io.ReadAll(body); err != nil || len(got) != len(pdf) {
+		t.Errorf("download read %d bytes, %v; want %d", len(got), err, len(pdf))
+	}
+}
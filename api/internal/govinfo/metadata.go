@@ -0,0 +1,92 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GetPackageMODS streams the MODS XML metadata for packageID as GovInfo
+// publishes it. The caller must close the returned body.
+//
+// Like DownloadPackage, the body is streamed and the client's default
+// timeout is not applied; cancel ctx to abort.
+func (c *Client) GetPackageMODS(ctx context.Context, packageID string) (io.ReadCloser, error) {
+	return c.openMetadata(ctx, packageID, "mods")
+}
+
+// GetPackagePREMIS streams the PREMIS preservation metadata XML for
+// packageID, like GetPackageMODS. The caller must close the returned body.
+func (c *Client) GetPackagePREMIS(ctx context.Context, packageID string) (io.ReadCloser, error) {
+	return c.openMetadata(ctx, packageID, "premis")
+}
+
+// GetModsMetadata returns the MODS XML for packageID read whole. Unlike
+// GetPackageMODS, it is bounded by the client's timeout and response
+// size limit.
+func (c *Client) GetModsMetadata(ctx context.Context, packageID string) ([]byte, error) {
+	return c.readMetadata(ctx, packageID, "mods")
+}
+
+// GetPremisMetadata returns the PREMIS XML for packageID read whole, like
+// GetModsMetadata.
+func (c *Client) GetPremisMetadata(ctx context.Context, packageID string) ([]byte, error) {
+	return c.readMetadata(ctx, packageID, "premis")
+}
+
+func (c *Client) readMetadata(ctx context.Context, packageID, doc string) ([]byte, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	body, err := c.openMetadata(ctx, packageID, doc)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	b, err := io.ReadAll(c.limitBody(body))
+	if err != nil {
+		return nil, fmt.Errorf("govinfo: /packages/%s/%s: read response: %w", packageID, doc, err)
+	}
+	return b, nil
+}
+
+// openMetadata streams /packages/{packageID}/{doc}, failing with
+// ErrPackageNotFound on a 404 and a *DecodeError when GovInfo answers
+// with something other than XML. The client's default timeout is not
+// applied; cancel ctx to abort.
+func (c *Client) openMetadata(ctx context.Context, packageID, doc string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, apiRequest{
+		method: http.MethodGet,
+		url:    c.baseURL + "/packages/" + url.PathEscape(packageID) + "/" + doc,
+		header: http.Header{"Accept": {"application/xml"}},
+		stream: true,
+	})
+	if err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !isXMLContentType(ct) {
+		defer resp.Body.Close()
+		// The start is enough for the error's snippet.
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, decodeSnippetLen))
+		return nil, newDecodeError(resp.Request.URL.Path, ct, snippet, errors.New("not XML"))
+	}
+	return resp.Body, nil
+}
+
+// isXMLContentType accepts application/xml, text/xml and any +xml type.
+func isXMLContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
@@ -0,0 +1,87 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPackageMetadata(t *testing.T) {
+	const doc = `<?xml version="1.0"?><mods/>`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/BILLS-1/mods", "/packages/BILLS-1/premis":
+			w.Header().Set("Content-Type", "application/xml")
+			io.WriteString(w, doc)
+		case "/packages/HTML-1/mods", "/packages/HTML-1/premis":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html>maintenance</html>")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	for name, open := range map[string]func(context.Context, string) (io.ReadCloser, error){
+		"mods":   c.GetPackageMODS,
+		"premis": c.GetPackagePREMIS,
+	} {
+		t.Run(name, func(t *testing.T) {
+			body, err := open(context.Background(), "BILLS-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := io.ReadAll(body)
+			body.Close()
+			if string(got) != doc {
+				t.Errorf("body = %q, want %q", got, doc)
+			}
+
+			_, err = open(context.Background(), "HTML-1")
+			var de *DecodeError
+			if !errors.As(err, &de) || de.ContentType != "text/html" {
+				t.Errorf("non-XML response error = %v, want a *DecodeError", err)
+			}
+
+			if _, err := open(context.Background(), "MISSING-1"); !errors.Is(err, ErrPackageNotFound) {
+				t.Errorf("404 error = %v, want ErrPackageNotFound", err)
+			}
+		})
+	}
+}
+
+func TestReadPackageMetadata(t *testing.T) {
+	const doc = `<?xml version="1.0"?><premis/>`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/BILLS-1/mods", "/packages/BILLS-1/premis":
+			w.Header().Set("Content-Type", "application/xml")
+			io.WriteString(w, doc)
+		case "/packages/HUGE-1/mods", "/packages/HUGE-1/premis":
+			w.Header().Set("Content-Type", "application/xml")
+			io.WriteString(w, "<mods>"+strings.Repeat(" ", 64)+"</mods>")
+		default:
+			http.NotFound(w, r)
+		}
+	}, WithMaxResponseBytes(32))
+
+	for name, read := range map[string]func(context.Context, string) ([]byte, error){
+		"mods":   c.GetModsMetadata,
+		"premis": c.GetPremisMetadata,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := read(context.Background(), "BILLS-1")
+			if err != nil || string(got) != doc {
+				t.Errorf("read = %q, %v; want %q", got, err, doc)
+			}
+			if _, err := read(context.Background(), "HUGE-1"); !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("oversized record error = %v, want ErrResponseTooLarge", err)
+			}
+			if _, err := read(context.Background(), "MISSING-1"); !errors.Is(err, ErrPackageNotFound) {
+				t.Errorf("404 error = %v, want ErrPackageNotFound", err)
+			}
+		})
+	}
+}
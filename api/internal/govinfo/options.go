@@ -0,0 +1,152 @@
+package govinfo
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+
+	"github.com/tingeytime/govinfo/api/internal/cache"
+)
+
+// Option customises a Client built by NewClient.
+type Option func(*Client)
+
+// WithCollectionsCacheTTL caches ListCollections results for ttl. A zero
+// or negative ttl disables the cache.
+func WithCollectionsCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl > 0 {
+			c.collections = cache.NewTTLCache[string, []Collection](ttl)
+		}
+	}
+}
+
+// WithRateLimit paces outbound requests to rps per second. A zero or
+// negative rps leaves requests unpaced.
+func WithRateLimit(rps float64) Option {
+	return func(c *Client) {
+		if rps > 0 {
+			burst := int(rps)
+			if burst < 1 {
+				burst = 1
+			}
+			c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+		}
+	}
+}
+
+// WithMaxConcurrency caps the requests in flight to GovInfo at n, shared
+// by every caller of the client. A request waits for a free slot until its
+// context ends. JSON calls hold their slot until the body is read;
+// downloads free theirs once the response starts. Zero or less means no
+// cap.
+func WithMaxConcurrency(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.sem = semaphore.NewWeighted(int64(n))
+		}
+	}
+}
+
+// WithRetries sets how many times a read-only request is retried after a
+// network error or a 429, 502, 503 or 504 response.
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		if n >= 0 {
+			c.maxRetries = n
+		}
+	}
+}
+
+// WithRetryBackoff sets the longest wait before the first retry; each
+// further retry doubles it. The actual wait is a random fraction of that.
+// A longer Retry-After from GovInfo wins.
+func WithRetryBackoff(base time.Duration) Option {
+	return func(c *Client) {
+		if base > 0 {
+			c.retryBackoff = base
+		}
+	}
+}
+
+// WithRetryMaxBackoff caps the doubling of WithRetryBackoff.
+func WithRetryMaxBackoff(max time.Duration) Option {
+	return func(c *Client) {
+		if max > 0 {
+			c.retryMaxBackoff = max
+		}
+	}
+}
+
+// WithRetryBudget caps the total time one call spends waiting between
+// retries, Retry-After waits included. Zero disables retries.
+func WithRetryBudget(d time.Duration) Option {
+	return func(c *Client) {
+		if d >= 0 {
+			c.retryBudget = d
+		}
+	}
+}
+
+// WithTimeout sets the deadline applied to calls whose context has none.
+// Zero disables the default deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d >= 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// WithBaseURL points the client at another GovInfo deployment, such as a
+// staging host or a local mock. An empty base keeps the production API.
+func WithBaseURL(base string) Option {
+	return func(c *Client) {
+		if base != "" {
+			c.baseURL = strings.TrimRight(base, "/")
+		}
+	}
+}
+
+// WithUserAgent replaces the default User-Agent sent with every request.
+// An empty ua keeps the default.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		if ua != "" {
+			c.userAgent = ua
+		}
+	}
+}
+
+// WithConditionalRequests keeps GET responses that carry an ETag or
+// Last-Modified header for ttl and revalidates them with If-None-Match /
+// If-Modified-Since, reusing the cached body on 304 Not Modified. At most
+// 1024 responses are kept; collection update listings never are.
+func WithConditionalRequests(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl > 0 {
+			c.responses = cache.NewTTLCache[string, cachedResponse](ttl)
+		}
+	}
+}
+
+// WithMaxResponseBytes caps how much of a JSON response is read before
+// the call fails with ErrResponseTooLarge. Zero or less removes the cap.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxBodyBytes = n
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast with ErrUpstreamUnavailable
+// after threshold consecutive upstream failures, for cooldown, before
+// letting a single probe through. A threshold below 1 disables it.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		if threshold > 0 && cooldown > 0 {
+			c.breaker = newBreaker(threshold, cooldown)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ErrPackageNotFound is returned when GovInfo has no package with the
+// requested ID.
+var ErrPackageNotFound = errors.New("govinfo: package not found")
+
+// PackageSummary is the summary record GovInfo publishes for a package.
+type PackageSummary struct {
+	PackageID      string `json:"packageId"`
+	Title          string `json:"title"`
+	DateIssued     string `json:"dateIssued"`
+	CollectionCode string `json:"collectionCode"`
+	CollectionName string `json:"collectionName"`
+	Category       string `json:"category"`
+	LastModified   string `json:"lastModified"`
+
+	// GovInfo returns the download links under a "download" object.
+	DownloadLinks DownloadLinks `json:"download"`
+}
+
+// DownloadLinks holds the per-format download URLs for a package. A
+// format the package is not published in is left empty.
+type DownloadLinks struct {
+	PDFLink    string `json:"pdfLink,omitempty"`
+	XMLLink    string `json:"xmlLink,omitempty"`
+	TxtLink    string `json:"txtLink,omitempty"`
+	ZipLink    string `json:"zipLink,omitempty"`
+	ModsLink   string `json:"modsLink,omitempty"`
+	PremisLink string `json:"premisLink,omitempty"`
+}
+
+// GetPackageSummary fetches the summary for packageID, for example
+// "FR-2024-01-02".
+func (c *Client) GetPackageSummary(ctx context.Context, packageID string) (*PackageSummary, error) {
+	var summary PackageSummary
+	path := "/packages/" + url.PathEscape(packageID) + "/summary"
+	if err := c.getJSON(ctx, path, nil, &summary); err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+	return &summary, nil
+}
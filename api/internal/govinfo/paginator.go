@@ -0,0 +1,89 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrCursorExpired matches errors from a listing or search resumed at an
+// offsetMark GovInfo no longer accepts. GovInfo doesn't document how long a
+// mark stays valid, so cursors should be followed promptly rather than
+// stored; once one expires the only way on is to start again from the
+// first page.
+var ErrCursorExpired = errors.New("govinfo: offsetMark expired")
+
+// cursorError reports err as ErrCursorExpired when GovInfo refused an
+// explicit offsetMark outright. The first page ("*") can't have expired.
+func cursorError(offsetMark string, err error) error {
+	if offsetMark == "" || offsetMark == "*" || errors.Is(err, ErrCursorExpired) {
+		return err
+	}
+	var se *StatusError
+	if errors.As(err, &se) && (se.StatusCode == http.StatusBadRequest || se.StatusCode == http.StatusGone) {
+		return fmt.Errorf("%w: %w", ErrCursorExpired, err)
+	}
+	return err
+}
+
+// pageFunc fetches the page at offsetMark and returns its packages along
+// with the mark for the following page ("" when there is none).
+type pageFunc func(ctx context.Context, offsetMark string) ([]Package, string, error)
+
+// Paginator walks a cursor-paginated GovInfo listing one page at a time by
+// following offsetMark, which GovInfo requires beyond the first 10,000
+// results. A mark that expires mid-walk fails Next with ErrCursorExpired.
+type Paginator struct {
+	fetch      pageFunc
+	offsetMark string
+	done       bool
+}
+
+func newPaginator(fetch pageFunc) *Paginator {
+	return &Paginator{fetch: fetch, offsetMark: "*"}
+}
+
+// NewSearchPaginator returns a Paginator over every page of query. Any
+// OffsetMark already set on query is used as the starting cursor.
+func (c *Client) NewSearchPaginator(query SearchQuery) *Paginator {
+	p := newPaginator(func(ctx context.Context, offsetMark string) ([]Package, string, error) {
+		q := query
+		q.OffsetMark = offsetMark
+		res, err := c.Search(ctx, q)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Results, res.OffsetMark, nil
+	})
+	if query.OffsetMark != "" {
+		p.offsetMark = query.OffsetMark
+	}
+	return p
+}
+
+// Next returns the next page of packages. The bool is false once the
+// listing is exhausted, in which case the slice is empty.
+func (p *Paginator) Next(ctx context.Context) ([]Package, bool, error) {
+	if p.done {
+		return nil, false, nil
+	}
+
+	pkgs, next, err := p.fetch(ctx, p.offsetMark)
+	if err != nil {
+		return nil, false, cursorError(p.offsetMark, err)
+	}
+
+	// GovInfo signals the last page by omitting the mark; an unchanged
+	// mark would loop forever, so treat it the same way.
+	if next == "" || next == p.offsetMark {
+		p.done = true
+	}
+	p.offsetMark = next
+
+	if len(pkgs) == 0 {
+		p.done = true
+		return nil, false, nil
+	}
+	return pkgs, true, nil
+}
@@ -0,0 +1,72 @@
+package govinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// cursorSearch serves two search pages, answering status for the second
+// page's mark as GovInfo does once a mark has expired.
+func cursorSearch(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req searchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.OffsetMark != "*" {
+			http.Error(w, `{"message":"invalid offsetMark"}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SearchResults{Count: 2, OffsetMark: "page-2", Results: []Package{{PackageID: "BILLS-1"}}})
+	}
+}
+
+func TestSearchPaginatorCursorExpired(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusGone} {
+		c := newTestClient(t, cursorSearch(status))
+		pages := c.NewSearchPaginator(SearchQuery{Query: "climate"})
+
+		pkgs, more, err := pages.Next(context.Background())
+		if err != nil || !more || len(pkgs) != 1 {
+			t.Fatalf("%d: first page = %v, %v, %v", status, pkgs, more, err)
+		}
+		_, _, err = pages.Next(context.Background())
+		if !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("%d: second page error = %v, want ErrCursorExpired", status, err)
+		}
+		var se *StatusError
+		if !errors.As(err, &se) || se.StatusCode != status {
+			t.Errorf("%d: second page error = %v, want the upstream StatusError kept", status, err)
+		}
+	}
+}
+
+func TestSearchStaleOffsetMark(t *testing.T) {
+	c := newTestClient(t, cursorSearch(http.StatusBadRequest))
+	_, err := c.Search(context.Background(), SearchQuery{Query: "climate", OffsetMark: "stale"})
+	if !errors.Is(err, ErrCursorExpired) {
+		t.Errorf("Search with a stale mark = %v, want ErrCursorExpired", err)
+	}
+}
+
+func TestCursorError(t *testing.T) {
+	bad := &StatusError{StatusCode: http.StatusBadRequest}
+	for _, tc := range []struct {
+		name, mark string
+		err        error
+		want       bool
+	}{
+		{"400 on a mark", "page-2", bad, true},
+		{"410 on a mark", "page-2", &StatusError{StatusCode: http.StatusGone}, true},
+		{"400 on the first page", "*", bad, false},
+		{"400 without a mark", "", bad, false},
+		{"500 on a mark", "page-2", &StatusError{StatusCode: http.StatusInternalServerError}, false},
+		{"network error", "page-2", errors.New("connection reset"), false},
+	} {
+		if got := errors.Is(cursorError(tc.mark, tc.err), ErrCursorExpired); got != tc.want {
+			t.Errorf("%s: expired = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package govinfo
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// PublishedResults is one page of packages issued in a date range. It has
+// the same shape as a collection update listing.
+type PublishedResults = PackageList
+
+// ListPublished returns one page of packages in collections whose
+// dateIssued falls between start and end, inclusive. Only the date part
+// of start and end is used. Pass "" as offsetMark for the first page.
+func (c *Client) ListPublished(ctx context.Context, start, end time.Time, collections []string, pageSize int, offsetMark string) (*PublishedResults, error) {
+	path := "/published/" + start.Format(time.DateOnly) + "/" + end.Format(time.DateOnly)
+
+	query := listQuery(pageSize, offsetMark)
+	if len(collections) > 0 {
+		query.Set("collection", strings.Join(collections, ","))
+	}
+
+	var res PublishedResults
+	if err := c.getJSON(ctx, path, query, &res); err != nil {
+		return nil, cursorError(offsetMark, err)
+	}
+	return &res, nil
+}
+
+// NewPublishedPaginator walks every page of ListPublished.
+func (c *Client) NewPublishedPaginator(start, end time.Time, collections []string, pageSize int) *Paginator {
+	return newPaginator(func(ctx context.Context, offsetMark string) ([]Package, string, error) {
+		res, err := c.ListPublished(ctx, start, end, collections, pageSize, offsetMark)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Packages, res.NextOffsetMark(), nil
+	})
+}
@@ -0,0 +1,81 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// RelatedPackage is a package GovInfo links to another, such as a bill's
+// enrolled version or the public law it became.
+type RelatedPackage struct {
+	// Relationship is GovInfo's name for the link, e.g. "Public Law".
+	Relationship   string `json:"relationship"`
+	CollectionCode string `json:"collectionCode"`
+	PackageID      string `json:"packageId"`
+	GranuleID      string `json:"granuleId,omitempty"`
+	Title          string `json:"title,omitempty"`
+	DateIssued     string `json:"dateIssued,omitempty"`
+	PackageLink    string `json:"packageLink,omitempty"`
+}
+
+type relationship struct {
+	Relationship string `json:"relationship"`
+	Collection   string `json:"collection"`
+}
+
+type relatedResults struct {
+	Results []struct {
+		PackageID   string `json:"packageId"`
+		GranuleID   string `json:"granuleId"`
+		Title       string `json:"title"`
+		DateIssued  string `json:"dateIssued"`
+		PackageLink string `json:"packageLink"`
+	} `json:"results"`
+}
+
+// GetRelatedPackages lists the packages related to packageID, one request
+// per related collection. A package GovInfo has no relationships for,
+// including one it doesn't know, has an empty result rather than an error.
+func (c *Client) GetRelatedPackages(ctx context.Context, packageID string) ([]RelatedPackage, error) {
+	var index struct {
+		Relationships []relationship `json:"relationships"`
+	}
+	path := "/related/" + url.PathEscape(packageID)
+	if err := c.getJSON(ctx, path, nil, &index); err != nil {
+		if isNotFound(err) {
+			return []RelatedPackage{}, nil
+		}
+		return nil, err
+	}
+
+	related := []RelatedPackage{}
+	for _, rel := range index.Relationships {
+		var res relatedResults
+		if err := c.getJSON(ctx, path+"/"+url.PathEscape(rel.Collection), nil, &res); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("govinfo: related %s in %s: %w", packageID, rel.Collection, err)
+		}
+		for _, r := range res.Results {
+			related = append(related, RelatedPackage{
+				Relationship:   rel.Relationship,
+				CollectionCode: rel.Collection,
+				PackageID:      r.PackageID,
+				GranuleID:      r.GranuleID,
+				Title:          r.Title,
+				DateIssued:     r.DateIssued,
+				PackageLink:    r.PackageLink,
+			})
+		}
+	}
+	return related, nil
+}
+
+func isNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,50 @@
+package govinfo
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Retry policy: GETs and other read-only requests are retried on network
+// errors and on the statuses below, up to maxRetries times. Each wait is
+// drawn uniformly from zero to retryBackoff doubled per attempt, capped
+// at retryMaxBackoff ("full jitter"), so clients that failed together
+// don't retry together. A longer Retry-After from GovInfo wins. The waits
+// of one call together may not exceed retryBudget.
+
+// retryableStatus reports whether a response with code may succeed if the
+// same request is sent again.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether r is safe to send more than once.
+func (r apiRequest) retryable() bool {
+	return r.method == http.MethodGet || r.method == http.MethodHead || r.idempotent
+}
+
+// retryDelay returns how long to wait before retrying after attempt, the
+// zero-based attempt that just failed, and takes it from budget. ok is
+// false when the wait would overrun the budget.
+func (c *Client) retryDelay(attempt int, retryAfter time.Duration, budget *time.Duration) (delay time.Duration, ok bool) {
+	ceiling := c.retryMaxBackoff
+	if attempt < 32 {
+		if d := c.retryBackoff << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	delay = rand.N(ceiling + 1)
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	if *budget <= 0 || delay > *budget {
+		return 0, false
+	}
+	*budget -= delay
+	return delay, true
+}
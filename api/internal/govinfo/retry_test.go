@@ -0,0 +1,215 @@
+package govinfo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetries429ThenSucceeds(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, collectionsJSON)
+	}, WithRetries(2), WithRetryBackoff(time.Millisecond))
+
+	got, err := c.ListCollections(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("collections = %+v", got)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upstream called %d times, want 2", n)
+	}
+}
+
+func TestRetriesGiveUpAfterMax(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, WithRetries(2), WithRetryBackoff(time.Millisecond))
+
+	_, err := c.ListCollections(context.Background())
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 StatusError", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("upstream called %d times, want 3", n)
+	}
+}
+
+func TestRetryAfterBeyondBudgetFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, WithRetries(3), WithRetryBudget(time.Second))
+
+	start := time.Now()
+	if _, err := c.ListCollections(context.Background()); err == nil {
+		t.Fatal("want an error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("gave up after %s, want at once", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("upstream called %d times, want 1", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := &Client{retryBackoff: 100 * time.Millisecond, retryMaxBackoff: time.Second}
+	budget := time.Hour
+	for attempt := range 6 {
+		ceiling := min(c.retryBackoff<<attempt, c.retryMaxBackoff)
+		d, ok := c.retryDelay(attempt, 0, &budget)
+		if !ok || d < 0 || d > ceiling {
+			t.Errorf("attempt %d: delay %s, %v; want at most %s", attempt, d, ok, ceiling)
+		}
+	}
+
+	if d, _ := c.retryDelay(0, 5*time.Second, &budget); d != 5*time.Second {
+		t.Errorf("delay with Retry-After 5s = %s", d)
+	}
+
+	budget = time.Second
+	if _, ok := c.retryDelay(0, 2*time.Second, &budget); ok {
+		t.Error("a wait beyond the budget was allowed")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for v, want := range map[string]time.Duration{
+		"":      0,
+		"2":     2 * time.Second,
+		"0":     0,
+		"-1":    0,
+		"later": 0,
+	} {
+		if got := retryAfter(v); got != want {
+			t.Errorf("retryAfter(%q) = %s, want %s", v, got, want)
+		}
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(date); got <= 58*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %s, want about a minute", date, got)
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	for code := 400; code < 600; code++ {
+		want := code == 429 || code == 502 || code == 503 || code == 504
+		if got := retryableStatus(code); got != want {
+			t.Errorf("retryableStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	for _, code := range []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError} {
+		var calls atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(code)
+		}, WithRetries(3), WithRetryBackoff(time.Millisecond))
+
+		if _, err := c.GetPackageSummary(context.Background(), "BILLS-1"); err == nil {
+			t.Errorf("%d: want an error", code)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("%d: upstream called %d times, want 1", code, n)
+		}
+	}
+}
+
+func TestNonIdempotentRequestsAreNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetries(3), WithRetryBackoff(time.Millisecond))
+
+	_, err := c.send(context.Background(), apiRequest{method: http.MethodPost, url: c.baseURL + "/submit"})
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("POST sent %d times, want 1", n)
+	}
+}
+
+func TestNetworkErrorsAreRetried(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response.
+			conn, _, err := http.NewResponseController(w).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"packageId":"BILLS-1"}`)
+	}, WithRetries(2), WithRetryBackoff(time.Millisecond))
+
+	if _, err := c.GetPackageSummary(context.Background(), "BILLS-1"); err != nil {
+		t.Fatalf("GetPackageSummary = %v, want the retry to succeed", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upstream called %d times, want 2", n)
+	}
+}
+
+func TestRetryDelayIsJittered(t *testing.T) {
+	c := &Client{retryBackoff: time.Second, retryMaxBackoff: time.Minute}
+	budget := 1000 * time.Hour
+	seen := map[time.Duration]bool{}
+	var low, high bool
+	for range 200 {
+		d, ok := c.retryDelay(3, 0, &budget)
+		if !ok || d < 0 || d > 8*time.Second {
+			t.Fatalf("delay %s, %v; want within [0, 8s]", d, ok)
+		}
+		seen[d] = true
+		low = low || d < 4*time.Second
+		high = high || d >= 4*time.Second
+	}
+	if len(seen) < 100 || !low || !high {
+		t.Errorf("%d distinct delays, low half %v, high half %v; want them spread over the range", len(seen), low, high)
+	}
+}
+
+func TestRetryBudgetSpansAttempts(t *testing.T) {
+	c := &Client{retryBackoff: time.Second, retryMaxBackoff: time.Second}
+	budget := 3 * time.Second
+	var spent time.Duration
+	for attempt := 0; ; attempt++ {
+		// Retry-After pins each wait so the budget runs out predictably.
+		d, ok := c.retryDelay(attempt, time.Second, &budget)
+		if !ok {
+			if attempt != 3 {
+				t.Errorf("budget ran out after %d retries, want 3", attempt)
+			}
+			break
+		}
+		spent += d
+	}
+	if spent != 3*time.Second || budget != 0 {
+		t.Errorf("spent %s with %s left, want the whole 3s budget used", spent, budget)
+	}
+}
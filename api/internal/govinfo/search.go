@@ -0,0 +1,106 @@
+package govinfo
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultSearchPageSize is used when SearchQuery.PageSize is zero.
+const DefaultSearchPageSize = 100
+
+// SearchQuery describes a full-text search across GovInfo publications.
+type SearchQuery struct {
+	// Query is the GovInfo search expression, e.g. "climate change".
+	Query string
+	// Collections restricts results to these collection codes.
+	Collections []string
+	// From and To bound the publish date. Zero values leave that end open.
+	From time.Time
+	To   time.Time
+	// PageSize is the number of results per page.
+	PageSize int
+	// OffsetMark is the cursor returned by the previous page; empty
+	// starts from the first page.
+	OffsetMark string
+}
+
+// Package is a single search result or package listing entry. Listings
+// fill in fewer fields than search results.
+type Package struct {
+	PackageID        string        `json:"packageId"`
+	GranuleID        string        `json:"granuleId,omitempty"`
+	Title            string        `json:"title"`
+	CollectionCode   string        `json:"collectionCode"`
+	DateIssued       string        `json:"dateIssued"`
+	LastModified     string        `json:"lastModified"`
+	GovernmentAuthor []string      `json:"governmentAuthor,omitempty"`
+	ResultLink       string        `json:"resultLink,omitempty"`
+	RelatedLink      string        `json:"relatedLink,omitempty"`
+	PackageLink      string        `json:"packageLink,omitempty"`
+	DocClass         string        `json:"docClass,omitempty"`
+	Congress         string        `json:"congress,omitempty"`
+	DownloadLinks    DownloadLinks `json:"download"`
+}
+
+// SearchResults is one page of search results.
+type SearchResults struct {
+	Count      int       `json:"count"`
+	OffsetMark string    `json:"offsetMark"`
+	Results    []Package `json:"results"`
+}
+
+type searchRequest struct {
+	Query      string `json:"query"`
+	PageSize   int    `json:"pageSize"`
+	OffsetMark string `json:"offsetMark"`
+}
+
+// Search runs query against the GovInfo /search endpoint and returns one
+// page of results. A stale query.OffsetMark fails with ErrCursorExpired.
+func (c *Client) Search(ctx context.Context, query SearchQuery) (*SearchResults, error) {
+	req := searchRequest{
+		Query:      query.expression(),
+		PageSize:   query.PageSize,
+		OffsetMark: query.OffsetMark,
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = DefaultSearchPageSize
+	}
+	if req.OffsetMark == "" {
+		req.OffsetMark = "*"
+	}
+
+	var results SearchResults
+	if err := c.postQuery(ctx, "/search", req, &results); err != nil {
+		return nil, cursorError(req.OffsetMark, err)
+	}
+	return &results, nil
+}
+
+// expression folds the collection and date filters into GovInfo's query
+// syntax, e.g. `flood collection:(FR OR CFR) publishdate:range(2024-01-01,)`.
+func (q SearchQuery) expression() string {
+	var b strings.Builder
+	b.WriteString(q.Query)
+
+	if len(q.Collections) > 0 {
+		b.WriteString(" collection:(")
+		b.WriteString(strings.Join(q.Collections, " OR "))
+		b.WriteString(")")
+	}
+
+	if !q.From.IsZero() || !q.To.IsZero() {
+		b.WriteString(" publishdate:range(")
+		if !q.From.IsZero() {
+			b.WriteString(q.From.Format(time.DateOnly))
+		}
+		b.WriteString(",")
+		if !q.To.IsZero() {
+			b.WriteString(q.To.Format(time.DateOnly))
+		}
+		b.WriteString(")")
+	}
+
+	return b.String()
+}
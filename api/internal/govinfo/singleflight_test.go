@@ -0,0 +1,96 @@
+package govinfo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentGetsShareOneUpstreamCall(t *testing.T) {
+	const callers = 20
+	var hits atomic.Int32
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"packageId":"BILLS-1","title":"A bill"}`))
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary, err := c.GetPackageSummary(context.Background(), "BILLS-1")
+			if err == nil && summary.Title != "A bill" {
+				t.Errorf("summary = %+v", summary)
+			}
+			errs <- err
+		}()
+	}
+
+	// Hold the one upstream call open until every caller has had time to
+	// join it.
+	deadline := time.Now().Add(2 * time.Second)
+	for hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetPackageSummary = %v", err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("upstream hit %d times for %d concurrent callers, want 1", n, callers)
+	}
+}
+
+func TestConcurrentGetsForDifferentURLsAreNotShared(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"packageId":"X"}`))
+	})
+
+	for _, id := range []string{"BILLS-1", "BILLS-2"} {
+		if _, err := c.GetPackageSummary(context.Background(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("upstream hit %d times for two packages, want 2", n)
+	}
+}
+
+func TestSharedGetErrorIsNotRemembered(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"packageId":"BILLS-1"}`))
+	})
+
+	if _, err := c.GetPackageSummary(context.Background(), "BILLS-1"); err == nil {
+		t.Fatal("first call succeeded against a failing upstream")
+	}
+	if _, err := c.GetPackageSummary(context.Background(), "BILLS-1"); err != nil {
+		t.Fatalf("call after the failure = %v, want a fresh upstream call", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("upstream hit %d times, want 2", n)
+	}
+}
@@ -0,0 +1,43 @@
+package govinfo
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/tingeytime/govinfo/api/internal/govinfo")
+
+// startSpan opens the client span for one call, covering its retries. The
+// URL is added later by sendWithRetry, once the key has been redacted;
+// otelhttp's transport would record it with the key in the query.
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "GovInfo "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", method)))
+}
+
+func endSpan(span trace.Span, resp *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	span.End()
+}
+
+// setSpanURL records the redacted request URL on the span in ctx.
+func setSpanURL(ctx context.Context, redacted string) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("url.full", redacted))
+}
+
+// injectTrace adds the trace context headers for ctx to h.
+func injectTrace(ctx context.Context, h http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
@@ -0,0 +1,115 @@
+package govinfo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.InMemoryExporter
+)
+
+// recordSpans installs an in-memory tracer provider for the package's
+// tracer and returns its recorder, emptied. The global tracer only
+// delegates to the first provider set, so every test shares one.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	recorder.Reset()
+	return recorder
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestClientSpanPerCall(t *testing.T) {
+	rec := recordSpans(t)
+
+	var traceparent string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"packageId":"BILLS-1","title":"A bill"}`))
+	})
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	if _, err := c.GetPackageSummary(ctx, "BILLS-1"); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	ended := rec.GetSpans()
+	if len(ended) != 2 {
+		t.Fatalf("recorded %d spans, want the client span and its parent", len(ended))
+	}
+	span := ended[0]
+	if span.Name != "GovInfo GET" {
+		t.Errorf("span name = %q, want GovInfo GET", span.Name)
+	}
+	if span.SpanKind != trace.SpanKindClient {
+		t.Errorf("span kind = %v, want client", span.SpanKind)
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("client span isn't a child of the caller's span")
+	}
+	if v, _ := spanAttr(span, "http.response.status_code"); v.AsInt64() != http.StatusOK {
+		t.Errorf("http.response.status_code = %v, want 200", v.Emit())
+	}
+	u, ok := spanAttr(span, "url.full")
+	if !ok || !strings.Contains(u.AsString(), "/packages/BILLS-1/summary") {
+		t.Errorf("url.full = %q, want the request URL", u.AsString())
+	}
+	if strings.Contains(u.AsString(), "test-key") {
+		t.Errorf("url.full = %q carries the API key", u.AsString())
+	}
+
+	// The upstream request carries the client span's context.
+	want := span.SpanContext
+	if !strings.Contains(traceparent, want.TraceID().String()) || !strings.Contains(traceparent, want.SpanID().String()) {
+		t.Errorf("traceparent = %q, want trace %s span %s", traceparent, want.TraceID(), want.SpanID())
+	}
+}
+
+func TestClientSpanRecordsError(t *testing.T) {
+	rec := recordSpans(t)
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	if _, err := c.GetPackageSummary(context.Background(), "BILLS-1"); err == nil {
+		t.Fatal("GetPackageSummary succeeded against a failing upstream")
+	}
+
+	ended := rec.GetSpans()
+	if len(ended) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(ended))
+	}
+	if got := ended[0].Status.Code; got != codes.Error {
+		t.Errorf("span status = %v, want error", got)
+	}
+	if len(ended[0].Events) == 0 {
+		t.Error("span has no recorded error event")
+	}
+}
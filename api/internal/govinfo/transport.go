@@ -0,0 +1,60 @@
+package govinfo
+
+import (
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the HTTP client used for GovInfo. Go's default
+// transport keeps only two idle connections per host, which forces new
+// TLS handshakes when we make many small calls to the same API host.
+type TransportConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// Timeout caps a whole request including reading the body. It is a
+	// backstop above the per-call timeout, sized for large downloads.
+	Timeout time.Duration
+}
+
+// DefaultTransportConfig returns the settings DefaultHTTPClient uses.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+		Timeout:             5 * time.Minute,
+	}
+}
+
+// DefaultHTTPClient returns an *http.Client tuned for GovInfo. NewClient
+// uses it when given a nil client.
+func DefaultHTTPClient() *http.Client {
+	return NewHTTPClient(DefaultTransportConfig())
+}
+
+// NewHTTPClient builds an *http.Client from tc, starting from a clone of
+// http.DefaultTransport so proxy and dial settings are kept. Zero fields
+// fall back to DefaultTransportConfig.
+func NewHTTPClient(tc TransportConfig) *http.Client {
+	def := DefaultTransportConfig()
+	if tc.MaxIdleConns <= 0 {
+		tc.MaxIdleConns = def.MaxIdleConns
+	}
+	if tc.MaxIdleConnsPerHost <= 0 {
+		tc.MaxIdleConnsPerHost = def.MaxIdleConnsPerHost
+	}
+	if tc.IdleConnTimeout <= 0 {
+		tc.IdleConnTimeout = def.IdleConnTimeout
+	}
+	if tc.Timeout <= 0 {
+		tc.Timeout = def.Timeout
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = tc.MaxIdleConns
+	t.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+	t.IdleConnTimeout = tc.IdleConnTimeout
+
+	return &http.Client{Transport: t, Timeout: tc.Timeout}
+}
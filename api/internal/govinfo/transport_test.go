@@ -0,0 +1,80 @@
+package govinfo
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientDefaults(t *testing.T) {
+	c := NewHTTPClient(TransportConfig{MaxIdleConnsPerHost: 5})
+	tr := c.Transport.(*http.Transport)
+	def := DefaultTransportConfig()
+	if tr.MaxIdleConnsPerHost != 5 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 5", tr.MaxIdleConnsPerHost)
+	}
+	if tr.MaxIdleConns != def.MaxIdleConns || tr.IdleConnTimeout != def.IdleConnTimeout || c.Timeout != def.Timeout {
+		t.Errorf("zero fields not defaulted: %d, %s, %s", tr.MaxIdleConns, tr.IdleConnTimeout, c.Timeout)
+	}
+	if tr == http.DefaultTransport {
+		t.Error("http.DefaultTransport modified in place")
+	}
+}
+
+// BenchmarkTransportReuse sends bursts of concurrent small GETs to one
+// host, as the poller and fan-out endpoints do, and reports connections
+// dialed per burst. Between bursts Go's default transport keeps only two
+// idle connections per host, so each burst dials most of its calls
+// again; the tuned transport keeps the whole burst's pool.
+func BenchmarkTransportReuse(b *testing.B) {
+	const burst = 16
+	for name, newClient := range map[string]func() *http.Client{
+		"default": func() *http.Client {
+			return &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone(), Timeout: time.Minute}
+		},
+		"tuned": DefaultHTTPClient,
+	} {
+		b.Run(name, func(b *testing.B) {
+			var dials atomic.Int64
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Microsecond)
+				io.WriteString(w, "{}")
+			}))
+			srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+				if s == http.StateNew {
+					dials.Add(1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			client := newClient()
+			defer client.CloseIdleConnections()
+
+			b.ResetTimer()
+			for range b.N {
+				var wg sync.WaitGroup
+				for range burst {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						resp, err := client.Get(srv.URL)
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						io.Copy(io.Discard, resp.Body)
+						resp.Body.Close()
+					}()
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(dials.Load())/float64(b.N), "dials/op")
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package govinfo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultListPageSize is used for package listings when no page size is given.
+const DefaultListPageSize = 100
+
+// PackageList is one page of a package listing such as a collection's
+// recent updates.
+type PackageList struct {
+	Count        int       `json:"count"`
+	Message      string    `json:"message,omitempty"`
+	NextPage     string    `json:"nextPage,omitempty"`
+	PreviousPage string    `json:"previousPage,omitempty"`
+	Packages     []Package `json:"packages"`
+}
+
+// NextOffsetMark returns the cursor for the page after l, or "" when l is
+// the last page.
+func (l *PackageList) NextOffsetMark() string {
+	return offsetMarkFromURL(l.NextPage)
+}
+
+// ListCollectionUpdates returns one page of packages in collection code
+// that were added or modified since the given time.
+func (c *Client) ListCollectionUpdates(ctx context.Context, code string, since time.Time, pageSize int, offsetMark string) (*PackageList, error) {
+	path := "/collections/" + url.PathEscape(code) + "/" + since.UTC().Format(time.RFC3339)
+
+	// since changes with every poll, so a cached page would never be
+	// revalidated.
+	var list PackageList
+	if err := c.doJSON(ctx, apiRequest{
+		method:      http.MethodGet,
+		url:         c.baseURL + path,
+		query:       listQuery(pageSize, offsetMark),
+		conditional: true,
+		noStore:     true,
+	}, &list); err != nil {
+		return nil, err
+	}
+	// The listing omits the collection on each entry.
+	for i := range list.Packages {
+		if list.Packages[i].CollectionCode == "" {
+			list.Packages[i].CollectionCode = code
+		}
+	}
+	return &list, nil
+}
+
+// NewCollectionPaginator walks every page of ListCollectionUpdates.
+func (c *Client) NewCollectionPaginator(code string, since time.Time, pageSize int) *Paginator {
+	return newPaginator(func(ctx context.Context, offsetMark string) ([]Package, string, error) {
+		list, err := c.ListCollectionUpdates(ctx, code, since, pageSize, offsetMark)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Packages, list.NextOffsetMark(), nil
+	})
+}
+
+func listQuery(pageSize int, offsetMark string) url.Values {
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	if offsetMark == "" {
+		offsetMark = "*"
+	}
+	return url.Values{
+		"pageSize":   {strconv.Itoa(pageSize)},
+		"offsetMark": {offsetMark},
+	}
+}
+
+// offsetMarkFromURL extracts the cursor from a listing's nextPage link,
+// returning "" when there is no next page.
+func offsetMarkFromURL(next string) string {
+	if next == "" {
+		return ""
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("offsetMark")
+}
@@ -0,0 +1,69 @@
+package govinfo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOutboundHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		opts  []Option
+		check func(ua string) bool
+	}{
+		{"default", nil, func(ua string) bool {
+			return strings.HasPrefix(ua, "govinfo-api/") && strings.Contains(ua, userAgentURL)
+		}},
+		{"override", []Option{WithUserAgent("ops-bot/1.0")}, func(ua string) bool { return ua == "ops-bot/1.0" }},
+		{"empty keeps default", []Option{WithUserAgent("")}, func(ua string) bool {
+			return ua == defaultUserAgent()
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			headers := map[string]http.Header{}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				headers[r.URL.Path] = r.Header.Clone()
+				mu.Unlock()
+				switch r.URL.Path {
+				case "/collections":
+					w.Header().Set("Content-Type", "application/json")
+					io.WriteString(w, collectionsJSON)
+				default:
+					w.Header().Set("Content-Type", "application/xml")
+					io.WriteString(w, "<mods/>")
+				}
+			}, tc.opts...)
+
+			if _, err := c.ListCollections(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			body, err := c.GetPackageMODS(context.Background(), "BILLS-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			body.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for path, accept := range map[string]string{
+				"/collections":           "application/json",
+				"/packages/BILLS-1/mods": "application/xml",
+			} {
+				h := headers[path]
+				if ua := h.Get("User-Agent"); !tc.check(ua) {
+					t.Errorf("%s User-Agent = %q", path, ua)
+				}
+				if got := h.Get("Accept"); got != accept {
+					t.Errorf("%s Accept = %q, want %q", path, got, accept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package health aggregates the checks behind the readiness probe.
+// Components register a named Checker at startup; Run calls them all
+// concurrently, each under its own timeout.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a check registered without WithTimeout.
+const DefaultTimeout = 2 * time.Second
+
+// Overall and per-check statuses.
+const (
+	StatusOK          = "ok"
+	StatusError       = "error"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Checker reports whether a dependency is usable. It should give up once
+// ctx is done.
+type Checker func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       Checker
+	timeout  time.Duration
+	critical bool
+}
+
+// Option customises a check at registration.
+type Option func(*check)
+
+// WithTimeout replaces the registry's default timeout for one check.
+func WithTimeout(d time.Duration) Option {
+	return func(c *check) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// NonCritical reports the check's failures without failing readiness.
+// Use it for dependencies the service can run without for a while.
+func NonCritical() Option {
+	return func(c *check) { c.critical = false }
+}
+
+// Registry holds the registered checks. It is safe for concurrent use.
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+// NewRegistry returns an empty registry whose checks time out after
+// timeout unless registered with their own. Zero or less means
+// DefaultTimeout.
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{timeout: timeout}
+}
+
+// Register adds fn under name. Checks are critical unless registered with
+// NonCritical. Registering a name again replaces its check.
+func (r *Registry) Register(name string, fn Checker, opts ...Option) {
+	c := check{name: name, fn: fn, timeout: r.timeout, critical: true}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+// Report is the outcome of a Run. Status is StatusOK when every check
+// passed, StatusDegraded when only non-critical ones failed, and
+// StatusUnavailable otherwise.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Healthy reports whether every critical check passed.
+func (rep Report) Healthy() bool {
+	return rep.Status != StatusUnavailable
+}
+
+// Run calls every check concurrently and waits for them all. A check that
+// overruns its timeout fails even if its Checker ignores ctx.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			res := Result{Status: StatusOK, Critical: c.critical}
+			if err := c.run(ctx); err != nil {
+				res.Status, res.Error = StatusError, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			rep.Checks[c.name] = res
+			switch {
+			case res.Status == StatusOK:
+			case c.critical:
+				rep.Status = StatusUnavailable
+			case rep.Status == StatusOK:
+				rep.Status = StatusDegraded
+			}
+		}(c)
+	}
+	wg.Wait()
+	return rep
+}
+
+// run calls the checker under its timeout, turning a panic into an error.
+func (c check) run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("check panicked: %v", v)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", c.timeout)
+	}
+}
+
+// Cached wraps fn so its result is reused for ttl. It suits checks that
+// call a rate-limited or billed API, which probes would otherwise hit
+// every few seconds.
+func Cached(fn Checker, ttl time.Duration) Checker {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		last    error
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			return last
+		}
+		last = fn(ctx)
+		// A check cut short says nothing about the dependency.
+		if ctx.Err() == nil {
+			checked = time.Now()
+		}
+		return last
+	}
+}
@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func pass(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("connection refused") }
+
+// hang blocks until its check times out.
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunMixedChecks(t *testing.T) {
+	r := NewRegistry(50 * time.Millisecond)
+	r.Register("db", pass)
+	r.Register("govinfo", fail, NonCritical())
+	r.Register("twilio", hang, NonCritical())
+	r.Register("poller", pass)
+
+	start := time.Now()
+	rep := r.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run took %v, want the hanging check cut off", elapsed)
+	}
+
+	if rep.Status != StatusDegraded || !rep.Healthy() {
+		t.Errorf("status = %s, healthy %v; want degraded but healthy", rep.Status, rep.Healthy())
+	}
+	if len(rep.Checks) != 4 {
+		t.Fatalf("checks = %v, want all 4", rep.Checks)
+	}
+	for name, want := range map[string]Result{
+		"db":      {Status: StatusOK, Critical: true},
+		"poller":  {Status: StatusOK, Critical: true},
+		"govinfo": {Status: StatusError, Error: "connection refused"},
+	} {
+		if got := rep.Checks[name]; got != want {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+	if got := rep.Checks["twilio"]; got.Status != StatusError || !strings.Contains(got.Error, "timed out") {
+		t.Errorf("twilio = %+v, want a timeout", got)
+	}
+}
+
+func TestRunCriticalFailure(t *testing.T) {
+	for name, fn := range map[string]Checker{"failing": fail, "timing out": hang} {
+		r := NewRegistry(20 * time.Millisecond)
+		r.Register("db", fn)
+		r.Register("govinfo", pass, NonCritical())
+
+		rep := r.Run(context.Background())
+		if rep.Status != StatusUnavailable || rep.Healthy() {
+			t.Errorf("%s critical check: status = %s, want unavailable", name, rep.Status)
+		}
+	}
+}
+
+func TestRunAllPassing(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("db", pass)
+	r.Register("govinfo", pass, NonCritical())
+	if rep := r.Run(context.Background()); rep.Status != StatusOK {
+		t.Errorf("status = %s, want ok", rep.Status)
+	}
+
+	// An empty registry is ready.
+	if rep := NewRegistry(0).Run(context.Background()); rep.Status != StatusOK || len(rep.Checks) != 0 {
+		t.Errorf("empty registry = %+v", rep)
+	}
+}
+
+func TestRunIsConcurrent(t *testing.T) {
+	r := NewRegistry(time.Second)
+	slow := func(context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		r.Register(name, slow)
+	}
+	start := time.Now()
+	r.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("five 100ms checks took %v, want them run together", elapsed)
+	}
+}
+
+func TestCheckTimeoutIgnoredByChecker(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	r := NewRegistry(time.Hour)
+	r.Register("stuck", func(context.Context) error {
+		<-release
+		return nil
+	}, WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	rep := r.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run took %v, want WithTimeout to end it", elapsed)
+	}
+	if got := rep.Checks["stuck"]; got.Status != StatusError || !strings.Contains(got.Error, "20ms") {
+		t.Errorf("stuck = %+v, want a 20ms timeout", got)
+	}
+}
+
+func TestCheckPanicIsAnError(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("boom", func(context.Context) error { panic("nil map") })
+	rep := r.Run(context.Background())
+	if got := rep.Checks["boom"]; got.Status != StatusError || !strings.Contains(got.Error, "panicked") {
+		t.Errorf("boom = %+v, want a recovered panic", got)
+	}
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("db", fail)
+	r.Register("db", pass)
+	rep := r.Run(context.Background())
+	if len(rep.Checks) != 1 || rep.Checks["db"].Status != StatusOK {
+		t.Errorf("checks = %v, want only the replacement", rep.Checks)
+	}
+}
+
+func TestCached(t *testing.T) {
+	var calls atomic.Int32
+	fn := Cached(func(context.Context) error {
+		calls.Add(1)
+		return errors.New("down")
+	}, time.Hour)
+
+	for range 3 {
+		if err := fn(context.Background()); err == nil {
+			t.Error("cached check lost the error")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("checker called %d times within the ttl, want 1", n)
+	}
+
+	// A check cut short is not cached.
+	calls.Store(0)
+	fn = Cached(func(ctx context.Context) error {
+		calls.Add(1)
+		return ctx.Err()
+	}, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fn(ctx)
+	fn(context.Background())
+	if n := calls.Load(); n != 2 {
+		t.Errorf("checker called %d times, want a cancelled check retried", n)
+	}
+}
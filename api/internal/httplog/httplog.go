@@ -0,0 +1,117 @@
+// Package httplog logs outbound HTTP calls for troubleshooting the
+// integrations. Bodies are never logged, and credentials in the query
+// string and headers are redacted.
+package httplog
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveParams are query parameters whose values are redacted,
+// compared case-insensitively.
+var sensitiveParams = map[string]bool{
+	"access_token": true,
+	"api_key":      true,
+	"apikey":       true,
+	"key":          true,
+	"password":     true,
+	"secret":       true,
+	"token":        true,
+}
+
+// sensitiveHeaders are request headers whose values are redacted, in
+// canonical form.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+}
+
+// Transport logs each round trip through next at debug level: method,
+// redacted URL and headers, status and latency.
+type Transport struct {
+	next   http.RoundTripper
+	logger *zap.Logger
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// Wrap returns next with round-trip logging to logger. A nil next means
+// http.DefaultTransport.
+func Wrap(next http.RoundTripper, logger *zap.Logger) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{next: next, logger: logger}
+}
+
+// RoundTrip implements http.RoundTripper. Nothing is formatted unless the
+// logger has debug enabled, so leaving it installed costs little.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.logger.Core().Enabled(zapcore.DebugLevel) {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("url", RedactURL(req.URL)),
+		zap.Any("headers", redactHeaders(req.Header)),
+		zap.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		// Transport errors quote the URL, key included.
+		t.logger.Debug("http round trip failed", append(fields, zap.String("error", redactError(err, req.URL)))...)
+		return resp, err
+	}
+	t.logger.Debug("http round trip", append(fields, zap.Int("status", resp.StatusCode))...)
+	return resp, err
+}
+
+// RedactURL returns u as a string with the values of sensitive query
+// parameters and any userinfo password replaced.
+func RedactURL(u *url.URL) string {
+	r := *u
+	if r.RawQuery != "" {
+		q := r.Query()
+		for name, vals := range q {
+			if sensitiveParams[strings.ToLower(name)] {
+				for i := range vals {
+					vals[i] = redacted
+				}
+			}
+		}
+		r.RawQuery = q.Encode()
+	}
+	return r.Redacted()
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, vals := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			out[name] = redacted
+			continue
+		}
+		out[name] = strings.Join(vals, ", ")
+	}
+	return out
+}
+
+func redactError(err error, u *url.URL) string {
+	msg := err.Error()
+	if u.RawQuery == "" && u.User == nil {
+		return msg
+	}
+	return strings.ReplaceAll(msg, u.String(), RedactURL(u))
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// memAudit collects audit rows, failing every write when err is set.
+type memAudit struct {
+	mu   sync.Mutex
+	rows []db.Notification
+	err  error
+}
+
+func (a *memAudit) Record(_ context.Context, n db.Notification) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.rows = append(a.rows, n)
+	return nil
+}
+
+// bySubscription returns the rows sorted by subscription and channel, so
+// concurrent sends compare in a fixed order.
+func (a *memAudit) bySubscription() []db.Notification {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rows := slices.Clone(a.rows)
+	slices.SortFunc(rows, func(x, y db.Notification) int {
+		return strings.Compare(x.SubscriptionID+x.Channel, y.SubscriptionID+y.Channel)
+	})
+	return rows
+}
+
+func TestDispatchAuditsSentAndFailedSends(t *testing.T) {
+	subs := staticSubscribers{
+		{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}},
+		{ID: "2", PhoneNumber: "+12025550102", Email: "b@example.com", Channels: []string{db.ChannelSMS, db.ChannelEmail}},
+	}
+	send := notifierFunc(func(sub db.Subscription) (string, error) {
+		if sub.ID == "2" {
+			return "", &SendError{Err: errors.New("provider down")}
+		}
+		return "SM1", nil
+	})
+	audit := &memAudit{}
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: send, db.ChannelEmail: send}, nil, nil, 2, 0, zap.NewNop(),
+		WithAuditLog(audit))
+
+	if _, err := d.DispatchPackage(context.Background(), bill); err != nil {
+		t.Fatal(err)
+	}
+	want := []db.Notification{
+		{SubscriptionID: "1", Channel: db.ChannelSMS, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: db.NotificationSent, ProviderMessageID: "SM1"},
+		{SubscriptionID: "2", Channel: db.ChannelEmail, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: db.NotificationFailed, Error: "provider down"},
+		{SubscriptionID: "2", Channel: db.ChannelSMS, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: db.NotificationFailed, Error: "provider down"},
+	}
+	if got := audit.bySubscription(); !slices.Equal(got, want) {
+		t.Errorf("audit rows = %+v\nwant %+v", got, want)
+	}
+}
+
+type staticWebhooks []db.Webhook
+
+func (w staticWebhooks) ListByCollection(context.Context, string) ([]db.Webhook, error) {
+	return w, nil
+}
+
+type nopDeliverer struct{}
+
+func (nopDeliverer) DeliverPackage(context.Context, db.Webhook, govinfo.Package) error { return nil }
+
+func TestDispatchDoesNotAuditDedupedOrWebhookSends(t *testing.T) {
+	audit := &memAudit{}
+	hooks := staticWebhooks{{ID: "h1", URL: "https://example.com/hook"}}
+	d := NewDispatcher(smsSubs("+12025550101"), map[string]Notifier{db.ChannelSMS: newCountingNotifier()}, hooks, nopDeliverer{}, 1, 0, zap.NewNop(),
+		WithDeduper(NewMemoryDeduper(time.Hour)), WithAuditLog(audit))
+
+	for range 2 {
+		if _, err := d.DispatchPackage(context.Background(), bill); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rows := audit.bySubscription(); len(rows) != 1 || rows[0].Status != db.NotificationSent {
+		t.Errorf("audit rows = %+v, want only the first text", rows)
+	}
+}
+
+func TestDispatchAuditWriteFailureKeepsTheSend(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	d := NewDispatcher(smsSubs("+12025550101"), map[string]Notifier{db.ChannelSMS: newCountingNotifier()}, nil, nil, 1, 0, zap.New(core),
+		WithAuditLog(&memAudit{err: errors.New("db down")}))
+
+	res, err := d.DispatchPackage(context.Background(), bill)
+	if err != nil || res.Sent != 1 {
+		t.Fatalf("dispatch = %+v, %v; want the text counted as sent", res, err)
+	}
+	if n := logs.FilterMessage("notification audit write failed").Len(); n != 1 {
+		t.Errorf("logged %d audit failures, want 1", n)
+	}
+}
+
+func TestQueuedAlertIsAuditedByTheOutbox(t *testing.T) {
+	audit := &memAudit{}
+	outbox := &memOutbox{}
+	subs := staticSubscribers{
+		{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}},
+		{ID: "2", PhoneNumber: "+12025550102", Channels: []string{db.ChannelSMS}},
+	}
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: OutboxNotifier{Outbox: outbox}}, nil, nil, 1, 0, zap.NewNop(),
+		WithAuditLog(audit))
+	if _, err := d.DispatchPackage(context.Background(), bill); err != nil {
+		t.Fatal(err)
+	}
+	rows := audit.bySubscription()
+	if len(rows) != 2 || rows[0].Status != db.NotificationQueued || rows[1].Status != db.NotificationQueued {
+		t.Fatalf("dispatch audit rows = %+v, want both queued", rows)
+	}
+
+	sms := smsFunc(func(to string) (string, error) {
+		if to == "+12025550102" {
+			return "", &SendError{Err: errors.New("invalid number"), Permanent: true}
+		}
+		return "SM1", nil
+	})
+	w := NewOutboxWorker(outbox, sms, 100, 3, zap.NewNop(), WithOutboxAuditLog(audit))
+	for range 2 {
+		if _, err := w.SendNext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit.mu.Lock()
+	outcomes := slices.Clone(audit.rows[2:])
+	audit.mu.Unlock()
+	want := []db.Notification{
+		{SubscriptionID: "1", Channel: db.ChannelSMS, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: db.NotificationSent, ProviderMessageID: "SM1"},
+		{SubscriptionID: "2", Channel: db.ChannelSMS, PackageID: "BILLS-1", CollectionCode: "BILLS", Status: db.NotificationFailed, Error: "invalid number"},
+	}
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("outbox audit rows = %+v\nwant %+v", outcomes, want)
+	}
+}
+
+func TestOutboxRetryIsNotAudited(t *testing.T) {
+	audit := &memAudit{}
+	outbox := &memOutbox{}
+	outbox.Enqueue(context.Background(), db.OutboxMessage{SubscriptionID: "1", PhoneNumber: "+12025550101", PackageID: "BILLS-1"})
+	w := NewOutboxWorker(outbox, smsFunc(func(string) (string, error) {
+		return "", &SendError{Err: errors.New("timeout")}
+	}), 100, 3, zap.NewNop(), WithOutboxAuditLog(audit))
+
+	if _, err := w.SendNext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(outbox.retried) != 1 || len(audit.rows) != 0 {
+		t.Errorf("retried %v with audit rows %+v, want a retry and no row yet", outbox.retried, audit.rows)
+	}
+}
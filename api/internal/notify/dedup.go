@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/cache"
+)
+
+// memoryDedupPurgeEvery is how many claims MemoryDeduper takes between
+// sweeps of its expired entries.
+const memoryDedupPurgeEvery = 1000
+
+// Deduper remembers which packages were recently texted to which phone
+// numbers, so a package seen twice, or a number subscribed twice to the
+// same collection, gets one alert. Claim reports false for a pair already
+// claimed within the window; Release undoes a claim whose send failed.
+type Deduper interface {
+	Claim(ctx context.Context, phone, packageID string) (bool, error)
+	Release(ctx context.Context, phone, packageID string) error
+}
+
+// DBDedupStore is the durable store behind DBDeduper. *db.SMSDedupRepo
+// satisfies it.
+type DBDedupStore interface {
+	Claim(ctx context.Context, phone, packageID string, window time.Duration) (bool, error)
+	Release(ctx context.Context, phone, packageID string) error
+}
+
+// DBDeduper keeps claims in the database, so they hold across restarts
+// and instances.
+type DBDeduper struct {
+	Store  DBDedupStore
+	Window time.Duration
+}
+
+func (d DBDeduper) Claim(ctx context.Context, phone, packageID string) (bool, error) {
+	return d.Store.Claim(ctx, phone, packageID, d.Window)
+}
+
+func (d DBDeduper) Release(ctx context.Context, phone, packageID string) error {
+	return d.Store.Release(ctx, phone, packageID)
+}
+
+type dedupKey struct {
+	phone, packageID string
+}
+
+// MemoryDeduper keeps claims in process memory. It suits single-instance
+// deployments; claims are lost on restart.
+type MemoryDeduper struct {
+	mu     sync.Mutex
+	seen   *cache.TTLCache[dedupKey, struct{}]
+	claims int
+}
+
+// NewMemoryDeduper returns a deduper whose claims last window.
+func NewMemoryDeduper(window time.Duration) *MemoryDeduper {
+	return &MemoryDeduper{seen: cache.NewTTLCache[dedupKey, struct{}](window)}
+}
+
+func (d *MemoryDeduper) Claim(_ context.Context, phone, packageID string) (bool, error) {
+	key := dedupKey{phone, packageID}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.seen.Get(key); ok {
+		return false, nil
+	}
+	d.seen.Set(key, struct{}{})
+	if d.claims++; d.claims%memoryDedupPurgeEvery == 0 {
+		d.seen.Purge()
+	}
+	return true, nil
+}
+
+func (d *MemoryDeduper) Release(_ context.Context, phone, packageID string) error {
+	d.seen.Delete(dedupKey{phone, packageID})
+	return nil
+}
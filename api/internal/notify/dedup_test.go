@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// countingNotifier counts alerts per phone number, failing those in fail.
+type countingNotifier struct {
+	mu    sync.Mutex
+	sent  map[string]int
+	fail  map[string]bool
+	calls int
+}
+
+func newCountingNotifier() *countingNotifier {
+	return &countingNotifier{sent: map[string]int{}, fail: map[string]bool{}}
+}
+
+func (c *countingNotifier) Notify(_ context.Context, sub db.Subscription, _ govinfo.Package) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.fail[sub.PhoneNumber] {
+		return "", errors.New("provider down")
+	}
+	c.sent[sub.PhoneNumber]++
+	return "SM" + sub.ID, nil
+}
+
+func (c *countingNotifier) setFailing(phone string, failing bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fail[phone] = failing
+}
+
+func (c *countingNotifier) total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, v := range c.sent {
+		n += v
+	}
+	return n
+}
+
+var bill = govinfo.Package{PackageID: "BILLS-1", CollectionCode: "BILLS"}
+
+func smsSubs(phones ...string) staticSubscribers {
+	var subs staticSubscribers
+	for i, phone := range phones {
+		subs = append(subs, db.Subscription{ID: string(rune('a' + i)), PhoneNumber: phone, Channels: []string{db.ChannelSMS}})
+	}
+	return subs
+}
+
+func TestSecondDispatchSendsNothing(t *testing.T) {
+	sms := newCountingNotifier()
+	d := NewDispatcher(smsSubs("+12025550101", "+12025550102"), map[string]Notifier{db.ChannelSMS: sms}, nil, nil, 2, 0, zap.NewNop(),
+		WithDeduper(NewMemoryDeduper(time.Hour)))
+
+	first, err := d.DispatchPackage(context.Background(), bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Sent != 2 || first.Deduped != 0 {
+		t.Fatalf("first dispatch = %+v, want 2 sent", first)
+	}
+
+	second, err := d.DispatchPackage(context.Background(), bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Sent != 0 || second.Deduped != 2 || second.Failed != 0 {
+		t.Errorf("second dispatch = %+v, want both deduped", second)
+	}
+	if n := sms.total(); n != 2 {
+		t.Errorf("sent %d texts, want 2", n)
+	}
+
+	// Another package still goes out.
+	other, err := d.DispatchPackage(context.Background(), govinfo.Package{PackageID: "BILLS-2", CollectionCode: "BILLS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Sent != 2 {
+		t.Errorf("new package dispatch = %+v, want 2 sent", other)
+	}
+}
+
+func TestDuplicateSubscriptionsGetOneText(t *testing.T) {
+	sms := newCountingNotifier()
+	d := NewDispatcher(smsSubs("+12025550101", "+12025550101"), map[string]Notifier{db.ChannelSMS: sms}, nil, nil, 2, 0, zap.NewNop(),
+		WithDeduper(NewMemoryDeduper(time.Hour)))
+
+	res, err := d.DispatchPackage(context.Background(), bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sent != 1 || res.Deduped != 1 {
+		t.Errorf("result = %+v, want 1 sent and 1 deduped", res)
+	}
+	if n := sms.sent["+12025550101"]; n != 1 {
+		t.Errorf("number texted %d times, want 1", n)
+	}
+}
+
+func TestFailedSendReleasesClaim(t *testing.T) {
+	sms := newCountingNotifier()
+	sms.setFailing("+12025550101", true)
+	d := NewDispatcher(smsSubs("+12025550101"), map[string]Notifier{db.ChannelSMS: sms}, nil, nil, 1, 0, zap.NewNop(),
+		WithDeduper(NewMemoryDeduper(time.Hour)))
+
+	if res, _ := d.DispatchPackage(context.Background(), bill); res.Failed != 1 {
+		t.Fatalf("failing dispatch = %+v, want 1 failed", res)
+	}
+	sms.setFailing("+12025550101", false)
+	res, err := d.DispatchPackage(context.Background(), bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sent != 1 || res.Deduped != 0 {
+		t.Errorf("retry after a failure = %+v, want it sent", res)
+	}
+}
+
+// brokenDeduper can't be reached.
+type brokenDeduper struct{}
+
+func (brokenDeduper) Claim(context.Context, string, string) (bool, error) {
+	return false, errors.New("db down")
+}
+func (brokenDeduper) Release(context.Context, string, string) error { return nil }
+
+func TestUnreachableDeduperDoesNotBlockAlerts(t *testing.T) {
+	sms := newCountingNotifier()
+	d := NewDispatcher(smsSubs("+12025550101"), map[string]Notifier{db.ChannelSMS: sms}, nil, nil, 1, 0, zap.NewNop(),
+		WithDeduper(brokenDeduper{}))
+
+	res, err := d.DispatchPackage(context.Background(), bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sent != 1 {
+		t.Errorf("result = %+v, want the alert sent anyway", res)
+	}
+}
+
+func TestEmailIsNotDeduped(t *testing.T) {
+	email := newCountingNotifier()
+	subs := staticSubscribers{{ID: "a", PhoneNumber: "+12025550101", Email: "a@example.com", Channels: []string{db.ChannelEmail}}}
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelEmail: email}, nil, nil, 1, 0, zap.NewNop(),
+		WithDeduper(NewMemoryDeduper(time.Hour)))
+
+	for range 2 {
+		if _, err := d.DispatchPackage(context.Background(), bill); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if email.calls != 2 {
+		t.Errorf("email sent %d times, want 2", email.calls)
+	}
+}
+
+func TestMemoryDeduperWindow(t *testing.T) {
+	ctx := context.Background()
+	d := NewMemoryDeduper(30 * time.Millisecond)
+
+	if ok, _ := d.Claim(ctx, "+12025550101", "BILLS-1"); !ok {
+		t.Fatal("first claim refused")
+	}
+	if ok, _ := d.Claim(ctx, "+12025550101", "BILLS-1"); ok {
+		t.Error("second claim within the window granted")
+	}
+	if ok, _ := d.Claim(ctx, "+12025550102", "BILLS-1"); !ok {
+		t.Error("claim for another number refused")
+	}
+	if ok, _ := d.Claim(ctx, "+12025550101", "BILLS-2"); !ok {
+		t.Error("claim for another package refused")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ok, _ := d.Claim(ctx, "+12025550101", "BILLS-1"); !ok {
+		t.Error("claim after the window refused")
+	}
+
+	d.Release(ctx, "+12025550101", "BILLS-1")
+	if ok, _ := d.Claim(ctx, "+12025550101", "BILLS-1"); !ok {
+		t.Error("claim after Release refused")
+	}
+}
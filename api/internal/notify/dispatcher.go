@@ -0,0 +1,445 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+const (
+	defaultWorkers = 5
+	defaultGrace   = 10 * time.Second
+
+	// maxTitleLen keeps alerts close to a single SMS segment.
+	maxTitleLen = 100
+)
+
+// SubscriberLister finds the subscribers to alert for a collection.
+// *db.SubscriptionRepo satisfies it.
+type SubscriberLister interface {
+	ListByCollection(ctx context.Context, collectionCode string) ([]db.Subscription, error)
+}
+
+// WebhookLister finds the webhooks to notify for a collection.
+// *db.WebhookRepo satisfies it.
+type WebhookLister interface {
+	ListByCollection(ctx context.Context, collectionCode string) ([]db.Webhook, error)
+}
+
+// WebhookDeliverer posts a package event to one webhook, retrying as it
+// sees fit. *webhook.Sender satisfies it.
+type WebhookDeliverer interface {
+	DeliverPackage(ctx context.Context, hook db.Webhook, pkg govinfo.Package) error
+}
+
+// AuditLog records each alert sent to a subscription. *db.NotificationRepo
+// satisfies it.
+type AuditLog interface {
+	Record(ctx context.Context, n db.Notification) error
+}
+
+// queuingNotifier is a Notifier that only queues alerts. Its sends are
+// audited as queued, leaving whatever drains the queue to record the
+// outcome.
+type queuingNotifier interface {
+	Notifier
+	queues()
+}
+
+// ErrDispatcherClosed is returned by DispatchPackage after Close.
+var ErrDispatcherClosed = errors.New("notify: dispatcher closed")
+
+// errDeduped marks an SMS alert skipped because the deduper had already
+// claimed it.
+var errDeduped = errors.New("notify: alert already sent")
+
+// Dispatcher fans a package event out to every subscriber and webhook of
+// its collection over a bounded pool of senders.
+//
+// It is also a prometheus.Collector for its send counts and batch
+// durations.
+type Dispatcher struct {
+	subs      SubscriberLister
+	notifiers map[string]Notifier
+	hooks     WebhookLister
+	webhooks  WebhookDeliverer
+	workers   int
+	grace     time.Duration
+	dedup     Deduper
+	audit     AuditLog
+	logger    *zap.Logger
+	metrics   dispatchMetrics
+
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+	// abort cancels sends still running when Close gives up waiting.
+	abort      context.Context
+	abortSends context.CancelFunc
+}
+
+// DispatcherOption customises a Dispatcher built by NewDispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithDeduper skips SMS alerts for a phone number and package that dedup
+// has already claimed, counting them as deduped.
+func WithDeduper(dedup Deduper) DispatcherOption {
+	return func(d *Dispatcher) { d.dedup = dedup }
+}
+
+// WithAuditLog records every subscription send, successful or not, to
+// audit. Deduped and skipped alerts aren't recorded, and neither are
+// sends to registered webhooks.
+func WithAuditLog(audit AuditLog) DispatcherOption {
+	return func(d *Dispatcher) { d.audit = audit }
+}
+
+// NewDispatcher returns a dispatcher that alerts each subscription over
+// its channels using the notifier registered for each, plus registered
+// webhooks when hooks is non-nil. grace bounds how long in-flight sends
+// may run on once a dispatch's context is cancelled.
+func NewDispatcher(subs SubscriberLister, notifiers map[string]Notifier, hooks WebhookLister, webhooks WebhookDeliverer, workers int, grace time.Duration, logger *zap.Logger, opts ...DispatcherOption) *Dispatcher {
+	if workers < 1 {
+		workers = defaultWorkers
+	}
+	if grace <= 0 {
+		grace = defaultGrace
+	}
+	abort, abortSends := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		subs:       subs,
+		notifiers:  notifiers,
+		hooks:      hooks,
+		webhooks:   webhooks,
+		workers:    workers,
+		grace:      grace,
+		logger:     logger,
+		metrics:    newDispatchMetrics(),
+		abort:      abort,
+		abortSends: abortSends,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Close stops new dispatches and waits for in-flight ones to finish. If
+// ctx ends first, sends still running are cancelled and ctx's error is
+// returned. The dispatcher has no loop of its own, so there is no Run.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.abortSends()
+		return fmt.Errorf("notify: close dispatcher: %w", ctx.Err())
+	}
+}
+
+// DispatchResult summarises one DispatchPackage call.
+type DispatchResult struct {
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+	// Deduped counts SMS alerts not sent because the number already got
+	// this package within the dedup window.
+	Deduped int `json:"deduped,omitempty"`
+	// Skipped counts recipients never tried because ctx was cancelled.
+	Skipped int `json:"skipped,omitempty"`
+	// Canceled reports that ctx ended before every recipient was tried.
+	Canceled bool              `json:"canceled,omitempty"`
+	Failures []DispatchFailure `json:"failures,omitempty"`
+}
+
+// DispatchFailure records a single recipient that could not be alerted.
+// Exactly one of SubscriptionID and WebhookID is set, and Channel is the
+// channel that failed.
+type DispatchFailure struct {
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	Channel        string `json:"channel,omitempty"`
+	WebhookID      string `json:"webhookId,omitempty"`
+	Error          string `json:"error"`
+	Permanent      bool   `json:"permanent"`
+}
+
+// recipient is one send in a dispatch, over whichever channel it uses.
+// notification is the audit row to record for it, and is nil for
+// registered webhooks.
+type recipient struct {
+	failure      DispatchFailure
+	logID        zap.Field
+	notification *db.Notification
+	send         func(ctx context.Context) (string, error)
+}
+
+// DispatchPackage alerts every subscriber and webhook of pkg's
+// collection. A failed recipient is logged and counted but does not stop
+// the batch.
+//
+// Cancelling ctx stops further recipients from being enqueued. Sends
+// already under way get the dispatcher's grace window to finish before
+// their context is cancelled too, as they do when Close runs out of time.
+// An interrupted batch returns its partial result, with Canceled set, and
+// an error wrapping ctx.Err().
+func (d *Dispatcher) DispatchPackage(ctx context.Context, pkg govinfo.Package) (DispatchResult, error) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return DispatchResult{}, ErrDispatcherClosed
+	}
+	d.inflight.Add(1)
+	d.mu.Unlock()
+	defer d.inflight.Done()
+
+	start := time.Now()
+	status := "error"
+	defer func() {
+		d.metrics.duration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+	}()
+
+	sendCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	defer context.AfterFunc(d.abort, cancel)()
+	defer context.AfterFunc(ctx, func() { time.AfterFunc(d.grace, cancel) })()
+
+	recipients, err := d.recipients(ctx, pkg)
+	if err != nil {
+		return DispatchResult{}, err
+	}
+	jobs := make(chan recipient)
+
+	var (
+		mu     sync.Mutex
+		result DispatchResult
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < min(d.workers, len(recipients)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var rcpt recipient
+				select {
+				case <-ctx.Done():
+					return
+				case next, ok := <-jobs:
+					if !ok {
+						return
+					}
+					rcpt = next
+				}
+				msgID, err := rcpt.send(sendCtx)
+
+				if errors.Is(err, errDeduped) {
+					d.metrics.notifications.WithLabelValues(rcpt.failure.Channel, outcomeDeduped).Inc()
+					mu.Lock()
+					result.Deduped++
+					mu.Unlock()
+					continue
+				}
+				d.record(sendCtx, rcpt, msgID, err)
+				d.metrics.notifications.WithLabelValues(rcpt.failure.Channel, sendOutcome(rcpt, err)).Inc()
+
+				mu.Lock()
+				if err != nil {
+					failure := rcpt.failure
+					failure.Error = err.Error()
+					failure.Permanent = IsPermanent(err)
+					result.Failed++
+					result.Failures = append(result.Failures, failure)
+				} else {
+					result.Sent++
+				}
+				mu.Unlock()
+
+				if err != nil {
+					d.logger.Warn("alert send failed",
+						zap.String("package_id", pkg.PackageID),
+						rcpt.logID,
+						zap.String("channel", rcpt.failure.Channel),
+						zap.Bool("permanent", IsPermanent(err)),
+						zap.Error(err))
+				}
+			}
+		}()
+	}
+
+enqueue:
+	for _, rcpt := range recipients {
+		select {
+		case jobs <- rcpt:
+		case <-ctx.Done():
+			break enqueue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result.Skipped = len(recipients) - result.Sent - result.Failed - result.Deduped
+	if result.Skipped > 0 {
+		result.Canceled = true
+		status = "canceled"
+		d.logger.Warn("package dispatch interrupted",
+			zap.String("package_id", pkg.PackageID),
+			zap.String("collection", pkg.CollectionCode),
+			zap.Int("sent", result.Sent),
+			zap.Int("failed", result.Failed),
+			zap.Int("deduped", result.Deduped),
+			zap.Int("skipped", result.Skipped))
+		return result, fmt.Errorf("notify: dispatch %s interrupted: %w", pkg.PackageID, ctx.Err())
+	}
+
+	d.logger.Info("package dispatched",
+		zap.String("package_id", pkg.PackageID),
+		zap.String("collection", pkg.CollectionCode),
+		zap.Int("sent", result.Sent),
+		zap.Int("failed", result.Failed),
+		zap.Int("deduped", result.Deduped))
+	status = "ok"
+	return result, nil
+}
+
+// sendOutcome labels a finished send for notify_alerts_total.
+func sendOutcome(rcpt recipient, err error) string {
+	switch {
+	case err != nil:
+		return outcomeFailed
+	case rcpt.notification != nil && rcpt.notification.Status == db.NotificationQueued:
+		return outcomeQueued
+	default:
+		return outcomeSent
+	}
+}
+
+// recipients lists every send pkg needs: one per channel of each active
+// subscription, and one per registered webhook.
+func (d *Dispatcher) recipients(ctx context.Context, pkg govinfo.Package) ([]recipient, error) {
+	subs, err := d.subs.ListByCollection(ctx, pkg.CollectionCode)
+	if err != nil {
+		return nil, fmt.Errorf("notify: list subscribers for %s: %w", pkg.CollectionCode, err)
+	}
+	var hooks []db.Webhook
+	if d.hooks != nil {
+		hooks, err = d.hooks.ListByCollection(ctx, pkg.CollectionCode)
+		if err != nil {
+			return nil, fmt.Errorf("notify: list webhooks for %s: %w", pkg.CollectionCode, err)
+		}
+	}
+
+	recipients := make([]recipient, 0, len(subs)+len(hooks))
+	for _, sub := range subs {
+		for _, channel := range sub.Channels {
+			n, ok := d.notifiers[channel]
+			send := func(ctx context.Context) (string, error) {
+				return n.Notify(ctx, sub, pkg)
+			}
+			if !ok {
+				send = func(context.Context) (string, error) {
+					return "", &SendError{Err: fmt.Errorf("notify: no notifier for channel %q", channel), Permanent: true}
+				}
+			} else if channel == db.ChannelSMS && d.dedup != nil {
+				send = d.dedupSend(sub.PhoneNumber, pkg.PackageID, send)
+			}
+			status := db.NotificationSent
+			if _, queues := n.(queuingNotifier); queues {
+				status = db.NotificationQueued
+			}
+			recipients = append(recipients, recipient{
+				failure: DispatchFailure{SubscriptionID: sub.ID, Channel: channel},
+				logID:   zap.String("subscription_id", sub.ID),
+				notification: &db.Notification{
+					SubscriptionID: sub.ID,
+					Channel:        channel,
+					PackageID:      pkg.PackageID,
+					CollectionCode: pkg.CollectionCode,
+					Status:         status,
+				},
+				send: send,
+			})
+		}
+	}
+	for _, hook := range hooks {
+		recipients = append(recipients, recipient{
+			failure: DispatchFailure{WebhookID: hook.ID, Channel: db.ChannelWebhook},
+			logID:   zap.String("webhook_id", hook.ID),
+			send: func(ctx context.Context) (string, error) {
+				return "", d.webhooks.DeliverPackage(ctx, hook, pkg)
+			},
+		})
+	}
+	return recipients, nil
+}
+
+// dedupSend wraps an SMS send so it only runs when the deduper grants the
+// claim for phone and packageID, and gives the claim back if the send
+// fails. A deduper that can't be reached doesn't block the alert: a
+// duplicate text beats a missed one.
+func (d *Dispatcher) dedupSend(phone, packageID string, send func(ctx context.Context) (string, error)) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		claimed, err := d.dedup.Claim(ctx, phone, packageID)
+		if err != nil {
+			d.logger.Warn("sms dedup claim failed, sending anyway", zap.String("package_id", packageID), zap.Error(err))
+			return send(ctx)
+		}
+		if !claimed {
+			return "", errDeduped
+		}
+		msgID, err := send(ctx)
+		if err != nil {
+			if relErr := d.dedup.Release(context.WithoutCancel(ctx), phone, packageID); relErr != nil {
+				d.logger.Warn("sms dedup release failed", zap.String("package_id", packageID), zap.Error(relErr))
+			}
+			return "", err
+		}
+		return msgID, nil
+	}
+}
+
+// record writes the audit row for rcpt's send, if it has one. The row is
+// written even once ctx is cancelled, since the send it describes has
+// already happened.
+func (d *Dispatcher) record(ctx context.Context, rcpt recipient, msgID string, sendErr error) {
+	if d.audit == nil || rcpt.notification == nil {
+		return
+	}
+	n := *rcpt.notification
+	n.ProviderMessageID = msgID
+	if sendErr != nil {
+		n.Status = db.NotificationFailed
+		n.Error = sendErr.Error()
+	}
+	if err := d.audit.Record(context.WithoutCancel(ctx), n); err != nil {
+		d.logger.Error("notification audit write failed",
+			zap.String("package_id", n.PackageID),
+			zap.String("subscription_id", n.SubscriptionID),
+			zap.String("channel", n.Channel),
+			zap.Error(err))
+	}
+}
+
+// FormatPackageAlert renders the SMS text for a new package.
+func FormatPackageAlert(pkg govinfo.Package) string {
+	title := pkg.Title
+	if r := []rune(title); len(r) > maxTitleLen {
+		title = string(r[:maxTitleLen-1]) + "…"
+	}
+	return fmt.Sprintf("New in %s: %s https://www.govinfo.gov/app/details/%s Reply STOP to unsubscribe.",
+		pkg.CollectionCode, title, pkg.PackageID)
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// staticSubscribers returns the same subscribers for every collection.
+type staticSubscribers []db.Subscription
+
+func (s staticSubscribers) ListByCollection(context.Context, string) ([]db.Subscription, error) {
+	return s, nil
+}
+
+// blockingSender signals each send on started and holds it until release
+// is closed or its context ends.
+type blockingSender struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s blockingSender) SendSMS(ctx context.Context, _, _ string) (string, error) {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+		return "SM1", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// notifierFunc adapts a function to Notifier.
+type notifierFunc func(sub db.Subscription) (string, error)
+
+func (f notifierFunc) Notify(_ context.Context, sub db.Subscription, _ govinfo.Package) (string, error) {
+	return f(sub)
+}
+
+func newBlockingDispatcher() (*Dispatcher, blockingSender) {
+	s := blockingSender{started: make(chan struct{}, 1), release: make(chan struct{})}
+	subs := staticSubscribers{{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}}}
+	return NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: SMSNotifier{Sender: s}}, nil, nil, 1, time.Minute, zap.NewNop()), s
+}
+
+func TestDispatcherCloseWaitsForInflight(t *testing.T) {
+	d, s := newBlockingDispatcher()
+	type outcome struct {
+		res DispatchResult
+		err error
+	}
+	dispatched := make(chan outcome, 1)
+	go func() {
+		res, err := d.DispatchPackage(context.Background(), govinfo.Package{PackageID: "BILLS-1"})
+		dispatched <- outcome{res, err}
+	}()
+	<-s.started
+
+	closed := make(chan error, 1)
+	go func() { closed <- d.Close(context.Background()) }()
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v with a send in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, err := d.DispatchPackage(context.Background(), govinfo.Package{}); !errors.Is(err, ErrDispatcherClosed) {
+		t.Errorf("dispatch during Close = %v, want ErrDispatcherClosed", err)
+	}
+
+	close(s.release)
+	if err := <-closed; err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	if got := <-dispatched; got.err != nil || got.res.Sent != 1 {
+		t.Errorf("in-flight dispatch = %+v, %v; want one send", got.res, got.err)
+	}
+}
+
+func TestDispatcherCloseTimeoutAbortsSends(t *testing.T) {
+	d, s := newBlockingDispatcher()
+	dispatched := make(chan DispatchResult, 1)
+	go func() {
+		res, _ := d.DispatchPackage(context.Background(), govinfo.Package{PackageID: "BILLS-1"})
+		dispatched <- res
+	}()
+	<-s.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close = %v, want context.DeadlineExceeded", err)
+	}
+
+	select {
+	case res := <-dispatched:
+		if res.Failed != 1 {
+			t.Errorf("aborted dispatch = %+v, want one failed send", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("send kept running after Close gave up")
+	}
+}
+
+func TestDispatchCancelledMidBatch(t *testing.T) {
+	const total = 50
+	var subs staticSubscribers
+	for i := range total {
+		subs = append(subs, db.Subscription{ID: fmt.Sprint(i), PhoneNumber: fmt.Sprintf("+1202555%04d", i), Channels: []string{db.ChannelSMS}})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var sent atomic.Int32
+	send := notifierFunc(func(db.Subscription) (string, error) {
+		if sent.Add(1) == 10 {
+			cancel()
+		}
+		return "msg", nil
+	})
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: send}, nil, nil, 2, time.Minute, zap.NewNop())
+
+	res, err := d.DispatchPackage(ctx, govinfo.Package{PackageID: "BILLS-1"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if !res.Canceled {
+		t.Error("result not marked Canceled")
+	}
+	if got := int(sent.Load()); res.Sent != got {
+		t.Errorf("reported %d sent, %d actually went out", res.Sent, got)
+	}
+	if res.Sent+res.Failed+res.Skipped != total {
+		t.Errorf("sent %d + failed %d + skipped %d != %d", res.Sent, res.Failed, res.Skipped, total)
+	}
+	// At most one send per worker can start after the cancel.
+	if res.Sent > 12 || res.Skipped == 0 {
+		t.Errorf("result = %+v, want enqueueing to stop soon after the cancel", res)
+	}
+}
+
+func TestDispatchGraceCutsOffSlowSends(t *testing.T) {
+	s := blockingSender{started: make(chan struct{}, 1), release: make(chan struct{})}
+	subs := staticSubscribers{{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}}}
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: SMSNotifier{Sender: s}}, nil, nil, 1, 20*time.Millisecond, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan DispatchResult, 1)
+	go func() {
+		res, _ := d.DispatchPackage(ctx, govinfo.Package{PackageID: "BILLS-1"})
+		done <- res
+	}()
+	<-s.started
+	cancel()
+
+	select {
+	case res := <-done:
+		if res.Failed != 1 {
+			t.Errorf("result = %+v, want the slow send failed after the grace window", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("send outlived the grace window")
+	}
+}
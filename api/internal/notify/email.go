@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// smtpImplicitTLSPort is the submissions port (RFC 8314), where TLS starts
+// before the SMTP greeting rather than via STARTTLS.
+const smtpImplicitTLSPort = 465
+
+// smtpTimeout bounds a whole SMTP conversation when ctx has no deadline.
+const smtpTimeout = 30 * time.Second
+
+// ErrSMTPAuth matches send errors caused by the relay rejecting, or us
+// refusing to send, the configured credentials. Retrying won't help
+// until SMTP_USER or SMTP_PASS is fixed.
+var ErrSMTPAuth = errors.New("smtp: authentication failed")
+
+// EmailSender sends a single plain-text email, returning its Message-ID.
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) (string, error)
+}
+
+// SMTPSender sends email through an SMTP relay. On port 465 it speaks
+// TLS from the start; on any other port it upgrades with STARTTLS when
+// the server offers it.
+type SMTPSender struct {
+	host        string
+	addr        string
+	implicitTLS bool
+	auth        smtp.Auth
+	from        string
+	// msgIDDomain is the right-hand side of generated Message-IDs.
+	msgIDDomain string
+	// rootCAs verifies the relay's certificate; nil means the system
+	// roots.
+	rootCAs *x509.CertPool
+}
+
+var _ EmailSender = (*SMTPSender)(nil)
+
+// NewSMTPSender builds a sender from the SMTP settings in cfg. Without
+// SMTP_USER it sends unauthenticated.
+func NewSMTPSender(cfg *config.Config) *SMTPSender {
+	s := &SMTPSender{
+		host:        cfg.SMTPHost,
+		addr:        net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		implicitTLS: cfg.SMTPPort == smtpImplicitTLSPort,
+		from:        cfg.SMTPFrom,
+		msgIDDomain: cfg.SMTPHost,
+	}
+	if addr, err := mail.ParseAddress(cfg.SMTPFrom); err == nil {
+		if i := strings.LastIndexByte(addr.Address, '@'); i >= 0 {
+			s.msgIDDomain = addr.Address[i+1:]
+		}
+	}
+	if cfg.SMTPUser != "" {
+		s.auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPHost)
+	}
+	return s
+}
+
+// SMTPError is a 4xx or 5xx reply from the relay. Op is the step that
+// failed: auth, mail, rcpt or data.
+type SMTPError struct {
+	Op      string
+	Code    int
+	Message string
+}
+
+func (e *SMTPError) Error() string {
+	return fmt.Sprintf("smtp: %s: %d %s", e.Op, e.Code, e.Message)
+}
+
+// Is reports a permanent rejection during AUTH as ErrSMTPAuth. 454 is a
+// temporary authentication failure and doesn't match.
+func (e *SMTPError) Is(target error) bool {
+	return target == ErrSMTPAuth && e.Op == "auth" && e.Code >= 500
+}
+
+// SendEmail sends body to to and returns the Message-ID it was sent
+// with. ctx bounds the whole conversation: its deadline is applied to
+// the connection, and cancelling it closes the connection. Auth failures
+// and 5xx replies are permanent; 4xx replies and network errors are
+// transient.
+func (s *SMTPSender) SendEmail(ctx context.Context, to, subject, body string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", &SendError{Err: fmt.Errorf("smtp: send to %s: %w", to, err)}
+	}
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return "", &SendError{Err: fmt.Errorf("smtp: header values must not contain newlines"), Permanent: true}
+	}
+
+	msgID := "<" + uuid.NewString() + "@" + s.msgIDDomain + ">"
+	msg := "From: " + s.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Message-ID: " + msgID + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return "", &SendError{Err: fmt.Errorf("smtp: connect to %s: %w", s.addr, err)}
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if op, err := s.send(conn, to, []byte(msg)); err != nil {
+		if ctx.Err() != nil {
+			return "", &SendError{Err: fmt.Errorf("smtp: send to %s: %w (last error: %v)", to, ctx.Err(), err)}
+		}
+		return "", smtpSendError(op, to, err)
+	}
+	return msgID, nil
+}
+
+// dial connects to the relay, completing the TLS handshake first on the
+// implicit TLS port.
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.implicitTLS {
+		d := &tls.Dialer{Config: s.tlsConfig()}
+		conn, err = d.DialContext(ctx, "tcp", s.addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	return &tls.Config{ServerName: s.host, RootCAs: s.rootCAs}
+}
+
+// send runs the SMTP conversation on conn and returns the step that
+// failed alongside the error.
+func (s *SMTPSender) send(conn net.Conn, to string, msg []byte) (string, error) {
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return "connect", err
+	}
+	if !s.implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(s.tlsConfig()); err != nil {
+				return "starttls", err
+			}
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return "auth", errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return "auth", err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return "mail", err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return "rcpt", err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return "data", err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return "data", err
+	}
+	if err := w.Close(); err != nil {
+		return "data", err
+	}
+	// The message has been accepted; a failed QUIT doesn't change that.
+	_ = c.Quit()
+	return "", nil
+}
+
+// smtpSendError classifies an error from step op of the conversation.
+func smtpSendError(op, to string, err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &SendError{Err: fmt.Errorf("smtp: send to %s: %s: %w", to, op, err)}
+	}
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		smtpErr := &SMTPError{Op: op, Code: tpErr.Code, Message: tpErr.Msg}
+		return &SendError{Err: smtpErr, Permanent: tpErr.Code >= 500}
+	}
+	if op == "auth" {
+		// net/smtp refuses PLAIN over an unencrypted connection to a
+		// remote host, and some relays don't offer AUTH at all.
+		return &SendError{Err: fmt.Errorf("%w: %v", ErrSMTPAuth, err), Permanent: true}
+	}
+	// TLS handshake and certificate failures won't fix themselves.
+	return &SendError{Err: fmt.Errorf("smtp: send to %s: %s: %w", to, op, err), Permanent: op == "starttls"}
+}
+
+var packageEmailTemplate = template.Must(template.New("package").Parse(
+	`{{.Title}}
+
+{{.Link}}
+
+You are receiving this because you subscribed to {{.Collection}} alerts.
+`))
+
+// FormatPackageEmail renders the email subject and body for a new package.
+func FormatPackageEmail(pkg govinfo.Package) (subject, body string) {
+	title := strings.Join(strings.Fields(pkg.Title), " ")
+	subject = fmt.Sprintf("New in %s: %s", pkg.CollectionCode, title)
+
+	var b strings.Builder
+	// The template only reads string fields, so Execute can't fail.
+	_ = packageEmailTemplate.Execute(&b, struct {
+		Title, Link, Collection string
+	}{
+		Title:      title,
+		Link:       "https://www.govinfo.gov/app/details/" + pkg.PackageID,
+		Collection: pkg.CollectionCode,
+	})
+	return subject, b.String()
+}
@@ -0,0 +1,306 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// testCert returns a certificate for 127.0.0.1 and a pool trusting it.
+func testCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, roots
+}
+
+// smtpMock is an in-process SMTP relay. It offers STARTTLS when startTLS
+// is set, speaks TLS from the greeting when implicitTLS is set, and
+// answers AUTH and RCPT with authReply and rcptReply when they're set.
+type smtpMock struct {
+	tls         *tls.Config
+	startTLS    bool
+	implicitTLS bool
+	authReply   string
+	rcptReply   string
+
+	mu       sync.Mutex
+	overTLS  bool
+	creds    string
+	messages []string
+}
+
+// start listens on 127.0.0.1 and returns a sender pointed at the mock,
+// trusting roots.
+func (m *smtpMock) start(t *testing.T, roots *x509.CertPool) *SMTPSender {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return &SMTPSender{
+		host:        "127.0.0.1",
+		addr:        ln.Addr().String(),
+		implicitTLS: m.implicitTLS,
+		auth:        smtp.PlainAuth("", "user", "pass", "127.0.0.1"),
+		from:        "alerts@example.com",
+		msgIDDomain: "example.com",
+		rootCAs:     roots,
+	}
+}
+
+func (m *smtpMock) serve(conn net.Conn) {
+	defer conn.Close()
+	secure := false
+	if m.implicitTLS {
+		conn = tls.Server(conn, m.tls)
+		secure = true
+	}
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 mock ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ext := []string{"mock"}
+			if m.startTLS && !secure {
+				ext = append(ext, "STARTTLS")
+			}
+			ext = append(ext, "AUTH PLAIN")
+			for i, e := range ext {
+				sep := "-"
+				if i == len(ext)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, e)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready")
+			tc := tls.Server(conn, m.tls)
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tc, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			_, resp, _ := strings.Cut(arg, " ")
+			creds, _ := base64.StdEncoding.DecodeString(resp)
+			m.mu.Lock()
+			m.creds, m.overTLS = string(creds), secure
+			m.mu.Unlock()
+			reply(tp, m.authReply, "235 2.7.0 accepted")
+		case "MAIL":
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			reply(tp, m.rcptReply, "250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			msg, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			m.mu.Lock()
+			m.messages = append(m.messages, string(msg))
+			m.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+func reply(tp *textproto.Conn, line, fallback string) {
+	if line == "" {
+		line = fallback
+	}
+	tp.PrintfLine("%s", line)
+}
+
+func TestSMTPSendEmailOverSTARTTLS(t *testing.T) {
+	cert, roots := testCert(t)
+	m := &smtpMock{tls: &tls.Config{Certificates: []tls.Certificate{cert}}, startTLS: true}
+	s := m.start(t, roots)
+
+	pkg := govinfo.Package{PackageID: "BILLS-118hr1enr", Title: "An  Act\nto do things", CollectionCode: "BILLS"}
+	subject, body := FormatPackageEmail(pkg)
+	msgID, err := s.SendEmail(context.Background(), "reader@example.com", subject, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.overTLS || m.creds != "\x00user\x00pass" {
+		t.Errorf("auth over TLS %v with %q, want PLAIN user/pass after STARTTLS", m.overTLS, m.creds)
+	}
+	if len(m.messages) != 1 {
+		t.Fatalf("relay got %d messages, want 1", len(m.messages))
+	}
+	// ReadDotBytes has already turned CRLF into LF.
+	msg := m.messages[0]
+	for _, want := range []string{
+		"From: alerts@example.com\n",
+		"To: reader@example.com\n",
+		"Subject: New in BILLS: An Act to do things\n",
+		"Message-ID: " + msgID + "\n",
+		"Content-Type: text/plain; charset=UTF-8\n",
+		"https://www.govinfo.gov/app/details/BILLS-118hr1enr\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+	if !strings.HasSuffix(msgID, "@example.com>") {
+		t.Errorf("Message-ID = %q, want it on the sender's domain", msgID)
+	}
+}
+
+func TestSMTPSendEmailOverImplicitTLS(t *testing.T) {
+	cert, roots := testCert(t)
+	m := &smtpMock{tls: &tls.Config{Certificates: []tls.Certificate{cert}}, implicitTLS: true}
+	s := m.start(t, roots)
+
+	if _, err := s.SendEmail(context.Background(), "reader@example.com", "hi", "body"); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.overTLS || len(m.messages) != 1 {
+		t.Errorf("over TLS %v, %d messages; want one message sent over TLS", m.overTLS, len(m.messages))
+	}
+}
+
+func TestSMTPSendEmailErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name                    string
+		authReply, rcptReply    string
+		wantAuth, wantPermanent bool
+		wantOp                  string
+		wantCode                int
+	}{
+		{"rejected credentials", "535 5.7.8 bad credentials", "", true, true, "auth", 535},
+		{"temporary auth failure", "454 4.7.0 try later", "", false, false, "auth", 454},
+		{"rejected recipient", "", "550 5.1.1 no such user", false, true, "rcpt", 550},
+		{"greylisted recipient", "", "451 4.7.1 greylisted", false, false, "rcpt", 451},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := (&smtpMock{authReply: tc.authReply, rcptReply: tc.rcptReply}).start(t, nil)
+			_, err := s.SendEmail(context.Background(), "reader@example.com", "hi", "body")
+			if errors.Is(err, ErrSMTPAuth) != tc.wantAuth {
+				t.Errorf("errors.Is(%v, ErrSMTPAuth) = %v, want %v", err, !tc.wantAuth, tc.wantAuth)
+			}
+			if IsPermanent(err) != tc.wantPermanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, !tc.wantPermanent, tc.wantPermanent)
+			}
+			var smtpErr *SMTPError
+			if !errors.As(err, &smtpErr) || smtpErr.Op != tc.wantOp || smtpErr.Code != tc.wantCode {
+				t.Errorf("err = %v, want an SMTPError for %s %d", err, tc.wantOp, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestSMTPUntrustedCertificateIsPermanent(t *testing.T) {
+	cert, _ := testCert(t)
+	m := &smtpMock{tls: &tls.Config{Certificates: []tls.Certificate{cert}}, startTLS: true}
+	s := m.start(t, x509.NewCertPool())
+
+	_, err := s.SendEmail(context.Background(), "reader@example.com", "hi", "body")
+	if err == nil || !IsPermanent(err) || errors.Is(err, ErrSMTPAuth) {
+		t.Errorf("err = %v, want a permanent non-auth error", err)
+	}
+}
+
+func TestSMTPUnreachableRelayIsTransient(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := &SMTPSender{host: "127.0.0.1", addr: addr, from: "alerts@example.com", msgIDDomain: "example.com"}
+	_, err = s.SendEmail(context.Background(), "reader@example.com", "hi", "body")
+	var se *SendError
+	if !errors.As(err, &se) || se.Permanent {
+		t.Errorf("err = %v, want a transient SendError", err)
+	}
+}
+
+func TestSMTPRejectsHeaderInjection(t *testing.T) {
+	s := (&smtpMock{}).start(t, nil)
+	_, err := s.SendEmail(context.Background(), "reader@example.com\r\nBcc: all@example.com", "hi", "body")
+	if !IsPermanent(err) {
+		t.Errorf("err = %v, want a permanent error", err)
+	}
+}
+
+func TestNewSMTPSender(t *testing.T) {
+	s := NewSMTPSender(&config.Config{SMTPHost: "smtp.example.org", SMTPPort: 465, SMTPFrom: "GovInfo Alerts <alerts@example.com>"})
+	if !s.implicitTLS || s.addr != "smtp.example.org:465" || s.auth != nil {
+		t.Errorf("port 465 sender = %+v, want implicit TLS without auth", s)
+	}
+	if s.msgIDDomain != "example.com" {
+		t.Errorf("msgIDDomain = %q, want the From domain", s.msgIDDomain)
+	}
+
+	s = NewSMTPSender(&config.Config{SMTPHost: "smtp.example.org", SMTPPort: 587, SMTPUser: "u", SMTPPass: "p", SMTPFrom: "not an address"})
+	if s.implicitTLS || s.auth == nil || s.msgIDDomain != "smtp.example.org" {
+		t.Errorf("port 587 sender = %+v, want STARTTLS with auth and the host as Message-ID domain", s)
+	}
+}
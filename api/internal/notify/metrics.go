@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcome labels for notify_alerts_total and notify_outbox_sends_total.
+// Sends to a queuing notifier count as queued in the first; the outbox
+// worker counts how they end in the second.
+const (
+	outcomeSent    = "sent"
+	outcomeQueued  = "queued"
+	outcomeFailed  = "failed"
+	outcomeDeduped = "deduped"
+	outcomeRetry   = "retry"
+)
+
+// dispatchMetrics are the Dispatcher's Prometheus collectors.
+type dispatchMetrics struct {
+	notifications *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+}
+
+func newDispatchMetrics() dispatchMetrics {
+	return dispatchMetrics{
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notify_alerts_total",
+			Help: "Alerts handled by the dispatcher, by channel and outcome.",
+		}, []string{"channel", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "notify_dispatch_duration_seconds",
+			Help: "Time to dispatch one package to all of its recipients, by status (ok, canceled or error).",
+			// A batch is a send per subscriber, so allow for minutes.
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, []string{"status"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (d *Dispatcher) Describe(ch chan<- *prometheus.Desc) {
+	d.metrics.notifications.Describe(ch)
+	d.metrics.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (d *Dispatcher) Collect(ch chan<- prometheus.Metric) {
+	d.metrics.notifications.Collect(ch)
+	d.metrics.duration.Collect(ch)
+}
+
+func newOutboxSends() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "notify_outbox_sends_total",
+		Help: "SMS outbox send attempts, by outcome (sent, retry or failed).",
+	}, []string{"outcome"})
+}
+
+// Describe implements prometheus.Collector.
+func (w *OutboxWorker) Describe(ch chan<- *prometheus.Desc) {
+	w.sends.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (w *OutboxWorker) Collect(ch chan<- prometheus.Metric) {
+	w.sends.Collect(ch)
+}
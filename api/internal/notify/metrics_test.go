@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// claimedDeduper reports every number in claimed as already alerted.
+type claimedDeduper map[string]bool
+
+func (d claimedDeduper) Claim(_ context.Context, phone, _ string) (bool, error) {
+	return !d[phone], nil
+}
+
+func (claimedDeduper) Release(context.Context, string, string) error { return nil }
+
+func TestDispatchMetrics(t *testing.T) {
+	subs := staticSubscribers{
+		{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}},
+		{ID: "2", PhoneNumber: "+12025550102", Channels: []string{db.ChannelSMS, db.ChannelEmail}},
+		{ID: "3", PhoneNumber: "+12025550103", Channels: []string{db.ChannelSMS}},
+		{ID: "4", Email: "a@example.com", Channels: []string{db.ChannelEmail}},
+	}
+	send := notifierFunc(func(sub db.Subscription) (string, error) {
+		if sub.ID == "2" {
+			return "", errors.New("provider down")
+		}
+		return "msg-" + sub.ID, nil
+	})
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: send, db.ChannelEmail: send}, nil, nil, 2, 0, zap.NewNop(),
+		WithDeduper(claimedDeduper{"+12025550103": true}))
+
+	res, err := d.DispatchPackage(context.Background(), govinfo.Package{PackageID: "BILLS-1", CollectionCode: "BILLS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sent != 2 || res.Failed != 2 || res.Deduped != 1 {
+		t.Fatalf("result = %+v", res)
+	}
+
+	want := `
+# HELP notify_alerts_total Alerts handled by the dispatcher, by channel and outcome.
+# TYPE notify_alerts_total counter
+notify_alerts_total{channel="email",outcome="failed"} 1
+notify_alerts_total{channel="email",outcome="sent"} 1
+notify_alerts_total{channel="sms",outcome="deduped"} 1
+notify_alerts_total{channel="sms",outcome="failed"} 1
+notify_alerts_total{channel="sms",outcome="sent"} 1
+`
+	if err := testutil.CollectAndCompare(d, strings.NewReader(want), "notify_alerts_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(d, "notify_dispatch_duration_seconds"); n != 1 {
+		t.Errorf("dispatch duration series = %d, want 1", n)
+	}
+}
+
+func TestDispatchMetricsCountsQueuedSends(t *testing.T) {
+	store := &memOutbox{}
+	subs := staticSubscribers{
+		{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}},
+		{ID: "2", PhoneNumber: "+12025550102", Channels: []string{db.ChannelSMS}},
+	}
+	d := NewDispatcher(subs, map[string]Notifier{db.ChannelSMS: OutboxNotifier{Outbox: store}}, nil, nil, 1, 0, zap.NewNop())
+	if _, err := d.DispatchPackage(context.Background(), govinfo.Package{PackageID: "FR-1", CollectionCode: "FR"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+# HELP notify_alerts_total Alerts handled by the dispatcher, by channel and outcome.
+# TYPE notify_alerts_total counter
+notify_alerts_total{channel="sms",outcome="queued"} 2
+`
+	if err := testutil.CollectAndCompare(d, strings.NewReader(want), "notify_alerts_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOutboxMetrics(t *testing.T) {
+	store := &memOutbox{}
+	for _, phone := range []string{"+12025550101", "+12025550102", "+12025550103"} {
+		if err := store.Enqueue(context.Background(), db.OutboxMessage{PhoneNumber: phone, Body: "alert"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sender := smsFunc(func(to string) (string, error) {
+		switch to {
+		case "+12025550102":
+			return "", &SendError{Err: errors.New("invalid number"), Permanent: true}
+		case "+12025550103":
+			return "", errors.New("timeout")
+		}
+		return "SM1", nil
+	})
+	w := NewOutboxWorker(store, sender, 100, 3, zap.NewNop())
+	for range 3 {
+		if _, err := w.SendNext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `
+# HELP notify_outbox_sends_total SMS outbox send attempts, by outcome (sent, retry or failed).
+# TYPE notify_outbox_sends_total counter
+notify_outbox_sends_total{outcome="failed"} 1
+notify_outbox_sends_total{outcome="retry"} 1
+notify_outbox_sends_total{outcome="sent"} 1
+`
+	if err := testutil.CollectAndCompare(w, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// Notifier alerts one subscription about a package over a single channel,
+// returning the provider's ID for the message when it issues one. The
+// dispatcher holds one per channel name (db.ChannelSMS and so on).
+type Notifier interface {
+	Notify(ctx context.Context, sub db.Subscription, pkg govinfo.Package) (string, error)
+}
+
+// SMSNotifier texts FormatPackageAlert to the subscription's phone number.
+type SMSNotifier struct {
+	Sender SMSSender
+}
+
+func (n SMSNotifier) Notify(ctx context.Context, sub db.Subscription, pkg govinfo.Package) (string, error) {
+	return n.Sender.SendSMS(ctx, sub.PhoneNumber, FormatPackageAlert(pkg))
+}
+
+// EmailNotifier emails FormatPackageEmail to the subscription's address.
+type EmailNotifier struct {
+	Sender EmailSender
+}
+
+func (n EmailNotifier) Notify(ctx context.Context, sub db.Subscription, pkg govinfo.Package) (string, error) {
+	subject, body := FormatPackageEmail(pkg)
+	return n.Sender.SendEmail(ctx, sub.Email, subject, body)
+}
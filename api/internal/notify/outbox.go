@@ -0,0 +1,248 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+const (
+	defaultOutboxRate     = 1
+	defaultOutboxAttempts = 5
+
+	// outboxLease hides a claimed message from other workers while it is
+	// being sent. It must outlast a send, Twilio's own retries included.
+	outboxLease = 2 * time.Minute
+	// outboxIdle is how long the worker waits when nothing is due.
+	outboxIdle = time.Second
+
+	outboxRetryBase = 30 * time.Second
+	outboxRetryMax  = 30 * time.Minute
+)
+
+// OutboxStore is the durable SMS queue. *db.OutboxRepo satisfies it.
+type OutboxStore interface {
+	Enqueue(ctx context.Context, msg db.OutboxMessage) error
+	Claim(ctx context.Context, lease time.Duration) (db.OutboxMessage, bool, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkRetry(ctx context.Context, id int64, sendErr string, next time.Time) error
+	MarkFailed(ctx context.Context, id int64, sendErr string) error
+}
+
+// OutboxNotifier queues FormatPackageAlert for the subscription's phone
+// number instead of texting it straight away; an OutboxWorker sends it.
+type OutboxNotifier struct {
+	Outbox OutboxStore
+}
+
+func (n OutboxNotifier) Notify(ctx context.Context, sub db.Subscription, pkg govinfo.Package) (string, error) {
+	return "", n.Outbox.Enqueue(ctx, db.OutboxMessage{
+		SubscriptionID: sub.ID,
+		PhoneNumber:    sub.PhoneNumber,
+		Body:           FormatPackageAlert(pkg),
+		PackageID:      pkg.PackageID,
+		CollectionCode: pkg.CollectionCode,
+	})
+}
+
+func (OutboxNotifier) queues() {}
+
+// OutboxWorker drains the SMS outbox one message at a time, oldest first,
+// at a bounded rate. Transient failures are retried with exponential
+// backoff; permanent ones, and messages out of attempts, are marked
+// failed. Messages left pending by a restart are picked up again.
+//
+// It is also a prometheus.Collector for its send outcomes.
+type OutboxWorker struct {
+	store       OutboxStore
+	sender      SMSSender
+	limiter     *rate.Limiter
+	maxAttempts int
+	audit       AuditLog
+	logger      *zap.Logger
+	sends       *prometheus.CounterVec
+
+	mu sync.Mutex
+	// started is set by Run, and closed by Close; a Run that starts after
+	// Close returns at once, so nothing touches the store past shutdown.
+	started, closed bool
+	// stopped is closed when Run returns, or by Close if Run never
+	// started.
+	stopped chan struct{}
+
+	now func() time.Time
+}
+
+// OutboxOption customises an OutboxWorker built by NewOutboxWorker.
+type OutboxOption func(*OutboxWorker)
+
+// WithOutboxAuditLog records the final outcome of each alert text to
+// audit: sent, or failed once it won't be retried.
+func WithOutboxAuditLog(audit AuditLog) OutboxOption {
+	return func(w *OutboxWorker) { w.audit = audit }
+}
+
+// NewOutboxWorker returns a worker sending at most rps messages a second
+// and trying each at most maxAttempts times.
+func NewOutboxWorker(store OutboxStore, sender SMSSender, rps float64, maxAttempts int, logger *zap.Logger, opts ...OutboxOption) *OutboxWorker {
+	if rps <= 0 {
+		rps = defaultOutboxRate
+	}
+	if maxAttempts < 1 {
+		maxAttempts = defaultOutboxAttempts
+	}
+	w := &OutboxWorker{
+		store:       store,
+		sender:      sender,
+		limiter:     rate.NewLimiter(rate.Limit(rps), 1),
+		maxAttempts: maxAttempts,
+		logger:      logger,
+		sends:       newOutboxSends(),
+		stopped:     make(chan struct{}),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run drains the outbox until ctx is cancelled. It must be called at most
+// once; use Close to wait for it to return. After Close it returns without
+// sending.
+func (w *OutboxWorker) Run(ctx context.Context) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.started = true
+	w.mu.Unlock()
+	defer close(w.stopped)
+
+	for {
+		if err := w.limiter.Wait(ctx); err != nil {
+			return
+		}
+		sent, err := w.SendNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("sms outbox drain failed", zap.Error(err))
+		}
+		if !sent && !sleep(ctx, outboxIdle) {
+			return
+		}
+	}
+}
+
+// Close waits for Run to return after its context has been cancelled,
+// giving up when ctx ends. If Run hasn't started it returns at once, and
+// a later Run does nothing.
+func (w *OutboxWorker) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.started && !w.closed {
+		close(w.stopped)
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	select {
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notify: close outbox worker: %w", ctx.Err())
+	}
+}
+
+// SendNext claims and sends the next due message. It reports whether a
+// message was attempted; a failed send is recorded, not returned.
+func (w *OutboxWorker) SendNext(ctx context.Context) (bool, error) {
+	msg, ok, err := w.store.Claim(ctx, outboxLease)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// The send and its bookkeeping finish even if ctx ends meanwhile, so
+	// a text that went out is never left pending to be sent again.
+	ctx = context.WithoutCancel(ctx)
+	sid, sendErr := w.sender.SendSMS(ctx, msg.PhoneNumber, msg.Body)
+	attempts := msg.Attempts + 1
+
+	switch {
+	case sendErr == nil:
+		w.sends.WithLabelValues(outcomeSent).Inc()
+		w.record(ctx, msg, db.NotificationSent, sid, "")
+		return true, w.store.MarkSent(ctx, msg.ID)
+	case IsPermanent(sendErr) || attempts >= w.maxAttempts:
+		w.sends.WithLabelValues(outcomeFailed).Inc()
+		w.record(ctx, msg, db.NotificationFailed, "", sendErr.Error())
+		w.logger.Warn("sms outbox message failed",
+			zap.Int64("outbox_id", msg.ID),
+			zap.String("subscription_id", msg.SubscriptionID),
+			zap.Int("attempts", attempts),
+			zap.Bool("permanent", IsPermanent(sendErr)),
+			zap.Error(sendErr))
+		return true, w.store.MarkFailed(ctx, msg.ID, sendErr.Error())
+	default:
+		w.sends.WithLabelValues(outcomeRetry).Inc()
+		next := w.now().Add(outboxBackoff(attempts))
+		w.logger.Info("sms outbox message will be retried",
+			zap.Int64("outbox_id", msg.ID),
+			zap.Int("attempts", attempts),
+			zap.Time("next_attempt_at", next),
+			zap.Error(sendErr))
+		return true, w.store.MarkRetry(ctx, msg.ID, sendErr.Error(), next)
+	}
+}
+
+// record writes an audit row for an alert text. Texts without a
+// subscription or package weren't queued by the dispatcher and aren't
+// audited.
+func (w *OutboxWorker) record(ctx context.Context, msg db.OutboxMessage, status, sid, sendErr string) {
+	if w.audit == nil || msg.SubscriptionID == "" || msg.PackageID == "" {
+		return
+	}
+	err := w.audit.Record(ctx, db.Notification{
+		SubscriptionID:    msg.SubscriptionID,
+		Channel:           db.ChannelSMS,
+		PackageID:         msg.PackageID,
+		CollectionCode:    msg.CollectionCode,
+		Status:            status,
+		ProviderMessageID: sid,
+		Error:             sendErr,
+	})
+	if err != nil {
+		w.logger.Error("notification audit write failed",
+			zap.Int64("outbox_id", msg.ID),
+			zap.String("subscription_id", msg.SubscriptionID),
+			zap.Error(err))
+	}
+}
+
+// outboxBackoff is the wait after the given number of failed attempts.
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxRetryBase << (attempts - 1)
+	if d <= 0 || d > outboxRetryMax {
+		return outboxRetryMax
+	}
+	return d
+}
+
+// sleep waits for d, returning false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+)
+
+func TestOutboxWorkerCloseBeforeRun(t *testing.T) {
+	store := &memOutbox{}
+	w := NewOutboxWorker(store, smsFunc(func(string) (string, error) { return "SM1", nil }), 100, 1, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Close(ctx); err != nil {
+		t.Fatalf("Close before Run = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run after Close did not return")
+	}
+	if store.claimed != 0 {
+		t.Errorf("Run after Close claimed %d messages, want 0", store.claimed)
+	}
+}
+
+func TestOutboxWorkerCloseWaitsForRun(t *testing.T) {
+	w := NewOutboxWorker(&memOutbox{}, smsFunc(func(string) (string, error) { return "SM1", nil }), 100, 1, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Run(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for !w.isStarted() {
+		if time.Now().After(deadline) {
+			t.Fatal("worker never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	closeCtx, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if err := w.Close(closeCtx); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	select {
+	case <-w.stopped:
+	default:
+		t.Fatal("Close returned before Run stopped")
+	}
+}
+
+func (w *OutboxWorker) isStarted() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.started
+}
+
+// smsFunc adapts a function to SMSSender.
+type smsFunc func(to string) (string, error)
+
+func (f smsFunc) SendSMS(_ context.Context, to, _ string) (string, error) { return f(to) }
+
+// memOutbox is an in-memory OutboxStore. Claim hands out pending
+// messages in order, ignoring leases and retry times.
+type memOutbox struct {
+	msgs    []db.OutboxMessage
+	claimed int
+	sent    []int64
+	retried []int64
+	failed  []int64
+}
+
+func (o *memOutbox) Enqueue(_ context.Context, msg db.OutboxMessage) error {
+	msg.ID = int64(len(o.msgs) + 1)
+	o.msgs = append(o.msgs, msg)
+	return nil
+}
+
+func (o *memOutbox) Claim(context.Context, time.Duration) (db.OutboxMessage, bool, error) {
+	if o.claimed >= len(o.msgs) {
+		return db.OutboxMessage{}, false, nil
+	}
+	o.claimed++
+	return o.msgs[o.claimed-1], true, nil
+}
+
+func (o *memOutbox) MarkSent(_ context.Context, id int64) error {
+	o.sent = append(o.sent, id)
+	return nil
+}
+
+func (o *memOutbox) MarkRetry(_ context.Context, id int64, _ string, _ time.Time) error {
+	o.retried = append(o.retried, id)
+	return nil
+}
+
+func (o *memOutbox) MarkFailed(_ context.Context, id int64, _ string) error {
+	o.failed = append(o.failed, id)
+	return nil
+}
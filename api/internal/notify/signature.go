@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// TwilioSignatureHeader carries Twilio's signature on webhook requests.
+const TwilioSignatureHeader = "X-Twilio-Signature"
+
+// ValidTwilioSignature reports whether signature is Twilio's signature for
+// a webhook POST to fullURL with form params, signed with authToken. See
+// https://www.twilio.com/docs/usage/webhooks/webhooks-security.
+func ValidTwilioSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	if authToken == "" || signature == "" {
+		return false
+	}
+	want := twilioSignature(authToken, fullURL, params)
+	return hmac.Equal([]byte(want), []byte(signature))
+}
+
+// twilioSignature is base64(HMAC-SHA1(url + each sorted key and value)).
+func twilioSignature(authToken, fullURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
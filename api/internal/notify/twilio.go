@@ -0,0 +1,220 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/httplog"
+)
+
+const (
+	twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+	defaultTwilioAttempts = 3
+	twilioRetryBackoff    = 500 * time.Millisecond
+)
+
+// SMSSender sends a single text message, returning the provider's ID
+// for it.
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, body string) (string, error)
+}
+
+// TwilioSender sends SMS through the Twilio Messages API.
+type TwilioSender struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	httpClient *http.Client
+
+	maxAttempts int
+	backoff     time.Duration
+}
+
+var _ SMSSender = (*TwilioSender)(nil)
+
+// NewTwilioSender builds a sender from the Twilio credentials, from number
+// and retry settings in cfg. With cfg.HTTPDebugLog, each call to Twilio is
+// logged to logger at debug level.
+func NewTwilioSender(cfg *config.Config, logger *zap.Logger) *TwilioSender {
+	attempts := cfg.TwilioMaxAttempts
+	if attempts < 1 {
+		attempts = defaultTwilioAttempts
+	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	if cfg.HTTPDebugLog {
+		httpClient.Transport = httplog.Wrap(nil, logger)
+	}
+	return &TwilioSender{
+		accountSID:  cfg.TwilioSID,
+		authToken:   cfg.TwilioToken,
+		from:        cfg.TwilioFrom,
+		baseURL:     twilioBaseURL,
+		httpClient:  httpClient,
+		maxAttempts: attempts,
+		backoff:     twilioRetryBackoff,
+	}
+}
+
+// SendError is returned by SendSMS when a message could not be sent.
+// Permanent failures, such as an invalid number, will fail again if
+// retried; transient ones may succeed if the caller re-queues them.
+type SendError struct {
+	Err       error
+	Permanent bool
+}
+
+func (e *SendError) Error() string { return e.Err.Error() }
+func (e *SendError) Unwrap() error { return e.Err }
+
+// IsPermanent reports whether err is a send failure that retrying won't fix.
+func IsPermanent(err error) bool {
+	var se *SendError
+	return errors.As(err, &se) && se.Permanent
+}
+
+// TwilioError is a non-2xx response from Twilio. Code is Twilio's own error
+// code (see https://www.twilio.com/docs/api/errors) and may be zero if the
+// body could not be parsed.
+type TwilioError struct {
+	StatusCode int
+	Code       int
+	Message    string
+	MoreInfo   string
+}
+
+func (e *TwilioError) Error() string {
+	return fmt.Sprintf("twilio: status %d, code %d: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// SendSMS sends body to the E.164 number to. 5xx and 429 responses, and
+// connections that failed before the request was written, are retried
+// with exponential backoff up to the configured attempts. Other network
+// errors are not retried here: the message may already have been
+// accepted, and Twilio's Messages API has no idempotency key to dedupe a
+// second POST. They are returned as transient so the caller can decide.
+// On success it returns Twilio's message SID.
+func (s *TwilioSender) SendSMS(ctx context.Context, to, body string) (string, error) {
+	var (
+		sid string
+		err error
+	)
+	for attempt := 0; attempt < s.maxAttempts; attempt++ {
+		if attempt > 0 {
+			t := time.NewTimer(s.backoff << (attempt - 1))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return "", &SendError{Err: fmt.Errorf("twilio: send to %s: %w (last error: %v)", to, ctx.Err(), err)}
+			case <-t.C:
+			}
+		}
+
+		var retry bool
+		sid, retry, err = s.send(ctx, to, body)
+		if err == nil {
+			return sid, nil
+		}
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return "", err
+}
+
+// send makes one attempt and reports whether a failure is safe to retry.
+func (s *TwilioSender) send(ctx context.Context, to, body string) (sid string, retry bool, err error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", s.from)
+	form.Set("Body", body)
+
+	endpoint := s.baseURL + "/Accounts/" + url.PathEscape(s.accountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", false, &SendError{Err: fmt.Errorf("twilio: build request: %w", err), Permanent: true}
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		dialFailed := errors.As(err, &opErr) && opErr.Op == "dial"
+		return "", dialFailed, &SendError{Err: fmt.Errorf("twilio: send to %s: %w", to, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		// Twilio has accepted the message, so a body we can't read only
+		// costs us the SID.
+		var msg struct {
+			SID string `json:"sid"`
+		}
+		json.NewDecoder(resp.Body).Decode(&msg)
+		io.Copy(io.Discard, resp.Body)
+		return msg.SID, false, nil
+	}
+
+	twErr := &TwilioError{StatusCode: resp.StatusCode}
+	var payload struct {
+		Code     int    `json:"code"`
+		Message  string `json:"message"`
+		MoreInfo string `json:"more_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err == nil {
+		twErr.Code = payload.Code
+		twErr.Message = payload.Message
+		twErr.MoreInfo = payload.MoreInfo
+	} else {
+		twErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	transient := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return "", transient, &SendError{Err: twErr, Permanent: !transient}
+}
+
+// CheckAccount fetches the Twilio account and fails unless it is active,
+// so suspended or closed accounts and bad credentials show up before an
+// alert is lost to them.
+func (s *TwilioSender) CheckAccount(ctx context.Context) error {
+	endpoint := s.baseURL + "/Accounts/" + url.PathEscape(s.accountSID) + ".json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("twilio: build request: %w", err)
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio: fetch account: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return &TwilioError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+
+	var account struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return fmt.Errorf("twilio: decode account: %w", err)
+	}
+	if account.Status != "active" {
+		return fmt.Errorf("twilio: account is %s", account.Status)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestTwilio(t *testing.T, h http.HandlerFunc) *TwilioSender {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &TwilioSender{
+		accountSID:  "AC123",
+		authToken:   "token",
+		from:        "+12025550100",
+		baseURL:     srv.URL,
+		httpClient:  srv.Client(),
+		maxAttempts: 3,
+		backoff:     time.Millisecond,
+	}
+}
+
+func TestTwilioSendSMS(t *testing.T) {
+	s := newTestTwilio(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Accounts/AC123/Messages.json" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "AC123" || pass != "token" {
+			t.Errorf("basic auth = %s:%s", user, pass)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("To") != "+12025550101" || r.PostForm.Get("From") != "+12025550100" || r.PostForm.Get("Body") != "hi" {
+			t.Errorf("form = %v", r.PostForm)
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"sid":"SM1"}`)
+	})
+
+	sid, err := s.SendSMS(context.Background(), "+12025550101", "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sid != "SM1" {
+		t.Errorf("sid = %q, want SM1", sid)
+	}
+}
+
+func TestTwilioSendSMSPermanentError(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestTwilio(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"code":21211,"message":"Invalid 'To' Phone Number","more_info":"https://www.twilio.com/docs/errors/21211"}`)
+	})
+
+	_, err := s.SendSMS(context.Background(), "+1", "hi")
+	var twErr *TwilioError
+	if !errors.As(err, &twErr) || twErr.StatusCode != http.StatusBadRequest || twErr.Code != 21211 {
+		t.Fatalf("err = %v, want a 400 TwilioError with code 21211", err)
+	}
+	if twErr.MoreInfo != "https://www.twilio.com/docs/errors/21211" {
+		t.Errorf("MoreInfo = %q", twErr.MoreInfo)
+	}
+	if !IsPermanent(err) {
+		t.Error("a 400 is not reported as permanent")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Twilio called %d times, want 1", n)
+	}
+}
+
+func TestTwilioSendSMSUnparsableError(t *testing.T) {
+	s := newTestTwilio(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "<html>not found</html>")
+	})
+
+	_, err := s.SendSMS(context.Background(), "+12025550101", "hi")
+	var twErr *TwilioError
+	if !errors.As(err, &twErr) || twErr.StatusCode != http.StatusNotFound || twErr.Code != 0 {
+		t.Fatalf("err = %v, want a 404 TwilioError with no code", err)
+	}
+	if twErr.Message != http.StatusText(http.StatusNotFound) {
+		t.Errorf("Message = %q, want the status text", twErr.Message)
+	}
+}
+
+func TestTwilioSendSMSRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestTwilio(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"sid":"SM2"}`)
+	})
+
+	sid, err := s.SendSMS(context.Background(), "+12025550101", "hi")
+	if err != nil || sid != "SM2" {
+		t.Fatalf("SendSMS = %q, %v; want SM2 after retries", sid, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Twilio called %d times, want 3", n)
+	}
+}
+
+func TestTwilioSendSMSGivesUpAsTransient(t *testing.T) {
+	s := newTestTwilio(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := s.SendSMS(context.Background(), "+12025550101", "hi")
+	var twErr *TwilioError
+	if !errors.As(err, &twErr) || twErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 TwilioError", err)
+	}
+	if IsPermanent(err) {
+		t.Error("a 429 is reported as permanent")
+	}
+}
+
+func TestTwilioSendSMSRespectsDeadline(t *testing.T) {
+	s := newTestTwilio(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	s.backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := s.SendSMS(ctx, "+12025550101", "hi")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if IsPermanent(err) {
+		t.Error("a deadline is reported as permanent")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SendSMS took %s past a 50ms deadline", elapsed)
+	}
+}
+
+func TestTwilioSendSMSRetriesRefusedConnections(t *testing.T) {
+	s := newTestTwilio(t, func(http.ResponseWriter, *http.Request) {})
+	srv := httptest.NewServer(http.NotFoundHandler())
+	s.baseURL = srv.URL
+	srv.Close()
+	var calls atomic.Int32
+	s.httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	_, err := s.SendSMS(context.Background(), "+12025550101", "hi")
+	if err == nil {
+		t.Fatal("want an error from a closed server")
+	}
+	var se *SendError
+	if !errors.As(err, &se) || se.Permanent {
+		t.Errorf("err = %v, want a transient SendError", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("tried %d times, want 3", n)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
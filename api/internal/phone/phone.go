@@ -0,0 +1,51 @@
+// Package phone normalizes subscriber phone numbers to E.164.
+//
+// Only North American (NANP, +1) numbers are accepted, since those are the
+// only ones our Twilio sender is provisioned for.
+package phone
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalid is returned for input that is not a valid NANP number.
+var ErrInvalid = errors.New("phone: invalid US phone number")
+
+// nanp matches a ten-digit number whose area code and exchange both start
+// with 2-9, as the numbering plan requires.
+var nanp = regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`)
+
+// Normalize returns s in E.164 form (+1XXXXXXXXXX). It accepts common
+// formatting such as "(202) 555-0143", "202.555.0143" and "+1 202 555 0143".
+func Normalize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ErrInvalid
+	}
+
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	digits := b.String()
+	if len(digits) == 11 && digits[0] == '1' {
+		digits = digits[1:]
+	} else if strings.HasPrefix(s, "+") {
+		// A leading + with anything other than country code 1.
+		return "", ErrInvalid
+	}
+	if !nanp.MatchString(digits) {
+		return "", ErrInvalid
+	}
+	return "+1" + digits, nil
+}
@@ -0,0 +1,269 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+)
+
+// flakySource serves packages, keyed by collection code, as GovInfo's
+// collection update listing, except that collections in fail get a 500
+// and collections in hang block until the request ends or the test does.
+type flakySource struct {
+	mu       sync.Mutex
+	released chan struct{}
+	packages map[string][]govinfo.Package
+	fail     map[string]bool
+	hang     map[string]bool
+	hits     map[string]int
+}
+
+func (s *flakySource) setFailing(code string, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail[code] = failing
+}
+
+func (s *flakySource) hitCount(code string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[code]
+}
+
+func newFlakySource(t *testing.T, packages map[string][]govinfo.Package) (*flakySource, *govinfo.Client) {
+	t.Helper()
+	s := &flakySource{released: make(chan struct{}), packages: packages, fail: map[string]bool{}, hang: map[string]bool{}, hits: map[string]int{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if len(parts) < 2 || parts[0] != "collections" {
+			http.NotFound(w, r)
+			return
+		}
+		code := parts[1]
+		s.mu.Lock()
+		s.hits[code]++
+		fail, hang, pkgs := s.fail[code], s.hang[code], s.packages[code]
+		s.mu.Unlock()
+		switch {
+		case hang:
+			select {
+			case <-r.Context().Done():
+			case <-s.released:
+			}
+			return
+		case fail:
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(govinfo.PackageList{Count: len(pkgs), Packages: pkgs})
+	}))
+	t.Cleanup(srv.Close)
+	// A shared fetch outlives the poll that started it, so free any
+	// hanging handler before closing the server.
+	t.Cleanup(func() { close(s.released) })
+
+	c, err := govinfo.NewClient("test-key", srv.Client(), govinfo.WithBaseURL(srv.URL), govinfo.WithRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, c
+}
+
+// panickingDispatcher panics on the package with ID panicOn and records
+// the rest.
+type panickingDispatcher struct {
+	recordingDispatcher
+	panicOn string
+}
+
+func (d *panickingDispatcher) DispatchPackage(ctx context.Context, pkg govinfo.Package) (notify.DispatchResult, error) {
+	if pkg.PackageID == d.panicOn {
+		panic("dispatch exploded")
+	}
+	return d.recordingDispatcher.DispatchPackage(ctx, pkg)
+}
+
+func (d *panickingDispatcher) dispatched() map[string]bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make(map[string]bool, len(d.pkgs))
+	for _, pkg := range d.pkgs {
+		ids[pkg.PackageID] = true
+	}
+	return ids
+}
+
+func resultsByCode(results []Result) map[string]Result {
+	m := make(map[string]Result, len(results))
+	for _, r := range results {
+		m[r.CollectionCode] = r
+	}
+	return m
+}
+
+func TestPollAllIsolatesFailingCollections(t *testing.T) {
+	watermark := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := watermark.Add(time.Hour)
+	source, client := newFlakySource(t, map[string][]govinfo.Package{
+		"BILLS": {testPackage("BILLS-1", after)},
+		"CRPT":  {testPackage("CRPT-1", after)},
+		"FR":    {testPackage("FR-1", after)},
+		"PLAW":  {testPackage("PLAW-1", after)},
+		"BOOM":  {testPackage("BOOM-1", after)},
+	})
+	source.setFailing("CRPT", true)
+	source.hang["PLAW"] = true
+
+	codes := []string{"BILLS", "CRPT", "PLAW", "BOOM", "FR"}
+	watermarks := map[string]time.Time{}
+	for _, code := range codes {
+		watermarks[code] = watermark
+	}
+	state := newMemState(watermarks)
+	dispatcher := &panickingDispatcher{panicOn: "BOOM-1"}
+	core, logs := observer.New(zapcore.InfoLevel)
+	p := New(client, staticCollections(codes), state, dispatcher, nil, nil, time.Minute, zap.New(core),
+		WithCollectionTimeout(100*time.Millisecond))
+
+	start := time.Now()
+	results, err := p.PollAll(context.Background())
+	if err != nil {
+		t.Fatalf("PollAll = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("PollAll took %v; the hanging collection wasn't cut off", elapsed)
+	}
+	if len(results) != len(codes) {
+		t.Fatalf("got %d results, want %d", len(results), len(codes))
+	}
+
+	byCode := resultsByCode(results)
+	for _, code := range []string{"BILLS", "FR"} {
+		if r := byCode[code]; r.Err != nil || r.Found != 1 {
+			t.Errorf("%s result = %+v, want 1 found and no error", code, r)
+		}
+		if !state.watermarks[code].Equal(after) {
+			t.Errorf("%s watermark = %v, want %v", code, state.watermarks[code], after)
+		}
+	}
+	for _, code := range []string{"CRPT", "PLAW", "BOOM"} {
+		if r := byCode[code]; r.Err == nil {
+			t.Errorf("%s result has no error", code)
+		}
+		if !state.watermarks[code].Equal(watermark) {
+			t.Errorf("%s watermark moved to %v after a failed poll", code, state.watermarks[code])
+		}
+	}
+	if err := byCode["BOOM"].Err; err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("BOOM error = %v, want a recovered panic", err)
+	}
+
+	got := dispatcher.dispatched()
+	if !got["BILLS-1"] || !got["FR-1"] || len(got) != 2 {
+		t.Errorf("dispatched %v, want BILLS-1 and FR-1", got)
+	}
+	if n := logs.FilterMessage("poll collection panicked").Len(); n != 1 {
+		t.Errorf("logged %d panics, want 1", n)
+	}
+	if n := logs.FilterMessage("poll collection failed").Len(); n != 3 {
+		t.Errorf("logged %d failed collections, want 3", n)
+	}
+
+	// The panic released the polling token, so later polls still run.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := p.PollCollection(ctx, "BILLS"); err != nil {
+		t.Errorf("PollCollection after a panic = %v", err)
+	}
+}
+
+func TestPollAllBacksOffFailingCollection(t *testing.T) {
+	watermark := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	source, client := newFlakySource(t, nil)
+	source.setFailing("CRPT", true)
+
+	state := newMemState(map[string]time.Time{"BILLS": watermark, "CRPT": watermark})
+	p := New(client, staticCollections{"BILLS", "CRPT"}, state, &recordingDispatcher{}, nil, nil, time.Minute, zap.NewNop())
+	var now atomic.Int64
+	now.Store(watermark.UnixNano())
+	p.now = func() time.Time { return time.Unix(0, now.Load()) }
+	advance := func(d time.Duration) { now.Add(int64(d)) }
+
+	poll := func() map[string]Result {
+		t.Helper()
+		results, err := p.PollAll(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resultsByCode(results)
+	}
+
+	// The first failures are retried at once.
+	for i := range backoffAfter {
+		if r := poll()["CRPT"]; r.Skipped || r.Err == nil {
+			t.Fatalf("poll %d: CRPT = %+v, want a failed attempt", i+1, r)
+		}
+	}
+	if n := source.hitCount("CRPT"); n != backoffAfter {
+		t.Fatalf("CRPT fetched %d times, want %d", n, backoffAfter)
+	}
+
+	// Then it sits out one interval, doubling after each further failure,
+	// while the healthy collection keeps polling.
+	for _, backoff := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		hits := source.hitCount("CRPT")
+		advance(backoff - time.Second)
+		r := poll()
+		if !r["CRPT"].Skipped {
+			t.Errorf("CRPT polled %v into a %v backoff", backoff-time.Second, backoff)
+		}
+		if r["BILLS"].Skipped || r["BILLS"].Err != nil {
+			t.Errorf("BILLS = %+v during CRPT's backoff", r["BILLS"])
+		}
+		if n := source.hitCount("CRPT"); n != hits {
+			t.Errorf("CRPT fetched during backoff")
+		}
+
+		advance(time.Second)
+		if r := poll()["CRPT"]; r.Skipped || r.Err == nil {
+			t.Errorf("CRPT = %+v after a %v backoff, want a failed attempt", r, backoff)
+		}
+	}
+
+	// A success clears the streak.
+	source.setFailing("CRPT", false)
+	advance(8 * time.Minute)
+	if r := poll()["CRPT"]; r.Skipped || r.Err != nil {
+		t.Fatalf("CRPT = %+v after recovering", r)
+	}
+	source.setFailing("CRPT", true)
+	for i := range backoffAfter {
+		if r := poll()["CRPT"]; r.Skipped {
+			t.Errorf("poll %d after recovery skipped; the old streak wasn't cleared", i+1)
+		}
+	}
+}
+
+func TestPollAllShutdownDoesNotCountAsFailure(t *testing.T) {
+	p := New(nil, staticCollections{"BILLS"}, newMemState(nil), &recordingDispatcher{}, nil, nil, time.Minute, zap.NewNop())
+	for range backoffAfter + 1 {
+		p.recordOutcome("BILLS", context.Canceled, true)
+	}
+	if until, ok := p.backedOff("BILLS"); ok {
+		t.Errorf("backed off until %v after polls cut short by shutdown", until)
+	}
+}
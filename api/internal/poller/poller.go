@@ -0,0 +1,575 @@
+// Package poller watches subscribed GovInfo collections for new packages
+// and hands them to the notification dispatcher.
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+)
+
+const (
+	defaultInterval          = 15 * time.Minute
+	defaultCollectionTimeout = 5 * time.Minute
+
+	// A collection that fails backoffAfter polls in a row sits out later
+	// polls for a doubling multiple of the interval, up to maxBackoff.
+	backoffAfter = 2
+	maxBackoff   = 6 * time.Hour
+
+	// maxParallelPolls caps how many collections PollAll polls at once.
+	maxParallelPolls = 4
+)
+
+// PackageSource lists packages updated in a collection since a time.
+// *govinfo.Client satisfies it.
+type PackageSource interface {
+	NewCollectionPaginator(code string, since time.Time, pageSize int) *govinfo.Paginator
+}
+
+// CollectionLister returns the collections that have subscribers.
+type CollectionLister interface {
+	ListCollections(ctx context.Context) ([]string, error)
+}
+
+// Collections merges several listers, such as SMS subscriptions and
+// webhooks, into one that returns each code once.
+func Collections(listers ...CollectionLister) CollectionLister {
+	return collectionUnion(listers)
+}
+
+type collectionUnion []CollectionLister
+
+func (u collectionUnion) ListCollections(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, l := range u {
+		cs, err := l.ListCollections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cs {
+			if !seen[c] {
+				seen[c] = true
+				codes = append(codes, c)
+			}
+		}
+	}
+	return codes, nil
+}
+
+// StateStore persists the per-collection watermark and the outcome of
+// each poll.
+type StateStore interface {
+	Watermark(ctx context.Context, code string) (time.Time, bool, error)
+	SetWatermark(ctx context.Context, code string, watermark time.Time) error
+	RecordPoll(ctx context.Context, code string, found int, pollErr error) error
+}
+
+// PackageDispatcher delivers alerts for a package.
+type PackageDispatcher interface {
+	DispatchPackage(ctx context.Context, pkg govinfo.Package) (notify.DispatchResult, error)
+}
+
+// PackageStore keeps local copies of the packages the poller sees.
+// *db.PackageRepo satisfies it.
+type PackageStore interface {
+	Upsert(ctx context.Context, pkg db.Package) error
+}
+
+// PackagePublisher announces newly found packages to live listeners.
+// *events.Hub satisfies it.
+type PackagePublisher interface {
+	Publish(pkg govinfo.Package) (dropped int)
+}
+
+// Poller periodically checks each subscribed collection for packages
+// modified after its stored watermark. It is also a prometheus.Collector
+// for the packages it finds.
+type Poller struct {
+	source     PackageSource
+	subs       CollectionLister
+	state      StateStore
+	dispatcher PackageDispatcher
+	store      PackageStore
+	events     PackagePublisher
+	logger     *zap.Logger
+	// detected counts dispatched packages per collection.
+	detected *prometheus.CounterVec
+
+	collectionTimeout time.Duration
+	// enabled, when non-nil, is the only collections PollAll polls.
+	enabled map[string]bool
+	// failures tracks collections that keep failing; only PollAll reads
+	// or writes it, under mu.
+	failures map[string]collectionFailures
+
+	mu sync.Mutex
+	// polling holds a token per collection while PollCollection runs, so
+	// a manual poll can't race the scheduled one over the same watermark.
+	polling  map[string]chan struct{}
+	interval time.Duration
+	// reset wakes Run when SetInterval changes the interval.
+	reset chan struct{}
+	// started is set by Run, and closed by Close; a Run that starts after
+	// Close returns at once, so nothing runs past shutdown.
+	started, closed bool
+	// stopped is closed when Run returns, or by Close if Run never
+	// started.
+	stopped chan struct{}
+	// beat is when Run last showed progress: a cycle starting or a
+	// collection finishing.
+	beat time.Time
+
+	now func() time.Time
+}
+
+type collectionFailures struct {
+	count int
+	until time.Time
+}
+
+// Option customises a Poller built by New.
+type Option func(*Poller)
+
+// WithCollectionTimeout bounds each collection's poll within PollAll.
+// Zero or less keeps the default.
+func WithCollectionTimeout(d time.Duration) Option {
+	return func(p *Poller) {
+		if d > 0 {
+			p.collectionTimeout = d
+		}
+	}
+}
+
+// WithEnabledCollections makes PollAll skip subscribed collections not
+// in codes. An empty codes polls them all.
+func WithEnabledCollections(codes []string) Option {
+	return func(p *Poller) {
+		if len(codes) == 0 {
+			return
+		}
+		p.enabled = make(map[string]bool, len(codes))
+		for _, code := range codes {
+			p.enabled[code] = true
+		}
+	}
+}
+
+// New returns a poller. A nil store skips keeping local copies of the
+// packages it finds, and a nil events skips announcing them.
+func New(source PackageSource, subs CollectionLister, state StateStore, dispatcher PackageDispatcher, store PackageStore, events PackagePublisher, interval time.Duration, logger *zap.Logger, opts ...Option) *Poller {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	p := &Poller{
+		source:     source,
+		subs:       subs,
+		state:      state,
+		dispatcher: dispatcher,
+		store:      store,
+		events:     events,
+		interval:   interval,
+		reset:      make(chan struct{}, 1),
+		polling:    make(map[string]chan struct{}),
+		stopped:    make(chan struct{}),
+		logger:     logger,
+		now:        time.Now,
+		detected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "poller_packages_detected_total",
+			Help: "New packages found and dispatched, by collection.",
+		}, []string{"collection"}),
+
+		collectionTimeout: defaultCollectionTimeout,
+		failures:          make(map[string]collectionFailures),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Run polls immediately and then every interval until ctx is cancelled.
+// It must be called at most once; use Close to wait for it to return.
+// After Close it returns without polling.
+func (p *Poller) Run(ctx context.Context) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.started = true
+	p.beat = p.now()
+	p.mu.Unlock()
+	defer close(p.stopped)
+
+	interval := p.Interval()
+	p.logger.Info("Poller started", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.heartbeat()
+		p.PollAll(ctx) // failures are logged per collection
+
+		if !p.waitTick(ctx, ticker) {
+			p.logger.Info("Poller stopped")
+			return
+		}
+	}
+}
+
+// waitTick blocks until the next tick, resetting the ticker whenever the
+// interval changes. It returns false once ctx is cancelled.
+func (p *Poller) waitTick(ctx context.Context, ticker *time.Ticker) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-p.reset:
+			ticker.Reset(p.Interval())
+		case <-ticker.C:
+			return true
+		}
+	}
+}
+
+// Close waits for Run to return after its context has been cancelled,
+// giving up when ctx ends. If Run hasn't started it returns at once, and
+// a later Run does nothing.
+func (p *Poller) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.started && !p.closed {
+		close(p.stopped)
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	select {
+	case <-p.stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("poller: close: %w", ctx.Err())
+	}
+}
+
+func (p *Poller) heartbeat() {
+	p.mu.Lock()
+	p.beat = p.now()
+	p.mu.Unlock()
+}
+
+// CheckHeartbeat fails when Run isn't running or has stalled: nothing has
+// happened for two rounds of the interval or collection timeout, whichever
+// is longer. It suits a health check.
+func (p *Poller) CheckHeartbeat(context.Context) error {
+	p.mu.Lock()
+	started, beat := p.started, p.beat
+	stale := 2 * max(p.interval, p.collectionTimeout)
+	p.mu.Unlock()
+
+	if !started {
+		return errors.New("poller: not started")
+	}
+	select {
+	case <-p.stopped:
+		return errors.New("poller: stopped")
+	default:
+	}
+	if since := p.now().Sub(beat); since > stale {
+		return fmt.Errorf("poller: no progress for %s", since.Round(time.Second))
+	}
+	return nil
+}
+
+// Interval returns the current poll interval.
+func (p *Poller) Interval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interval
+}
+
+// SetInterval changes the poll interval. A running poller restarts its
+// wait from the moment of the change. Non-positive values are ignored.
+func (p *Poller) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.interval = d
+	p.mu.Unlock()
+
+	select {
+	case p.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Result is the outcome of polling one collection. Skipped is set, and
+// Err left nil, for a collection sitting out a backoff.
+type Result struct {
+	CollectionCode string
+	Found          int
+	Err            error
+	Skipped        bool
+}
+
+// PollAll polls every subscribed collection once, up to maxParallelPolls
+// at a time. Each collection gets its own timeout, and an error or panic
+// in one is logged and doesn't hold up the others; collections that keep
+// failing are backed off. It returns one result per collection, in the
+// order they were listed, or an error when the collections couldn't be
+// listed.
+func (p *Poller) PollAll(ctx context.Context) ([]Result, error) {
+	codes, err := p.subs.ListCollections(ctx)
+	if err != nil {
+		p.logger.Error("list subscribed collections failed", zap.Error(err))
+		return nil, fmt.Errorf("poller: list collections: %w", err)
+	}
+	if p.enabled != nil {
+		codes = slices.DeleteFunc(codes, func(code string) bool { return !p.enabled[code] })
+	}
+
+	results := make([]Result, len(codes))
+	slots := make(chan struct{}, maxParallelPolls)
+	var wg sync.WaitGroup
+	for i, code := range codes {
+		results[i].CollectionCode = code
+		if until, ok := p.backedOff(code); ok {
+			p.logger.Debug("collection backed off", zap.String("collection", code), zap.Time("until", until))
+			results[i].Skipped = true
+			continue
+		}
+
+		if ctx.Err() != nil {
+			wg.Wait()
+			return results[:i], ctx.Err()
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return results[:i], ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			n, err := p.pollBounded(ctx, code)
+			p.heartbeat()
+			results[i].Found, results[i].Err = n, err
+			p.recordOutcome(code, err, ctx.Err() != nil)
+			if err != nil {
+				p.logger.Error("poll collection failed", zap.String("collection", code), zap.Error(err))
+				return
+			}
+			if n > 0 {
+				p.logger.Info("new packages dispatched", zap.String("collection", code), zap.Int("count", n))
+			}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// pollBounded runs PollCollection under the per-collection timeout,
+// turning a panic into an error.
+func (p *Poller) pollBounded(ctx context.Context, code string) (n int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, p.collectionTimeout)
+	defer cancel()
+	defer func() {
+		if v := recover(); v != nil {
+			p.logger.Error("poll collection panicked",
+				zap.String("collection", code),
+				zap.Any("panic", v),
+				zap.Stack("stack"))
+			err = fmt.Errorf("poller: poll %s panicked: %v", code, v)
+		}
+	}()
+	return p.PollCollection(ctx, code)
+}
+
+// backedOff reports whether code is sitting out polls, and until when.
+func (p *Poller) backedOff(code string) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.failures[code]
+	return f.until, p.now().Before(f.until)
+}
+
+// recordOutcome updates code's failure streak. A poll cut short by
+// shutdown doesn't count against the collection.
+func (p *Poller) recordOutcome(code string, err error, shuttingDown bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		delete(p.failures, code)
+		return
+	}
+	if shuttingDown {
+		return
+	}
+
+	f := p.failures[code]
+	f.count++
+	if f.count >= backoffAfter {
+		backoff := p.interval << (f.count - backoffAfter)
+		if backoff <= 0 || backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		f.until = p.now().Add(backoff)
+		p.logger.Warn("backing off failing collection",
+			zap.String("collection", code),
+			zap.Int("failures", f.count),
+			zap.Duration("backoff", backoff))
+	}
+	p.failures[code] = f
+}
+
+// PollCollection dispatches every package in code modified after the
+// stored watermark and advances the watermark. It returns the number of
+// new packages found.
+//
+// The first poll of a collection only records a watermark; alerting on
+// its whole back catalogue would flood new subscribers. The watermark is
+// left untouched when a poll fails part-way, since listings aren't
+// guaranteed to be ordered by lastModified; the retry may re-alert.
+//
+// Each collection is polled by one caller at a time, whether Run or a
+// direct call; a call waits its turn until ctx ends.
+func (p *Poller) PollCollection(ctx context.Context, code string) (int, error) {
+	token := p.pollToken(code)
+	select {
+	case token <- struct{}{}:
+		defer func() { <-token }()
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	found, err := p.pollCollection(ctx, code)
+	// A poll cut short by shutdown says nothing about the collection.
+	if !errors.Is(err, context.Canceled) {
+		if recErr := p.state.RecordPoll(context.WithoutCancel(ctx), code, found, err); recErr != nil {
+			p.logger.Warn("record poll outcome failed", zap.String("collection", code), zap.Error(recErr))
+		}
+	}
+	return found, err
+}
+
+// pollToken returns the channel that serializes polls of code.
+func (p *Poller) pollToken(code string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token, ok := p.polling[code]
+	if !ok {
+		token = make(chan struct{}, 1)
+		p.polling[code] = token
+	}
+	return token
+}
+
+func (p *Poller) pollCollection(ctx context.Context, code string) (int, error) {
+	watermark, ok, err := p.state.Watermark(ctx, code)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, p.state.SetWatermark(ctx, code, p.now())
+	}
+
+	newest := watermark
+	found := 0
+
+	pages := p.source.NewCollectionPaginator(code, watermark, 0)
+	for {
+		pkgs, more, err := pages.Next(ctx)
+		if err != nil {
+			return found, fmt.Errorf("poller: list %s: %w", code, err)
+		}
+		if !more {
+			break
+		}
+
+		for _, pkg := range pkgs {
+			modified, err := time.Parse(time.RFC3339, pkg.LastModified)
+			if err != nil {
+				p.logger.Warn("skipping package with bad lastModified",
+					zap.String("package_id", pkg.PackageID),
+					zap.String("last_modified", pkg.LastModified))
+				continue
+			}
+			// GovInfo's start date is inclusive, so anything at the
+			// watermark was handled by the previous poll.
+			if !modified.After(watermark) {
+				continue
+			}
+
+			p.storePackage(ctx, pkg, modified)
+			if _, err := p.dispatcher.DispatchPackage(ctx, pkg); err != nil {
+				return found, fmt.Errorf("poller: dispatch %s: %w", pkg.PackageID, err)
+			}
+			p.publish(pkg)
+			p.detected.WithLabelValues(code).Inc()
+			found++
+			if modified.After(newest) {
+				newest = modified
+			}
+		}
+	}
+
+	return found, p.state.SetWatermark(ctx, code, newest)
+}
+
+// storePackage saves a local copy of pkg. The copy is only a cache, so a
+// failure is logged rather than failing the poll.
+func (p *Poller) storePackage(ctx context.Context, pkg govinfo.Package, modified time.Time) {
+	if p.store == nil {
+		return
+	}
+	err := p.store.Upsert(ctx, db.Package{
+		PackageID:      pkg.PackageID,
+		CollectionCode: pkg.CollectionCode,
+		Title:          pkg.Title,
+		DateIssued:     pkg.DateIssued,
+		LastModified:   modified,
+		PackageLink:    pkg.PackageLink,
+	})
+	if err != nil {
+		p.logger.Warn("store package failed", zap.String("package_id", pkg.PackageID), zap.Error(err))
+	}
+}
+
+// publish announces a dispatched package to live listeners.
+func (p *Poller) publish(pkg govinfo.Package) {
+	if p.events == nil {
+		return
+	}
+	if dropped := p.events.Publish(pkg); dropped > 0 {
+		p.logger.Debug("slow stream listeners missed a package",
+			zap.String("package_id", pkg.PackageID),
+			zap.Int("dropped", dropped))
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (p *Poller) Describe(ch chan<- *prometheus.Desc) {
+	p.detected.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (p *Poller) Collect(ch chan<- prometheus.Metric) {
+	p.detected.Collect(ch)
+}
@@ -0,0 +1,301 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+)
+
+// newTestSource serves packages, keyed by collection code, as GovInfo's
+// collection update listing. Every package is returned whatever the
+// requested start time.
+func newTestSource(t *testing.T, packages map[string][]govinfo.Package) *govinfo.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if len(parts) < 2 || parts[0] != "collections" {
+			http.NotFound(w, r)
+			return
+		}
+		pkgs := packages[parts[1]]
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(govinfo.PackageList{Count: len(pkgs), Packages: pkgs})
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := govinfo.NewClient("test-key", srv.Client(), govinfo.WithBaseURL(srv.URL), govinfo.WithRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+type staticCollections []string
+
+func (s staticCollections) ListCollections(context.Context) ([]string, error) {
+	return append([]string(nil), s...), nil
+}
+
+// memState is an in-memory StateStore.
+type memState struct {
+	mu         sync.Mutex
+	watermarks map[string]time.Time
+	polled     []string
+}
+
+func newMemState(watermarks map[string]time.Time) *memState {
+	if watermarks == nil {
+		watermarks = map[string]time.Time{}
+	}
+	return &memState{watermarks: watermarks}
+}
+
+func (s *memState) Watermark(_ context.Context, code string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wm, ok := s.watermarks[code]
+	return wm, ok, nil
+}
+
+func (s *memState) SetWatermark(_ context.Context, code string, wm time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watermarks[code] = wm
+	return nil
+}
+
+func (s *memState) RecordPoll(_ context.Context, code string, _ int, _ error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polled = append(s.polled, code)
+	return nil
+}
+
+// recordingDispatcher remembers the packages it was handed.
+type recordingDispatcher struct {
+	mu   sync.Mutex
+	pkgs []govinfo.Package
+}
+
+func (d *recordingDispatcher) DispatchPackage(_ context.Context, pkg govinfo.Package) (notify.DispatchResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pkgs = append(d.pkgs, pkg)
+	return notify.DispatchResult{}, nil
+}
+
+func testPackage(id string, modified time.Time) govinfo.Package {
+	return govinfo.Package{PackageID: id, LastModified: modified.UTC().Format(time.RFC3339)}
+}
+
+func TestPollerPackagesDetectedMetric(t *testing.T) {
+	watermark := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newTestSource(t, map[string][]govinfo.Package{
+		"BILLS": {
+			testPackage("BILLS-1", watermark.Add(time.Hour)),
+			testPackage("BILLS-2", watermark.Add(2*time.Hour)),
+			// At the watermark, so already handled last time.
+			testPackage("BILLS-0", watermark),
+		},
+		"FR": {testPackage("FR-1", watermark.Add(time.Hour))},
+	})
+	state := newMemState(map[string]time.Time{"BILLS": watermark, "FR": watermark})
+	p := New(source, staticCollections{"BILLS", "FR"}, state, &recordingDispatcher{}, nil, nil, time.Minute, zap.NewNop())
+
+	if _, err := p.PollAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+# HELP poller_packages_detected_total New packages found and dispatched, by collection.
+# TYPE poller_packages_detected_total counter
+poller_packages_detected_total{collection="BILLS"} 2
+poller_packages_detected_total{collection="FR"} 1
+`
+	if err := testutil.CollectAndCompare(p, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+// countingCollections counts ListCollections calls.
+type countingCollections struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingCollections) ListCollections(context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return nil, nil
+}
+
+func (c *countingCollections) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func TestPollerCloseBeforeRun(t *testing.T) {
+	subs := &countingCollections{}
+	p := New(nil, subs, nil, nil, nil, nil, time.Minute, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("Close before Run = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run after Close did not return")
+	}
+	if n := subs.count(); n != 0 {
+		t.Errorf("Run after Close polled %d times, want 0", n)
+	}
+}
+
+func TestPollerCloseWaitsForRun(t *testing.T) {
+	subs := &countingCollections{}
+	p := New(nil, subs, nil, nil, nil, nil, time.Hour, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go p.Run(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for subs.count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("poller never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	closeCtx, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if err := p.Close(closeCtx); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	select {
+	case <-p.stopped:
+	default:
+		t.Fatal("Close returned before Run stopped")
+	}
+}
+
+func TestPollAllPollsCollectionsInParallel(t *testing.T) {
+	var (
+		mu             sync.Mutex
+		inFlight, peak int
+		full           = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		if inFlight == maxParallelPolls {
+			select {
+			case <-full:
+			default:
+				close(full)
+			}
+		}
+		mu.Unlock()
+		// Hold each poll until the cap is reached, so a serial PollAll
+		// would show a peak of one.
+		select {
+		case <-full:
+		case <-time.After(2 * time.Second):
+		}
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"count":0,"packages":[]}`)
+	}))
+	t.Cleanup(srv.Close)
+	client, err := govinfo.NewClient("test-key", srv.Client(), govinfo.WithBaseURL(srv.URL), govinfo.WithRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := staticCollections{"BILLS", "CRPT", "FR", "PLAW", "CREC", "USCOURTS"}
+	watermarks := map[string]time.Time{}
+	for _, code := range codes {
+		watermarks[code] = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	p := New(client, codes, newMemState(watermarks), &recordingDispatcher{}, nil, nil, time.Minute, zap.NewNop())
+
+	results, err := p.PollAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.CollectionCode != codes[i] || r.Err != nil {
+			t.Errorf("result %d = %+v, want %s without error", i, r, codes[i])
+		}
+	}
+	if len(results) != len(codes) {
+		t.Errorf("%d results, want %d", len(results), len(codes))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != maxParallelPolls {
+		t.Errorf("peak of %d collections polled at once, want %d", peak, maxParallelPolls)
+	}
+}
+
+func TestPollAllEnabledCollections(t *testing.T) {
+	watermark := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	packages := map[string][]govinfo.Package{
+		"BILLS": {testPackage("BILLS-1", watermark.Add(time.Hour))},
+		"FR":    {testPackage("FR-1", watermark.Add(time.Hour))},
+		"CREC":  {testPackage("CREC-1", watermark.Add(time.Hour))},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		enabled []string
+		want    []string
+	}{
+		{"restricted", []string{"BILLS", "CREC", "PLAW"}, []string{"BILLS", "CREC"}},
+		{"unrestricted", nil, []string{"BILLS", "FR", "CREC"}},
+	} {
+		state := newMemState(map[string]time.Time{"BILLS": watermark, "FR": watermark, "CREC": watermark})
+		dispatcher := &recordingDispatcher{}
+		p := New(newTestSource(t, packages), staticCollections{"BILLS", "FR", "CREC"}, state, dispatcher, nil, nil, time.Minute, zap.NewNop(),
+			WithEnabledCollections(tc.enabled))
+
+		results, err := p.PollAll(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var polled []string
+		for _, r := range results {
+			polled = append(polled, r.CollectionCode)
+		}
+		if strings.Join(polled, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: polled %v, want %v", tc.name, polled, tc.want)
+		}
+		if len(dispatcher.pkgs) != len(tc.want) {
+			t.Errorf("%s: dispatched %d packages, want %d", tc.name, len(dispatcher.pkgs), len(tc.want))
+		}
+	}
+}
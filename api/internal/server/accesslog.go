@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLogOptions picks the log level for error responses and the paths
+// left out of the log. Successful and redirect responses are always
+// logged at Info.
+type AccessLogOptions struct {
+	ClientError zapcore.Level // 4xx
+	ServerError zapcore.Level // 5xx
+	// SkipPaths are matched exactly against the request path. Error
+	// responses on them are still logged, so a failing probe shows up.
+	SkipPaths []string
+}
+
+// AccessLog logs one line per request with its method, path, status,
+// response size and latency. It uses the request-scoped logger, so it must
+// be installed after RequestID.
+func AccessLog(opts AccessLogOptions) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		skip[p] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := newResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			status := rw.Status()
+			if status < 400 && skip[r.URL.Path] {
+				return
+			}
+			level := zapcore.InfoLevel
+			switch {
+			case status >= 500:
+				level = opts.ServerError
+			case status >= 400:
+				level = opts.ClientError
+			}
+
+			logger := LoggerFromContext(r.Context())
+			if ce := logger.Check(level, "request completed"); ce != nil {
+				ce.Write(
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Int("status", status),
+					zap.Int("bytes", rw.bytes),
+					zap.Duration("latency", time.Since(start)),
+				)
+			}
+		})
+	}
+}
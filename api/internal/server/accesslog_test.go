@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestProbesAreNotAccessLogged(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	for _, path := range []string{"/healthz", "/metrics", "/version"} {
+		if rec := env.do(s, httptest.NewRequest(http.MethodGet, path, nil)); rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d", path, rec.Code)
+		}
+	}
+
+	var logged []string
+	for _, e := range env.logs.FilterMessage("request completed").All() {
+		logged = append(logged, e.ContextMap()["path"].(string))
+	}
+	if len(logged) != 1 || logged[0] != "/version" {
+		t.Errorf("access logged %v, want only /version", logged)
+	}
+	if n := env.logs.FilterMessage("Health check called").Len(); n != 0 {
+		t.Errorf("health check logged %d times at info", n)
+	}
+}
+
+func TestFailingProbeIsAccessLogged(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	// The test database is unreachable, so readiness fails.
+	if rec := env.do(s, httptest.NewRequest(http.MethodGet, "/readyz", nil)); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz = %d, want 503", rec.Code)
+	}
+	if n := env.logs.FilterMessage("request completed").FilterField(zap.String("path", "/readyz")).Len(); n != 1 {
+		t.Errorf("failing /readyz access logged %d times, want 1", n)
+	}
+}
+
+func TestAccessLogSkipPathsOverride(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.AccessLogSkipPaths = []string{"/version"}
+	env := newTestEnv(t, cfg, nil)
+	s := env.server()
+
+	env.do(s, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	env.do(s, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	entries := env.logs.FilterMessage("request completed").All()
+	if len(entries) != 1 || entries[0].ContextMap()["path"] != "/healthz" {
+		t.Errorf("access log entries = %v, want only /healthz", entries)
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+func handleGetLogLevel(level zap.AtomicLevel) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		httpjson.WriteJSON(w, http.StatusOK, logLevelBody{Level: level.Level().String()})
+		return nil
+	}
+}
+
+// handleSetLogLevel changes the level until the next restart or SIGHUP
+// reload, whichever comes first.
+func handleSetLogLevel(level zap.AtomicLevel) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		logger := LoggerFromContext(r.Context())
+
+		var req logLevelBody
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			return err
+		}
+		lvl, err := zapcore.ParseLevel(req.Level)
+		if err != nil {
+			return apperr.New(apperr.ErrInvalidInput, "level must be one of debug, info, warn, error, dpanic, panic, fatal")
+		}
+
+		prev := level.Level()
+		level.SetLevel(lvl)
+		logger.Warn("Log level changed", zap.Stringer("from", prev), zap.Stringer("to", lvl))
+
+		httpjson.WriteJSON(w, http.StatusOK, logLevelBody{Level: lvl.String()})
+		return nil
+	}
+}
+
+// handleGetConfig returns the running configuration, including SIGHUP
+// reloads, with secrets redacted.
+func handleGetConfig(live *config.Live) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		httpjson.WriteJSON(w, http.StatusOK, live.Current().Redacted())
+		return nil
+	}
+}
+
+type outboxPage struct {
+	Messages   []db.OutboxEntry `json:"messages"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// handleListOutbox pages through SMS outbox messages, newest first,
+// optionally only those with one status.
+func handleListOutbox(outbox *db.OutboxRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		params := r.URL.Query()
+		status := params.Get("status")
+		switch status {
+		case "", db.OutboxPending, db.OutboxSent, db.OutboxFailed:
+		default:
+			return apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("status must be %s, %s or %s", db.OutboxPending, db.OutboxSent, db.OutboxFailed))
+		}
+
+		limit := defaultListLimit
+		if v := params.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxListLimit {
+				return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			}
+			limit = n
+		}
+
+		msgs, next, err := outbox.List(r.Context(), status, limit, params.Get("cursor"))
+		if errors.Is(err, db.ErrInvalidCursor) {
+			return apperr.New(apperr.ErrInvalidInput, "invalid cursor")
+		}
+		if err != nil {
+			return err
+		}
+		if msgs == nil {
+			msgs = []db.OutboxEntry{}
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, outboxPage{Messages: msgs, NextCursor: next})
+		return nil
+	}
+}
+
+// handleRetryOutbox requeues a failed SMS so the outbox worker sends it
+// again.
+func handleRetryOutbox(outbox *db.OutboxRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			return apperr.New(apperr.ErrNotFound, "outbox message not found")
+		}
+
+		err = outbox.Retry(r.Context(), id)
+		if errors.Is(err, db.ErrNotFound) {
+			return apperr.New(apperr.ErrNotFound, "outbox message not found")
+		}
+		if errors.Is(err, db.ErrOutboxNotFailed) {
+			return apperr.New(apperr.ErrConflict, "only failed messages can be retried")
+		}
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+type notificationPage struct {
+	Notifications []db.Notification `json:"notifications"`
+	NextCursor    string            `json:"nextCursor,omitempty"`
+}
+
+// handleListNotifications pages through the alert audit trail, newest
+// first, optionally narrowed to a collection, a status, and rows written
+// at or after since.
+func handleListNotifications(notifications *db.NotificationRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		params := r.URL.Query()
+		filter := db.NotificationFilter{
+			CollectionCode: params.Get("collection"),
+			Status:         params.Get("status"),
+		}
+		switch filter.Status {
+		case "", db.NotificationQueued, db.NotificationSent, db.NotificationFailed:
+		default:
+			return apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("status must be %s, %s or %s", db.NotificationQueued, db.NotificationSent, db.NotificationFailed))
+		}
+		since, err := parseTimeParam(params, "since")
+		if err != nil {
+			return err
+		}
+		filter.Since = since
+
+		limit := defaultListLimit
+		if v := params.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxListLimit {
+				return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			}
+			limit = n
+		}
+
+		list, next, err := notifications.List(r.Context(), filter, limit, params.Get("cursor"))
+		if errors.Is(err, db.ErrInvalidCursor) {
+			return apperr.New(apperr.ErrInvalidInput, "invalid cursor")
+		}
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			list = []db.Notification{}
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, notificationPage{Notifications: list, NextCursor: next})
+		return nil
+	}
+}
+
+// handleOutboxStats reports the SMS outbox depth by status.
+func handleOutboxStats(outbox *db.OutboxRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		stats, err := outbox.Stats(r.Context())
+		if err != nil {
+			return err
+		}
+		httpjson.WriteJSON(w, http.StatusOK, stats)
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+// APIKeyHeader is the alternative to an Authorization: Bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// Identity is the named key a request authenticated with.
+type Identity struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// HasScope reports whether the identity's key grants scope.
+func (id Identity) HasScope(scope string) bool {
+	return config.APIKey{Scopes: id.Scopes}.HasScope(scope)
+}
+
+// IdentityFromContext returns the identity RequireAPIKey stored in ctx.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey).(Identity)
+	return id, ok
+}
+
+// RequireAPIKey rejects requests that do not present one of keys, either
+// as a Bearer token or in X-API-Key, and stores the matching key's
+// Identity in the request context. Missing credentials get 401 and wrong
+// ones 403. No keys rejects every request rather than letting all of
+// them through.
+func RequireAPIKey(keys []config.APIKey) func(http.Handler) http.Handler {
+	// Comparing digests keeps the comparison constant-time regardless
+	// of the presented key's length.
+	digests := make([][sha256.Size]byte, len(keys))
+	for i, k := range keys {
+		digests[i] = sha256.Sum256([]byte(k.Key))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := presentedKey(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="govinfo"`)
+				httpjson.WriteError(w, http.StatusUnauthorized, httpjson.CodeUnauthorized, "missing API key")
+				return
+			}
+			sum := sha256.Sum256([]byte(got))
+			// Every key is compared so timing doesn't reveal which
+			// one matched.
+			match := -1
+			for i := range digests {
+				if subtle.ConstantTimeCompare(sum[:], digests[i][:]) == 1 {
+					match = i
+				}
+			}
+			if match < 0 {
+				httpjson.WriteError(w, http.StatusForbidden, httpjson.CodeForbidden, "invalid API key")
+				return
+			}
+			id := Identity{Name: keys[match].Name, Scopes: keys[match].Scopes}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey, id)))
+		})
+	}
+}
+
+// RequireScope rejects requests whose identity lacks scope with 403. It
+// must run after RequireAPIKey; without an identity it answers 401.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := IdentityFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="govinfo"`)
+				httpjson.WriteError(w, http.StatusUnauthorized, httpjson.CodeUnauthorized, "missing API key")
+				return
+			}
+			if !id.HasScope(scope) {
+				httpjson.WriteError(w, http.StatusForbidden, httpjson.CodeForbidden,
+					"API key "+id.Name+" lacks the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleWhoAmI reports the identity and scopes of the caller's API key.
+func handleWhoAmI(w http.ResponseWriter, r *http.Request) error {
+	id, ok := IdentityFromContext(r.Context())
+	if !ok {
+		return apperr.New(apperr.ErrUnauthorized, "missing API key")
+	}
+	httpjson.WriteJSON(w, http.StatusOK, id)
+	return nil
+}
+
+func presentedKey(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, token, found := strings.Cut(auth, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			if token = strings.TrimSpace(token); token != "" {
+				return token, true
+			}
+		}
+	}
+	if k := r.Header.Get(APIKeyHeader); k != "" {
+		return k, true
+	}
+	return "", false
+}
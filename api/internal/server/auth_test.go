@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+)
+
+// scopedConfig accepts reader-key, writer-key and, through API_KEY,
+// admin-key.
+func scopedConfig(t *testing.T) *config.Config {
+	cfg := testConfig(t)
+	cfg.APIKeys = []config.APIKey{
+		{Name: "reader", Key: "reader-key", Scopes: []string{config.ScopeRead}},
+		{Name: "writer", Key: "writer-key", Scopes: []string{config.ScopeWrite}},
+	}
+	return cfg
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	env := newTestEnv(t, scopedConfig(t), nil)
+	s := env.server()
+
+	const (
+		unauthorized = http.StatusUnauthorized
+		forbidden    = http.StatusForbidden
+		allowed      = 0 // anything but 401 and 403
+	)
+	for _, route := range []struct {
+		method, path string
+		// want is the outcome for no key, a wrong key, reader-key,
+		// writer-key and admin-key.
+		want [5]int
+	}{
+		{http.MethodGet, "/v1/whoami", [5]int{unauthorized, forbidden, allowed, allowed, allowed}},
+		{http.MethodGet, "/v1/subscriptions", [5]int{unauthorized, forbidden, allowed, allowed, allowed}},
+		{http.MethodDelete, "/v1/subscriptions/00000000-0000-0000-0000-000000000001", [5]int{unauthorized, forbidden, forbidden, allowed, allowed}},
+		{http.MethodGet, "/v1/admin/loglevel", [5]int{unauthorized, forbidden, forbidden, forbidden, allowed}},
+		{http.MethodPost, "/v1/admin/poll", [5]int{unauthorized, forbidden, forbidden, forbidden, allowed}},
+	} {
+		for i, key := range []string{"", "wrong-key", "reader-key", "writer-key", "admin-key"} {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			if key != "" {
+				req.Header.Set(APIKeyHeader, key)
+			}
+			rec := env.do(s, req)
+			want := route.want[i]
+			switch {
+			case want == allowed && (rec.Code == unauthorized || rec.Code == forbidden):
+				t.Errorf("key %q: %s %s = %d %s, want it allowed", key, route.method, route.path, rec.Code, rec.Body)
+			case want != allowed && rec.Code != want:
+				t.Errorf("key %q: %s %s = %d, want %d", key, route.method, route.path, rec.Code, want)
+			}
+			if rec.Code == unauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("key %q: %s %s 401 without WWW-Authenticate", key, route.method, route.path)
+			}
+		}
+	}
+}
+
+func TestAPIKeyAsBearerToken(t *testing.T) {
+	env := newTestEnv(t, scopedConfig(t), nil)
+	s := env.server()
+
+	for auth, want := range map[string]int{
+		"Bearer reader-key": http.StatusOK,
+		"bearer reader-key": http.StatusOK,
+		"Bearer wrong-key":  http.StatusForbidden,
+		"Bearer ":           http.StatusUnauthorized,
+		"Basic cmVhZGVy":    http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/whoami", nil)
+		req.Header.Set("Authorization", auth)
+		if rec := env.do(s, req); rec.Code != want {
+			t.Errorf("Authorization %q: GET /v1/whoami = %d, want %d", auth, rec.Code, want)
+		}
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	env := newTestEnv(t, scopedConfig(t), nil)
+	s := env.server()
+
+	for key, want := range map[string]Identity{
+		"reader-key": {Name: "reader", Scopes: []string{config.ScopeRead}},
+		"writer-key": {Name: "writer", Scopes: []string{config.ScopeWrite}},
+		"admin-key":  {Name: config.DefaultKeyName, Scopes: []string{config.ScopeAdmin}},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/whoami", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := env.do(s, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("key %q: GET /v1/whoami = %d %s", key, rec.Code, rec.Body)
+		}
+		var got Identity
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != want.Name || !slices.Equal(got.Scopes, want.Scopes) {
+			t.Errorf("key %q: whoami = %+v, want %+v", key, got, want)
+		}
+	}
+}
+
+func TestRequireScopeWithoutIdentity(t *testing.T) {
+	h := RequireScope(config.ScopeRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran without an identity")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestRequireAPIKeyWithNoKeysRejectsAll(t *testing.T) {
+	h := RequireAPIKey(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran with no keys configured")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "")
+	req.Header.Set("Authorization", "Bearer anything")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+// maxRequestBodyBytes caps JSON request bodies. Every body we accept is a
+// handful of short fields.
+const maxRequestBodyBytes = 64 << 10
+
+// decodeJSONBody caps the request body at maxRequestBodyBytes and decodes
+// it with httpjson.DecodeStrict.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	return httpjson.DecodeStrict(r, dst)
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateSubscriptionRejectsHugeBody(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	body := `{"phoneNumber":"+12025550101","collectionCode":"BILLS","padding":"` + strings.Repeat("x", maxRequestBodyBytes) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "admin-key")
+	req.Header.Set("Content-Type", "application/json")
+
+	if rec := env.do(s, req); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestDecodeJSONBodyAcceptsSmallBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x"}`))
+	var dst struct{ Name string }
+	if err := decodeJSONBody(httptest.NewRecorder(), req, &dst); err != nil || dst.Name != "x" {
+		t.Errorf("decode = %+v, %v", dst, err)
+	}
+}
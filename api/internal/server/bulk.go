@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/phone"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+const (
+	// maxBulkSubscriptions caps the rows in one POST /subscriptions/bulk.
+	maxBulkSubscriptions = 1000
+	// maxBulkBodyBytes leaves room for maxBulkSubscriptions short rows.
+	maxBulkBodyBytes = 1 << 20
+)
+
+// bulkInvalid is the row status for entries that failed validation; the
+// others come from db.CreateBatch.
+const bulkInvalid = "invalid"
+
+type bulkSubscriptionEntry struct {
+	PhoneNumber    string `json:"phoneNumber"`
+	CollectionCode string `json:"collectionCode"`
+}
+
+type bulkRowResult struct {
+	Index        int              `json:"index"`
+	Status       string           `json:"status"`
+	Error        string           `json:"error,omitempty"`
+	Subscription *db.Subscription `json:"subscription,omitempty"`
+}
+
+type bulkSubscriptionResponse struct {
+	Created    int             `json:"created"`
+	Duplicates int             `json:"duplicates"`
+	Invalid    int             `json:"invalid"`
+	Results    []bulkRowResult `json:"results"`
+}
+
+// handleBulkCreateSubscriptions imports already-consented SMS subscribers
+// as active subscriptions. The body is a JSON array of entries, or one
+// entry per line with Content-Type application/x-ndjson. Invalid rows and
+// duplicates are reported per row without failing the rest of the batch;
+// so are collections outside a non-empty enabled.
+func handleBulkCreateSubscriptions(repo *db.SubscriptionRepo, gov *govinfo.Client, enabled []string) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+
+		var entries []bulkSubscriptionEntry
+		var err error
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/x-ndjson" {
+			entries, err = decodeNDJSONEntries(r.Body)
+		} else {
+			err = httpjson.DecodeStrict(r, &entries)
+		}
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return apperr.New(apperr.ErrInvalidInput, "at least one subscription is required")
+		}
+		if len(entries) > maxBulkSubscriptions {
+			return apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("a batch may hold at most %d subscriptions", maxBulkSubscriptions))
+		}
+
+		refreshCollections(r, gov)
+
+		resp := bulkSubscriptionResponse{Results: make([]bulkRowResult, len(entries))}
+		var valid []db.Subscription
+		var validIdx []int
+		for i, e := range entries {
+			resp.Results[i].Index = i
+			sub, msg := validateBulkEntry(e, enabled)
+			if msg != "" {
+				resp.Results[i].Status, resp.Results[i].Error = bulkInvalid, msg
+				resp.Invalid++
+				continue
+			}
+			valid = append(valid, sub)
+			validIdx = append(validIdx, i)
+		}
+
+		if len(valid) > 0 {
+			batch, err := repo.CreateBatch(r.Context(), valid)
+			if err != nil {
+				return err
+			}
+			for j, row := range batch.Rows {
+				res := &resp.Results[validIdx[j]]
+				res.Status = row.Status
+				if row.Status == db.BatchCreated {
+					res.Subscription = &row.Subscription
+					resp.Created++
+				} else {
+					resp.Duplicates++
+				}
+			}
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, resp)
+		return nil
+	}
+}
+
+// validateBulkEntry returns the subscription for e, or a message saying
+// why it was rejected.
+func validateBulkEntry(e bulkSubscriptionEntry, enabled []string) (db.Subscription, string) {
+	number, err := phone.Normalize(e.PhoneNumber)
+	if err != nil {
+		return db.Subscription{}, "phoneNumber must be a valid US phone number"
+	}
+	if e.CollectionCode == "" {
+		return db.Subscription{}, "collectionCode is required"
+	}
+	if !govinfo.CollectionCode(e.CollectionCode).Valid() {
+		return db.Subscription{}, fmt.Sprintf("unknown collection %q", e.CollectionCode)
+	}
+	if msg := disabledCollectionMessage(enabled, e.CollectionCode); msg != "" {
+		return db.Subscription{}, msg
+	}
+	return db.Subscription{
+		PhoneNumber:    number,
+		CollectionCode: e.CollectionCode,
+		Channels:       []string{db.ChannelSMS},
+	}, ""
+}
+
+// decodeNDJSONEntries reads one entry per line. Malformed lines fail the
+// whole request, since later lines can't be trusted to line up.
+func decodeNDJSONEntries(body io.Reader) ([]bulkSubscriptionEntry, error) {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	var entries []bulkSubscriptionEntry
+	for {
+		var e bulkSubscriptionEntry
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, apperr.Wrap(apperr.ErrTooLarge, "request body too large", err)
+			}
+			return nil, apperr.Wrap(apperr.ErrInvalidInput, fmt.Sprintf("invalid JSON in entry %d", len(entries)), err)
+		}
+		if len(entries) == maxBulkSubscriptions {
+			return nil, apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("a batch may hold at most %d subscriptions", maxBulkSubscriptions))
+		}
+		entries = append(entries, e)
+	}
+}
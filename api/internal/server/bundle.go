@@ -0,0 +1,147 @@
+package server
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"go.uber.org/zap"
+)
+
+// bundleFormats go into a bundle when the package is published in them.
+// GovInfo's own zip is left out since it repeats the others.
+var bundleFormats = []string{"pdf", "xml", "mods"}
+
+// bundleErrorsEntry lists the formats that couldn't be fetched.
+const bundleErrorsEntry = "errors.json"
+
+type bundlePart struct {
+	format string
+	body   io.ReadCloser
+	err    error
+}
+
+type bundleError struct {
+	Format string `json:"format"`
+	Error  string `json:"error"`
+}
+
+// handleDownloadBundle streams a ZIP holding every format packageID is
+// published in. The formats are requested from GovInfo concurrently but
+// copied into the archive one at a time, so only one body is read at
+// once. A format that fails is listed in errors.json instead of aborting
+// the archive, since the response has already started by then.
+func handleDownloadBundle(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		logger := LoggerFromContext(r.Context())
+		packageID := chi.URLParam(r, "packageID")
+
+		summary, err := gov.GetPackageSummary(r.Context(), packageID)
+		switch {
+		case errors.Is(err, govinfo.ErrPackageNotFound):
+			return apperr.New(apperr.ErrNotFound, "package not found")
+		case err != nil:
+			return apperr.Wrap(apperr.ErrUpstream, "failed to fetch package summary",
+				fmt.Errorf("package %s: %w", packageID, err))
+		}
+
+		var formats []string
+		for _, f := range bundleFormats {
+			if summary.DownloadLinks.Has(f) {
+				formats = append(formats, f)
+			}
+		}
+		if len(formats) == 0 {
+			return apperr.New(apperr.ErrNotFound, "package has no downloadable formats")
+		}
+
+		parts := make([]chan bundlePart, len(formats))
+		for i, f := range formats {
+			parts[i] = make(chan bundlePart, 1)
+			go func() {
+				body, err := gov.DownloadFromSummary(r.Context(), summary, f)
+				parts[i] <- bundlePart{format: f, body: body, err: err}
+			}()
+		}
+		// Bodies fetched but never copied, when the client goes away
+		// part-way, still need closing.
+		next := 0
+		defer func() {
+			for _, ch := range parts[next:] {
+				if p := <-ch; p.body != nil {
+					p.body.Close()
+				}
+			}
+		}()
+
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			logger.Debug("could not clear write deadline", zap.Error(err))
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", packageID+".zip"))
+
+		zw := zip.NewWriter(w)
+		var failed []bundleError
+		for ; next < len(parts); next++ {
+			p := <-parts[next]
+			if p.err == nil {
+				p.err = writeBundleEntry(zw, packageID, p.format, p.body)
+				p.body.Close()
+			}
+			if r.Context().Err() != nil {
+				logger.Warn("bundle stream interrupted", zap.String("package_id", packageID), zap.Error(r.Context().Err()))
+				next++
+				return nil
+			}
+			if p.err != nil {
+				logger.Warn("bundle format failed",
+					zap.String("package_id", packageID),
+					zap.String("format", p.format),
+					zap.Error(p.err))
+				failed = append(failed, bundleError{Format: p.format, Error: p.err.Error()})
+			}
+		}
+
+		if len(failed) > 0 {
+			if err := writeBundleErrors(zw, failed); err != nil {
+				logger.Warn("bundle stream interrupted", zap.String("package_id", packageID), zap.Error(err))
+				return nil
+			}
+		}
+		if err := zw.Close(); err != nil {
+			logger.Warn("bundle stream interrupted", zap.String("package_id", packageID), zap.Error(err))
+		}
+		return nil
+	}
+}
+
+func writeBundleEntry(zw *zip.Writer, packageID, format string, body io.Reader) error {
+	_, ext, _ := govinfo.DownloadFormat(format)
+	entry, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     packageID + "." + ext,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, body)
+	return err
+}
+
+func writeBundleErrors(zw *zip.Writer, failed []bundleError) error {
+	entry, err := zw.Create(bundleErrorsEntry)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(entry)
+	enc.SetIndent("", "  ")
+	return enc.Encode(failed)
+}
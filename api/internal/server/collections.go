@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+func handleListCollections(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		collections, err := gov.ListCollections(r.Context())
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "failed to fetch collections", err)
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, map[string]any{"collections": collections})
+		return nil
+	}
+}
+
+type subscriberCount struct {
+	CollectionCode string `json:"collectionCode"`
+	Count          int    `json:"count"`
+}
+
+// handleCountSubscribers reports how many active subscribers a collection
+// has. Codes GovInfo doesn't know are rejected rather than counted as 0.
+func handleCountSubscribers(gov *govinfo.Client, repo *db.SubscriptionRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		code := chi.URLParam(r, "code")
+		if err := validateCollections(r, gov, code); err != nil {
+			return err
+		}
+
+		n, err := repo.CountActiveByCollection(r.Context(), code)
+		if err != nil {
+			return err
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, subscriberCount{CollectionCode: code, Count: n})
+		return nil
+	}
+}
+
+// handleGetCollectionStatus reports the poller's watermark and last poll
+// outcome for a collection. Codes aren't checked against GovInfo, so the
+// status stays readable while GovInfo is down; an unknown code has simply
+// never been polled.
+func handleGetCollectionStatus(state *db.CollectionStateRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		code := chi.URLParam(r, "code")
+		s, err := state.GetState(r.Context(), code)
+		if errors.Is(err, db.ErrNotFound) {
+			return apperr.New(apperr.ErrNotFound, "collection "+code+" has not been polled yet")
+		}
+		if err != nil {
+			return err
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, s)
+		return nil
+	}
+}
+
+// validateCollections rejects any code GovInfo doesn't publish with a 400
+// listing the valid ones. The known codes are refreshed first.
+func validateCollections(r *http.Request, gov *govinfo.Client, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	refreshCollections(r, gov)
+
+	for _, code := range codes {
+		if !govinfo.CollectionCode(code).Valid() {
+			return apperr.New(apperr.ErrInvalidInput, unknownCollectionMessage(code))
+		}
+	}
+	return nil
+}
+
+// refreshCollections updates the codes govinfo.CollectionCode.Valid
+// accepts, keeping the last known set if GovInfo can't be reached.
+func refreshCollections(r *http.Request, gov *govinfo.Client) {
+	if _, err := gov.ListCollections(r.Context()); err != nil {
+		LoggerFromContext(r.Context()).Debug("collection list unavailable, using known codes", zap.Error(err))
+	}
+}
+
+// requireEnabledCollection rejects code with a 400 unless it is in
+// enabled, the ENABLED_COLLECTIONS allowlist. An empty enabled allows
+// every code.
+func requireEnabledCollection(enabled []string, code string) error {
+	if msg := disabledCollectionMessage(enabled, code); msg != "" {
+		return apperr.New(apperr.ErrInvalidInput, msg)
+	}
+	return nil
+}
+
+// disabledCollectionMessage says why code isn't allowed, or returns ""
+// if it is.
+func disabledCollectionMessage(enabled []string, code string) string {
+	if len(enabled) == 0 || slices.Contains(enabled, code) {
+		return ""
+	}
+	return fmt.Sprintf("collection %q is not enabled; enabled collections are %s", code, strings.Join(enabled, ", "))
+}
+
+func unknownCollectionMessage(code string) string {
+	known := govinfo.KnownCollectionCodes()
+	valid := make([]string, len(known))
+	for i, c := range known {
+		valid[i] = string(c)
+	}
+	return fmt.Sprintf("unknown collection %q; valid codes are %s", code, strings.Join(valid, ", "))
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// allowlistRequests are the writes ENABLED_COLLECTIONS restricts, each
+// for collection FR.
+var allowlistRequests = []struct{ method, path, body string }{
+	{http.MethodPost, "/v1/subscriptions", `{"phoneNumber":"+12025550101","collectionCode":"FR"}`},
+	{http.MethodPost, "/v1/webhooks", `{"url":"https://example.com/hook","collectionCode":"FR"}`},
+	{http.MethodPost, "/v1/admin/poll?collection=FR", ""},
+}
+
+func TestEnabledCollectionsRestricted(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.EnabledCollections = []string{"BILLS", "PLAW"}
+	env := newTestEnv(t, cfg, nil)
+	s := env.server()
+
+	for _, tc := range allowlistRequests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set(APIKeyHeader, "admin-key")
+		req.Header.Set("Content-Type", "application/json")
+		rec := env.do(s, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "BILLS, PLAW") {
+			t.Errorf("%s %s = %d %s, want 400 listing the enabled collections", tc.method, tc.path, rec.Code, rec.Body)
+		}
+	}
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("readyz = %d %s: %v", rec.Code, rec.Body, err)
+	}
+	if !slices.Equal(resp.EnabledCollections, []string{"BILLS", "PLAW"}) {
+		t.Errorf("readyz enabledCollections = %v, want the allowlist", resp.EnabledCollections)
+	}
+}
+
+func TestEnabledCollectionsUnrestricted(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	// Past the allowlist these reach the unreachable database or run a
+	// poll, so anything but a 400 will do.
+	for _, tc := range allowlistRequests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set(APIKeyHeader, "admin-key")
+		req.Header.Set("Content-Type", "application/json")
+		if rec := env.do(s, req); rec.Code == http.StatusBadRequest {
+			t.Errorf("%s %s = 400 %s, want FR allowed", tc.method, tc.path, rec.Body)
+		}
+	}
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if strings.Contains(rec.Body.String(), "enabledCollections") {
+		t.Errorf("readyz = %s, want no allowlist", rec.Body)
+	}
+}
+
+func TestValidateBulkEntryEnabledCollections(t *testing.T) {
+	entry := bulkSubscriptionEntry{PhoneNumber: "+12025550101", CollectionCode: "FR"}
+
+	if _, msg := validateBulkEntry(entry, []string{"BILLS"}); !strings.Contains(msg, `"FR" is not enabled`) {
+		t.Errorf("restricted: message = %q, want FR rejected", msg)
+	}
+	sub, msg := validateBulkEntry(entry, nil)
+	if msg != "" || sub.CollectionCode != "FR" {
+		t.Errorf("unrestricted: %+v, %q; want FR accepted", sub, msg)
+	}
+	if _, msg := validateBulkEntry(bulkSubscriptionEntry{PhoneNumber: "+12025550101", CollectionCode: "NOPE"}, nil); !strings.Contains(msg, "unknown collection") {
+		t.Errorf("unknown collection: message = %q", msg)
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/cache"
+)
+
+const (
+	// firstServedTTL is how long ConditionalGET remembers when it first
+	// served a body, which it reports as Last-Modified.
+	firstServedTTL = 24 * time.Hour
+	// firstServedPurgeEvery is how many new bodies ConditionalGET sees
+	// between sweeps of expired ones.
+	firstServedPurgeEvery = 1000
+)
+
+// ConditionalGET buffers successful JSON GET responses and tags them
+// with a strong ETag, the SHA-256 of the body, and a Last-Modified date,
+// so clients can revalidate with If-None-Match or If-Modified-Since and
+// get a bodiless 304 when nothing changed. Last-Modified is the handler's
+// own when it sets one, otherwise when this process first served that
+// body. Range requests are not honored.
+//
+// Responses of any other media type, such as streamed XML, pass through
+// untagged and unbuffered. Only mount it on bounded read routes: JSON
+// bodies are held in memory whole.
+func ConditionalGET() func(http.Handler) http.Handler {
+	var (
+		mu          sync.Mutex
+		firstServed = cache.NewTTLCache[string, time.Time](firstServedTTL)
+		added       int
+	)
+	modifiedAt := func(etag string, now time.Time) time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		if t, ok := firstServed.Get(etag); ok {
+			return t
+		}
+		firstServed.Set(etag, now)
+		if added++; added%firstServedPurgeEvery == 0 {
+			firstServed.Purge()
+		}
+		return now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bw := &bufferedWriter{ResponseWriter: w}
+			next.ServeHTTP(bw, r)
+			if bw.passthrough {
+				return
+			}
+			if bw.status != http.StatusOK {
+				bw.flush()
+				return
+			}
+
+			body := bw.body.Bytes()
+			sum := sha256.Sum256(body)
+			etag := `"` + hex.EncodeToString(sum[:]) + `"`
+			h := w.Header()
+			h.Set("ETag", etag)
+
+			modified, err := http.ParseTime(h.Get("Last-Modified"))
+			if err != nil {
+				modified = modifiedAt(etag, time.Now())
+			}
+			h.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+			if notModified(r, etag, modified) {
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			h.Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+		})
+	}
+}
+
+// notModified reports whether r's validators match etag or modified.
+// If-None-Match takes precedence over If-Modified-Since, as RFC 9110
+// requires.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// bufferedWriter holds a JSON handler's status and body until it
+// returns. Headers go straight to the underlying writer's map. Once the
+// handler commits to any other Content-Type, it writes straight through.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (bw *bufferedWriter) WriteHeader(code int) {
+	if bw.status != 0 {
+		return
+	}
+	bw.status = code
+	if mt, _, _ := mime.ParseMediaType(bw.Header().Get("Content-Type")); mt != mediaTypeJSON {
+		bw.passthrough = true
+		bw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	if bw.status == 0 {
+		bw.WriteHeader(http.StatusOK)
+	}
+	if bw.passthrough {
+		return bw.ResponseWriter.Write(b)
+	}
+	return bw.body.Write(b)
+}
+
+// Unwrap exposes the underlying writer, so WriteJSON still sees Pretty.
+func (bw *bufferedWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
+
+// flush writes the buffered response unchanged.
+func (bw *bufferedWriter) flush() {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	bw.ResponseWriter.WriteHeader(bw.status)
+	bw.ResponseWriter.Write(bw.body.Bytes())
+}
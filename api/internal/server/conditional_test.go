@@ -0,0 +1,215 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// conditionalTest wraps a handler serving *body in ConditionalGET.
+func conditionalTest(body *string, lastModified string) http.Handler {
+	return ConditionalGET()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lastModified != "" {
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		if *body == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, *body)
+	}))
+}
+
+func serveGet(h http.Handler, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConditionalGETWithETag(t *testing.T) {
+	body := `{"collections":["BILLS"]}`
+	h := conditionalTest(&body, "")
+
+	first := serveGet(h)
+	sum := sha256.Sum256([]byte(body))
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || first.Body.String() != body {
+		t.Fatalf("first GET = %d %q", first.Code, first.Body)
+	}
+	if etag != `"`+hex.EncodeToString(sum[:])+`"` {
+		t.Errorf("ETag = %s, want the SHA-256 of the body", etag)
+	}
+	if first.Header().Get("Last-Modified") == "" {
+		t.Error("no Last-Modified")
+	}
+
+	again := serveGet(h, "If-None-Match", etag)
+	if again.Code != http.StatusNotModified || again.Body.Len() != 0 {
+		t.Fatalf("revalidation = %d with %d body bytes, want a bodiless 304", again.Code, again.Body.Len())
+	}
+	if again.Header().Get("ETag") != etag {
+		t.Errorf("304 ETag = %s, want %s", again.Header().Get("ETag"), etag)
+	}
+
+	if rec := serveGet(h, "If-None-Match", `"stale"`); rec.Code != http.StatusOK || rec.Body.String() != body {
+		t.Errorf("other ETag = %d %q, want the full body", rec.Code, rec.Body)
+	}
+
+	body = `{"collections":["BILLS","FR"]}`
+	changed := serveGet(h, "If-None-Match", etag)
+	if changed.Code != http.StatusOK || changed.Body.String() != body || changed.Header().Get("ETag") == etag {
+		t.Errorf("after a change = %d %q ETag %s, want the new body and ETag", changed.Code, changed.Body, changed.Header().Get("ETag"))
+	}
+}
+
+func TestConditionalGETWithIfModifiedSince(t *testing.T) {
+	body := `{"packageId":"BILLS-1"}`
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	h := conditionalTest(&body, modified.Format(http.TimeFormat))
+
+	if rec := serveGet(h); rec.Header().Get("Last-Modified") != modified.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q, want the handler's own", rec.Header().Get("Last-Modified"))
+	}
+	for since, want := range map[time.Time]int{
+		modified:                 http.StatusNotModified,
+		modified.Add(time.Hour):  http.StatusNotModified,
+		modified.Add(-time.Hour): http.StatusOK,
+	} {
+		rec := serveGet(h, "If-Modified-Since", since.Format(http.TimeFormat))
+		if rec.Code != want {
+			t.Errorf("If-Modified-Since %v = %d, want %d", since, rec.Code, want)
+		}
+		if want == http.StatusNotModified && rec.Body.Len() != 0 {
+			t.Errorf("If-Modified-Since %v: 304 with a body", since)
+		}
+	}
+
+	// If-None-Match wins when both are sent.
+	rec := serveGet(h, "If-None-Match", `"stale"`, "If-Modified-Since", modified.Add(time.Hour).Format(http.TimeFormat))
+	if rec.Code != http.StatusOK {
+		t.Errorf("stale ETag with a fresh date = %d, want 200", rec.Code)
+	}
+}
+
+func TestConditionalGETLastModifiedIsFirstServed(t *testing.T) {
+	body := `{"a":1}`
+	h := conditionalTest(&body, "")
+
+	first := serveGet(h).Header().Get("Last-Modified")
+	time.Sleep(1100 * time.Millisecond)
+	if again := serveGet(h).Header().Get("Last-Modified"); again != first {
+		t.Errorf("Last-Modified moved from %s to %s for the same body", first, again)
+	}
+	if rec := serveGet(h, "If-Modified-Since", first); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since the first serve = %d, want 304", rec.Code)
+	}
+}
+
+func TestConditionalGETPassesThrough(t *testing.T) {
+	body := ""
+	h := conditionalTest(&body, "")
+	if rec := serveGet(h); rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" {
+		t.Errorf("404 = %d with ETag %q, want it untagged", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	body = `{"a":1}`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Errorf("POST = %d with ETag %q, want it untagged", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestConditionalGETOnRoutes(t *testing.T) {
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"collections":[{"collectionCode":"BILLS","collectionName":"Bills"}]}`)
+	}
+	env := newTestEnv(t, testConfig(t), gov)
+	s := env.server()
+
+	first := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/collections", nil))
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET /v1/collections = %d with ETag %q", first.Code, etag)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/collections", nil)
+	req.Header.Set("If-None-Match", etag)
+	if rec := env.do(s, req); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidated GET /v1/collections = %d with %d body bytes, want a bodiless 304", rec.Code, rec.Body.Len())
+	}
+
+	// Authenticated routes aren't cached.
+	req = httptest.NewRequest(http.MethodGet, "/v1/whoami", nil)
+	req.Header.Set(APIKeyHeader, "admin-key")
+	if rec := env.do(s, req); rec.Header().Get("ETag") != "" {
+		t.Errorf("GET /v1/whoami has ETag %q", rec.Header().Get("ETag"))
+	}
+}
+
+func TestConditionalGETStreamsNonJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var midway string
+	h := ConditionalGET()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		io.WriteString(w, "<mods>")
+		midway = rec.Body.String()
+		io.WriteString(w, "</mods>")
+	}))
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if midway != "<mods>" {
+		t.Errorf("client had %q while the handler was still writing, want it unbuffered", midway)
+	}
+	if rec.Body.String() != "<mods></mods>" || rec.Header().Get("ETag") != "" {
+		t.Errorf("XML = %q with ETag %q, want it passed through untagged", rec.Body, rec.Header().Get("ETag"))
+	}
+}
+
+func TestConditionalGETIgnoresRange(t *testing.T) {
+	body := `{"collections":["BILLS"]}`
+	rec := serveGet(conditionalTest(&body, ""), "Range", "bytes=0-3")
+	if rec.Code != http.StatusOK || rec.Body.String() != body {
+		t.Errorf("ranged GET = %d %q, want the whole body", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Accept-Ranges") != "" || rec.Header().Get("Content-Range") != "" {
+		t.Errorf("ranged GET advertised ranges: %v", rec.Header())
+	}
+}
+
+func TestConditionalGETSkipsXMLRoutes(t *testing.T) {
+	const doc = `<?xml version="1.0"?><mods/>`
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		io.WriteString(w, doc)
+	}
+	env := newTestEnv(t, testConfig(t), gov)
+	s := env.server()
+
+	for _, tc := range []struct{ path, accept string }{
+		{"/v1/packages/BILLS-1/mods", ""},
+		{"/v1/packages/BILLS-1/premis", ""},
+		{"/v1/packages/BILLS-1/summary", "application/xml"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		rec := env.do(s, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != doc {
+			t.Errorf("GET %s = %d %q, want the XML", tc.path, rec.Code, rec.Body)
+		}
+		if rec.Header().Get("ETag") != "" || rec.Header().Get("Accept-Ranges") != "" {
+			t.Errorf("GET %s has ETag %q and Accept-Ranges %q, want neither", tc.path, rec.Header().Get("ETag"), rec.Header().Get("Accept-Ranges"))
+		}
+	}
+}
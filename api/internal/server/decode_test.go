@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+func TestMalformedGovInfoBodyIsBadGateway(t *testing.T) {
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<html><body>proxy-internal-10.0.0.7 error</body></html>`)
+	}
+	env := newTestEnv(t, testConfig(t), gov)
+	s := env.server()
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/packages/BILLS-1/summary", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d %s, want 502", rec.Code, rec.Body)
+	}
+	if code := decodeErrorCode(t, rec.Body.String()); code != httpjson.CodeUpstream {
+		t.Errorf("error code = %q, want %q", code, httpjson.CodeUpstream)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "proxy-internal") || strings.Contains(body, "<html>") {
+		t.Errorf("response leaks the upstream body: %s", body)
+	}
+
+	// The snippet goes to the log instead.
+	logged := false
+	for _, e := range env.logs.FilterMessage("request failed").All() {
+		if strings.Contains(fmt.Sprint(e.ContextMap()), "proxy-internal") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Error("the malformed body was not logged")
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"go.uber.org/zap"
+)
+
+func handleDownloadPackage(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		logger := LoggerFromContext(r.Context())
+		packageID := chi.URLParam(r, "packageID")
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "pdf"
+		}
+		contentType, ext, ok := govinfo.DownloadFormat(format)
+		if !ok {
+			return apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("unsupported format %q; use pdf, xml, mods or zip", format))
+		}
+
+		body, err := gov.DownloadPackage(r.Context(), packageID, format)
+		switch {
+		case errors.Is(err, govinfo.ErrPackageNotFound):
+			return apperr.New(apperr.ErrNotFound, "package not found")
+		case errors.Is(err, govinfo.ErrFormatUnavailable):
+			return apperr.New(apperr.ErrNotFound, fmt.Sprintf("package is not available as %s", format))
+		case err != nil:
+			return apperr.Wrap(apperr.ErrUpstream, "failed to download package",
+				fmt.Errorf("package %s as %s: %w", packageID, format, err))
+		}
+		defer body.Close()
+
+		// Large files outlast the server's WriteTimeout, so lift the
+		// deadline for this response only.
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			logger.Debug("could not clear write deadline", zap.Error(err))
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", packageID+"."+ext))
+		if _, err := io.Copy(w, body); err != nil {
+			logger.Warn("download stream interrupted", zap.String("package_id", packageID), zap.Error(err))
+		}
+		return nil
+	}
+}
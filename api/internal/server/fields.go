@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// Top-level JSON fields a fields parameter may name, per response type.
+var (
+	packageSummaryFields = jsonFields(reflect.TypeFor[govinfo.PackageSummary]())
+	packageFields        = jsonFields(reflect.TypeFor[govinfo.Package]())
+)
+
+// jsonFields lists the JSON names of t's exported fields, sorted.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// parseFields reads the comma-separated fields parameter, rejecting any
+// name not in known. It returns nil when the parameter is absent, which
+// means every field.
+func parseFields(params url.Values, known []string) ([]string, error) {
+	fields := splitList(params["fields"])
+	for _, f := range fields {
+		if !slices.Contains(known, f) {
+			return nil, apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("unknown field %q; valid fields are %s", f, strings.Join(known, ", ")))
+		}
+	}
+	return fields, nil
+}
+
+// project returns v reduced to the named top-level JSON fields, or v
+// itself when fields is empty. Fields left empty under omitempty stay
+// absent.
+func project(v any, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("project fields: %w", err)
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, fmt.Errorf("project fields: %w", err)
+	}
+	out := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if raw, ok := all[f]; ok {
+			out[f] = raw
+		}
+	}
+	return out, nil
+}
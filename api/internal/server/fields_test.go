@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+func TestParseFields(t *testing.T) {
+	known := []string{"collectionCode", "dateIssued", "title"}
+
+	got, err := parseFields(url.Values{"fields": {"title,dateIssued", "collectionCode"}}, known)
+	if err != nil || !slices.Equal(got, []string{"title", "dateIssued", "collectionCode"}) {
+		t.Errorf("parseFields = %v, %v", got, err)
+	}
+	if got, err := parseFields(url.Values{}, known); got != nil || err != nil {
+		t.Errorf("absent fields = %v, %v; want nil for every field", got, err)
+	}
+
+	_, err = parseFields(url.Values{"fields": {"title,secret"}}, known)
+	if !errors.Is(err, apperr.ErrInvalidInput) {
+		t.Fatalf("unknown field error = %v, want ErrInvalidInput", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"secret"`) || !strings.Contains(msg, "collectionCode, dateIssued, title") {
+		t.Errorf("unknown field message = %q, want the field and the valid ones", msg)
+	}
+}
+
+func TestProject(t *testing.T) {
+	pkg := govinfo.Package{PackageID: "BILLS-1", Title: "A bill", DateIssued: "2024-01-02", CollectionCode: "BILLS"}
+
+	v, err := project(pkg, []string{"title", "dateIssued", "resultLink"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(v)
+	// resultLink is empty under omitempty, so it stays absent.
+	if string(b) != `{"dateIssued":"2024-01-02","title":"A bill"}` {
+		t.Errorf("projection = %s", b)
+	}
+
+	if v, _ := project(pkg, nil); !reflect.DeepEqual(v, pkg) {
+		t.Errorf("project with no fields = %v, want the value itself", v)
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	want := []string{"category", "collectionCode", "collectionName", "dateIssued", "download", "lastModified", "packageId", "title"}
+	if !slices.Equal(packageSummaryFields, want) {
+		t.Errorf("packageSummaryFields = %v, want %v", packageSummaryFields, want)
+	}
+}
+
+func projectionGovInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/summary"):
+		io.WriteString(w, `{"packageId":"BILLS-1","title":"A bill","dateIssued":"2024-01-02","collectionCode":"BILLS","category":"Bills","download":{"pdfLink":"x"}}`)
+	case r.URL.Path == "/search":
+		io.WriteString(w, `{"count":1,"offsetMark":"next","results":[{"packageId":"BILLS-1","title":"A bill","dateIssued":"2024-01-02","collectionCode":"BILLS"}]}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestFieldsOnRoutes(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), projectionGovInfo)
+	s := env.server()
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/packages/BILLS-1/summary?fields=title,dateIssued,collectionCode", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("summary = %d %s", rec.Code, rec.Body)
+	}
+	var summary map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &summary)
+	if len(summary) != 3 || summary["title"] != "A bill" || summary["dateIssued"] != "2024-01-02" || summary["collectionCode"] != "BILLS" {
+		t.Errorf("projected summary = %v", summary)
+	}
+
+	rec = env.do(s, httptest.NewRequest(http.MethodGet, "/v1/search?q=climate&fields=packageId", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("search = %d %s", rec.Code, rec.Body)
+	}
+	var page struct {
+		Count      int              `json:"count"`
+		OffsetMark string           `json:"offsetMark"`
+		Results    []map[string]any `json:"results"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &page)
+	if page.Count != 1 || page.OffsetMark != "next" || len(page.Results) != 1 {
+		t.Fatalf("search page = %+v, want paging kept", page)
+	}
+	if r := page.Results[0]; len(r) != 1 || r["packageId"] != "BILLS-1" {
+		t.Errorf("projected result = %v", r)
+	}
+
+	// Without fields every field comes back.
+	rec = env.do(s, httptest.NewRequest(http.MethodGet, "/v1/packages/BILLS-1/summary", nil))
+	if !strings.Contains(rec.Body.String(), `"category":"Bills"`) {
+		t.Errorf("unprojected summary = %s", rec.Body)
+	}
+}
+
+func TestUnknownFieldIsBadRequest(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), projectionGovInfo)
+	s := env.server()
+
+	for _, path := range []string{
+		"/v1/packages/BILLS-1/summary?fields=title,bogus",
+		"/v1/search?q=climate&fields=bogus",
+		"/v1/search/all?q=climate&fields=bogus",
+	} {
+		rec := env.do(s, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "bogus") {
+			t.Errorf("GET %s = %d %s, want 400 naming the field", path, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+type granulePage struct {
+	Count    int               `json:"count"`
+	Granules []govinfo.Granule `json:"granules"`
+	// NextOffsetMark is passed back as offsetMark for the next page.
+	NextOffsetMark string `json:"nextOffsetMark,omitempty"`
+}
+
+// handleListGranules returns one page of a package's granules.
+func handleListGranules(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		packageID := chi.URLParam(r, "packageID")
+		params := r.URL.Query()
+
+		pageSize := govinfo.DefaultListPageSize
+		if v := params.Get("pageSize"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSearchPageSize {
+				return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("pageSize must be between 1 and %d", maxSearchPageSize))
+			}
+			pageSize = n
+		}
+
+		res, err := gov.ListGranules(r.Context(), packageID, pageSize, params.Get("offsetMark"))
+		if errors.Is(err, govinfo.ErrPackageNotFound) {
+			return apperr.New(apperr.ErrNotFound, "package not found")
+		}
+		if errors.Is(err, govinfo.ErrCursorExpired) {
+			return errCursorExpired(err)
+		}
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "failed to list granules",
+				fmt.Errorf("package %s: %w", packageID, err))
+		}
+
+		page := granulePage{Count: res.Count, Granules: res.Granules, NextOffsetMark: res.NextOffsetMark()}
+		if page.Granules == nil {
+			page.Granules = []govinfo.Granule{}
+		}
+		httpjson.WriteJSON(w, http.StatusOK, page)
+		return nil
+	}
+}
+
+func handleGetGranuleSummary(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		packageID := chi.URLParam(r, "packageID")
+		granuleID := chi.URLParam(r, "granuleID")
+
+		summary, err := gov.GetGranuleSummary(r.Context(), packageID, granuleID)
+		if errors.Is(err, govinfo.ErrGranuleNotFound) {
+			return apperr.New(apperr.ErrNotFound, "granule not found")
+		}
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "failed to fetch granule summary",
+				fmt.Errorf("package %s granule %s: %w", packageID, granuleID, err))
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, summary)
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+// apiHandler is a handler that reports failure by returning an error
+// instead of writing the response itself. ServeHTTP turns the error into
+// the JSON envelope via httpjson.WriteErr, so a route only has to say what
+// went wrong:
+//
+//	func handleGetThing(repo *db.ThingRepo) apiHandler {
+//		return func(w http.ResponseWriter, r *http.Request) error {
+//			thing, err := repo.Get(r.Context(), chi.URLParam(r, "id"))
+//			if errors.Is(err, db.ErrNotFound) {
+//				return apperr.New(apperr.ErrNotFound, "thing not found")
+//			}
+//			if err != nil {
+//				return err // logged, and a 500 to the client
+//			}
+//			httpjson.WriteJSON(w, http.StatusOK, thing)
+//			return nil
+//		}
+//	}
+//
+//	r.Method(http.MethodGet, "/things/{id}", handleGetThing(repo))
+//
+// A handler that has already started writing its response must log and
+// return nil rather than return an error.
+type apiHandler func(w http.ResponseWriter, r *http.Request) error
+
+func (h apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h(w, r); err != nil {
+		// Whatever failed, it did so because Timeout's deadline passed.
+		if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+			err = apperr.Wrap(apperr.ErrTimeout, "request timed out", err)
+		}
+		logger := LoggerFromContext(r.Context())
+		if errors.Is(err, govinfo.ErrInvalidAPIKey) {
+			// Clients only see a 502; make sure operators see why.
+			logger.Error("GovInfo rejected GOVINFO_API_KEY; check that it is valid and has quota left", zap.Error(err))
+		}
+		httpjson.WriteErr(w, logger, err)
+	}
+}
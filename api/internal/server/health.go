@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/buildinfo"
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/health"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+// upstreamCheckTTL is how long the GovInfo and Twilio checks reuse their
+// last result, so probes don't spend API quota.
+const upstreamCheckTTL = 30 * time.Second
+
+type readinessResponse struct {
+	health.Report
+	// ShuttingDown is set once a shutdown signal has arrived; no checks
+	// are run then.
+	ShuttingDown bool `json:"shuttingDown,omitempty"`
+	// EnabledCollections is the ENABLED_COLLECTIONS allowlist; absent
+	// when every collection is enabled.
+	EnabledCollections []string       `json:"enabledCollections,omitempty"`
+	Build              buildinfo.Info `json:"build"`
+}
+
+// accountChecker is implemented by SMS senders that can verify their
+// account, such as *notify.TwilioSender.
+type accountChecker interface {
+	CheckAccount(ctx context.Context) error
+}
+
+// newHealthRegistry registers the readiness checks for deps. Only the
+// database is critical: the API can still serve local data while GovInfo
+// or Twilio is down, and a stalled poller isn't fixed by taking the
+// instance out of rotation.
+func newHealthRegistry(cfg *config.Config, deps Deps) *health.Registry {
+	reg := health.NewRegistry(health.DefaultTimeout)
+	reg.Register("database", deps.Pool.Ping)
+	reg.Register("govinfo", health.Cached(func(ctx context.Context) error {
+		if cfg.GovInfoAPIKey == "" {
+			return errors.New("GOVINFO_API_KEY is not set")
+		}
+		_, err := deps.Gov.ListCollections(ctx)
+		return err
+	}, upstreamCheckTTL), health.NonCritical())
+	if ac, ok := deps.SMS.(accountChecker); ok && cfg.TwilioSID != "" {
+		reg.Register("twilio", health.Cached(ac.CheckAccount, upstreamCheckTTL), health.NonCritical())
+	}
+	reg.Register("poller", deps.Poller.CheckHeartbeat, health.NonCritical())
+	return reg
+}
+
+// handleHealthz logs at Debug only: load balancers probe it constantly.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	LoggerFromContext(r.Context()).Debug("Health check called")
+	httpjson.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	httpjson.WriteJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+// handleReadyz runs every registered check and answers 503 unless all the
+// critical ones pass. Once shuttingDown is set it answers 503 straight
+// away, so load balancers stop sending traffic while requests drain.
+func handleReadyz(reg *health.Registry, enabled []string, shuttingDown *atomic.Bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if shuttingDown.Load() {
+			httpjson.WriteJSON(w, http.StatusServiceUnavailable, readinessResponse{
+				Report:             health.Report{Status: health.StatusUnavailable, Checks: map[string]health.Result{}},
+				ShuttingDown:       true,
+				EnabledCollections: enabled,
+				Build:              buildinfo.Get(),
+			})
+			return
+		}
+		resp := readinessResponse{Report: reg.Run(r.Context()), EnabledCollections: enabled, Build: buildinfo.Get()}
+		code := http.StatusOK
+		if !resp.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		httpjson.WriteJSON(w, code, resp)
+	}
+}
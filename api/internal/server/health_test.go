@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/health"
+)
+
+func readyz(t *testing.T, h http.HandlerFunc) (int, readinessResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("readyz body %q: %v", rec.Body, err)
+	}
+	return rec.Code, resp
+}
+
+func TestReadyzReportsEachCheck(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("down") }
+
+	for _, tc := range []struct {
+		name       string
+		critical   health.Checker
+		optional   health.Checker
+		wantCode   int
+		wantStatus string
+	}{
+		{"all passing", ok, ok, http.StatusOK, health.StatusOK},
+		{"optional failing", ok, down, http.StatusOK, health.StatusDegraded},
+		{"critical failing", down, ok, http.StatusServiceUnavailable, health.StatusUnavailable},
+	} {
+		reg := health.NewRegistry(0)
+		reg.Register("database", tc.critical)
+		reg.Register("govinfo", tc.optional, health.NonCritical())
+
+		code, resp := readyz(t, handleReadyz(reg, nil, new(atomic.Bool)))
+		if code != tc.wantCode || resp.Status != tc.wantStatus {
+			t.Errorf("%s: %d %s, want %d %s", tc.name, code, resp.Status, tc.wantCode, tc.wantStatus)
+		}
+		if len(resp.Checks) != 2 {
+			t.Errorf("%s: checks = %v, want database and govinfo", tc.name, resp.Checks)
+		}
+	}
+}
+
+// The test pool never connects, so the critical database check fails.
+func TestReadyzWithUnreachableDatabase(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz = %d, want 503", rec.Code)
+	}
+	var resp readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"database", "govinfo", "poller"} {
+		if _, ok := resp.Checks[name]; !ok {
+			t.Errorf("no %s check in %v", name, resp.Checks)
+		}
+	}
+	if db := resp.Checks["database"]; db.Status != health.StatusError || !db.Critical {
+		t.Errorf("database = %+v, want a failed critical check", db)
+	}
+}
+
+func TestReadyzFailsWhileShuttingDown(t *testing.T) {
+	var checks atomic.Int32
+	reg := health.NewRegistry(0)
+	reg.Register("database", func(context.Context) error {
+		checks.Add(1)
+		return nil
+	})
+	var shuttingDown atomic.Bool
+	h := handleReadyz(reg, nil, &shuttingDown)
+
+	if code, resp := readyz(t, h); code != http.StatusOK || resp.ShuttingDown {
+		t.Fatalf("before shutdown: %d %+v, want 200", code, resp)
+	}
+
+	shuttingDown.Store(true)
+	checks.Store(0)
+	code, resp := readyz(t, h)
+	if code != http.StatusServiceUnavailable || !resp.ShuttingDown || resp.Status != health.StatusUnavailable {
+		t.Errorf("shutting down: %d %+v, want 503 with shuttingDown", code, resp)
+	}
+	if n := checks.Load(); n != 0 {
+		t.Errorf("ran %d checks while shutting down, want none", n)
+	}
+
+	shuttingDown.Store(false)
+	if code, _ := readyz(t, h); code != http.StatusOK {
+		t.Errorf("after clearing the flag: %d, want 200", code)
+	}
+}
+
+// During the drain delay the server keeps serving, but /readyz reports
+// the shutdown.
+func TestReadyzFailsAsSoonAsShutdownBegins(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShutdownDrainDelay = 2 * time.Second
+	env := newTestEnv(t, cfg, nil)
+	url, stop := env.start(t, env.server())
+
+	shuttingDown := func() bool {
+		_, body := get(t, url+"/readyz")
+		return strings.Contains(body, `"shuttingDown":true`)
+	}
+	if shuttingDown() {
+		t.Fatal("/readyz reports a shutdown before one began")
+	}
+
+	go stop()
+	deadline := time.Now().Add(time.Second)
+	for !shuttingDown() {
+		if time.Now().After(deadline) {
+			t.Fatal("/readyz never reported the shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code, _ := get(t, url+"/healthz"); code != http.StatusOK {
+		t.Errorf("GET /healthz during the drain delay = %d, want 200", code)
+	}
+}
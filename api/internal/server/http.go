@@ -1,23 +1,291 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"go.uber.org/zap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
 )
 
-func Start(cfg *config.Config, logger *zap.Logger) error {
+// apiKeyCheckTimeout bounds the startup GovInfo key check.
+const apiKeyCheckTimeout = 15 * time.Second
+
+// checkGovInfoKey warns at startup when GovInfo rejects the configured
+// key. The server keeps running either way so routes that don't need
+// GovInfo stay up.
+func checkGovInfoKey(ctx context.Context, gov *govinfo.Client, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, apiKeyCheckTimeout)
+	defer cancel()
+
+	err := gov.VerifyAPIKey(ctx)
+	switch {
+	case err == nil:
+		logger.Info("GovInfo API key verified")
+	case errors.Is(err, govinfo.ErrInvalidAPIKey):
+		logger.Error("GOVINFO_API_KEY was REJECTED by GovInfo; every GovInfo request will fail until it is fixed", zap.Error(err))
+	case errors.Is(err, context.Canceled):
+		// Shutting down before the check finished.
+	default:
+		logger.Warn("Could not verify GOVINFO_API_KEY", zap.Error(err))
+	}
+}
+
+// Server is the HTTP API and the background work behind it.
+type Server struct {
+	cfg     *config.Config
+	live    *config.Live
+	deps    Deps
+	logger  *zap.Logger
+	limiter *RateLimiter
+	handler http.Handler
+	// routes is the router inside handler, kept so every registered
+	// route can be listed.
+	routes chi.Routes
+	// shuttingDown fails /readyz from the moment a shutdown signal
+	// arrives.
+	shuttingDown atomic.Bool
+}
+
+// NewServer builds the router for cfg and deps. Nothing is started until
+// Run.
+func NewServer(cfg *config.Config, deps Deps) *Server {
+	s := &Server{cfg: cfg, live: config.NewLive(cfg), deps: deps, logger: deps.Logger}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	// The query tracer is set up with the pool, before this registry
+	// exists, and collects its own metrics.
+	if c, ok := deps.Pool.Config().ConnConfig.Tracer.(prometheus.Collector); ok {
+		reg.MustRegister(c)
+	}
+	reg.MustRegister(deps.Dispatcher, deps.OutboxWorker, deps.Poller)
+	metrics := NewMetrics(reg)
+
 	r := chi.NewRouter()
+	r.Use(Recover(s.logger))
+	r.Use(RequestID(s.logger))
+	r.Use(traceRoute)
+	r.Use(AccessLog(AccessLogOptions{
+		ClientError: cfg.AccessLogClientErrorLevel,
+		ServerError: cfg.AccessLogServerErrorLevel,
+		SkipPaths:   cfg.AccessLogSkipPaths,
+	}))
+	r.Use(metrics.Middleware)
+	r.Use(CORS(CORSOptions{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+	}))
+	// Without limiting the middleware isn't installed at all, and
+	// s.limiter stays nil.
+	if cfg.RateLimitEnabled() {
+		s.limiter = NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst, cfg.TrustProxyHeaders)
+		r.Use(s.limiter.Middleware)
+	}
+	r.Use(httpjson.Pretty)
+
+	r.Get("/healthz", handleHealthz)
+	r.Get("/version", handleVersion)
+	r.Get("/readyz", handleReadyz(newHealthRegistry(cfg, deps), cfg.EnabledCollections, &s.shuttingDown))
+	r.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	r.Get("/openapi.json", handleOpenAPI)
+
+	// Twilio posts to the URL configured in its console, which is also
+	// part of the request signature, so it stays unversioned.
+	r.Method(http.MethodPost, "/twilio/inbound", handleTwilioInbound(deps.Subs, cfg.TwilioToken, cfg.TwilioWebhookURL, cfg.TrustProxyHeaders))
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		logger.Info("Health check called")
-		fmt.Fprintln(w, "OK")
-	})
+	s.Register(r)
+	s.routes = r
+	s.handler = Trace(r)
+	return s
+}
+
+// Handler returns the server's router, middleware included.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// newTLSConfig serves cert over TLS 1.2 or later. TLS 1.2 is limited to
+// forward-secret AEAD suites; TLS 1.3 suites are not configurable and are
+// all sound.
+func newTLSConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// Run serves the API until SIGINT or SIGTERM is received or ctx is
+// cancelled, then drains in-flight requests for up to cfg.ShutdownTimeout.
+// SIGHUP reloads the runtime-adjustable settings, including
+// cfg.AtomicLevel. The pool is owned by the caller and is not closed here.
+// The poller, dispatcher and outbox worker are closed even when draining
+// fails, and every shutdown error is returned joined.
+//
+// The port is bound before anything else starts, so a bind error such as
+// the port already being in use is returned at once. With TLS_CERT_FILE
+// and TLS_KEY_FILE set it serves HTTPS, and HTTP/2, directly.
+func (s *Server) Run(ctx context.Context) error {
+	cfg, logger := s.cfg, s.logger
+	logWarnings(logger, cfg.Warnings)
+	if s.limiter == nil {
+		logger.Info("Rate limiting is disabled",
+			zap.String("env", cfg.Env),
+			zap.Float64("rate_limit_rps", cfg.RateLimitRPS))
+	}
+
+	// Load the certificate before binding so a bad pair fails just as
+	// fast as a bad port.
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("load TLS certificate: %w", err)
+		}
+		tlsConfig = newTLSConfig(cert)
+	}
 
 	addr := ":" + cfg.Port
-	logger.Info("Server listening", zap.String("addr", addr))
-	return http.ListenAndServe(addr, r)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	defer ln.Close()
+
+	// Background work derives from bgCtx so shutdown can stop it in one go.
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go s.deps.Poller.Run(bgCtx)
+	go s.deps.OutboxWorker.Run(bgCtx)
+	go checkGovInfoKey(bgCtx, s.deps.Gov, logger)
+
+	srv := &http.Server{
+		Handler:      s.handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		TLSConfig:    tlsConfig,
+	}
+	// Open streams never go idle, so end them rather than letting them
+	// hold Shutdown until its deadline.
+	srv.RegisterOnShutdown(s.deps.Events.Close)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		// Addr reports the port actually bound when PORT is 0.
+		logger.Info("Server listening", zap.String("addr", ln.Addr().String()), zap.Bool("tls", tlsConfig != nil))
+		if tlsConfig != nil {
+			// The certificate is already in TLSConfig. ServeTLS also
+			// offers HTTP/2 through ALPN.
+			serveErr <- srv.ServeTLS(ln, "", "")
+			return
+		}
+		serveErr <- srv.Serve(ln)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var reason string
+wait:
+	for {
+		select {
+		case err := <-serveErr:
+			return err
+		case <-hup:
+			reload(s.live, logger, cfg.AtomicLevel(), s.limiter, s.deps.Poller)
+		case sig := <-stop:
+			reason = sig.String()
+			break wait
+		case <-ctx.Done():
+			reason = "context done"
+			break wait
+		}
+	}
+	s.shuttingDown.Store(true)
+	logger.Info("Shutdown signal received, draining connections",
+		zap.String("signal", reason),
+		zap.Duration("drain_delay", cfg.ShutdownDrainDelay),
+		zap.Duration("timeout", cfg.ShutdownTimeout))
+
+	// Keep serving while load balancers see /readyz fail and stop
+	// routing here. A second signal cuts the wait short.
+	if cfg.ShutdownDrainDelay > 0 {
+		t := time.NewTimer(cfg.ShutdownDrainDelay)
+		select {
+		case <-t.C:
+		case <-stop:
+			t.Stop()
+		case err := <-serveErr:
+			t.Stop()
+			return err
+		}
+	}
+
+	stopBackground()
+
+	// One deadline covers draining HTTP, the poller and in-flight sends.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// A failed HTTP drain still leaves the background workers to stop,
+	// so every step runs and the errors are joined.
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("server shutdown: %w", err))
+		srv.Close()
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	if err := s.deps.Poller.Close(ctx); err != nil {
+		logger.Error("Poller did not stop in time", zap.Error(err))
+		errs = append(errs, err)
+	}
+	if err := s.deps.Dispatcher.Close(ctx); err != nil {
+		logger.Error("Dispatcher did not drain in time", zap.Error(err))
+		errs = append(errs, err)
+	}
+	if err := s.deps.OutboxWorker.Close(ctx); err != nil {
+		logger.Error("SMS outbox worker did not stop in time", zap.Error(err))
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	logger.Info("Server stopped")
+	return nil
 }
@@ -0,0 +1,83 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+// DecodeStrict decodes exactly one JSON value from the request body into
+// dst. Unknown fields, trailing data and empty bodies are rejected with
+// apperr.ErrInvalidInput and a message saying what was wrong; a body cut
+// off by http.MaxBytesReader is apperr.ErrTooLarge.
+func DecodeStrict(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return decodeError(err)
+		}
+		return apperr.New(apperr.ErrInvalidInput, "body must contain a single JSON value")
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		tooLarge  *http.MaxBytesError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return apperr.New(apperr.ErrInvalidInput, "body must not be empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperr.Wrap(apperr.ErrInvalidInput, "body contains incomplete JSON", err)
+	case errors.As(err, &syntaxErr):
+		return apperr.Wrap(apperr.ErrInvalidInput,
+			fmt.Sprintf("body contains malformed JSON at offset %d", syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return apperr.Wrap(apperr.ErrInvalidInput, "body must be "+jsonKind(typeErr), err)
+		}
+		return apperr.Wrap(apperr.ErrInvalidInput,
+			fmt.Sprintf("field %q must be %s", typeErr.Field, jsonKind(typeErr)), err)
+	case errors.As(err, &tooLarge):
+		return apperr.Wrap(apperr.ErrTooLarge, "request body too large", err)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for this case.
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return apperr.Wrap(apperr.ErrInvalidInput, "unknown field "+field, err)
+	}
+	return apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err)
+}
+
+// jsonKind names the JSON type a Go type decodes from.
+func jsonKind(e *json.UnmarshalTypeError) string {
+	switch e.Type.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "of type " + e.Type.String()
+}
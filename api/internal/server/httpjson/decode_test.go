@@ -0,0 +1,80 @@
+package httpjson
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+type decodeTarget struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	On    bool     `json:"on"`
+	Tags  []string `json:"tags"`
+	Inner struct {
+		Code string `json:"code"`
+	} `json:"inner"`
+}
+
+func TestDecodeStrict(t *testing.T) {
+	for _, tc := range []struct {
+		name, body, message string
+	}{
+		{"empty", "", "body must not be empty"},
+		{"whitespace", "  \n", "body must not be empty"},
+		{"incomplete", `{"name":"x"`, "body contains incomplete JSON"},
+		{"syntax", `{"name":}`, "body contains malformed JSON at offset 9"},
+		{"unknown field", `{"nmae":"x"}`, `unknown field "nmae"`},
+		{"trailing value", `{"name":"x"} {}`, "body must contain a single JSON value"},
+		{"trailing garbage", `{"name":"x"} x`, "body must contain a single JSON value"},
+		{"not an object", `[1,2]`, "body must be an object"},
+		{"string for number", `{"count":"3"}`, `field "count" must be a number`},
+		{"number for string", `{"name":3}`, `field "name" must be a string`},
+		{"string for bool", `{"on":"yes"}`, `field "on" must be a boolean`},
+		{"object for array", `{"tags":{}}`, `field "tags" must be an array`},
+		{"nested field", `{"inner":{"code":1}}`, `field "inner.code" must be a string`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var dst decodeTarget
+			err := DecodeStrict(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body)), &dst)
+			if !errors.Is(err, apperr.ErrInvalidInput) {
+				t.Fatalf("err = %v, want ErrInvalidInput", err)
+			}
+			if got := apperr.Message(err, apperr.ErrInvalidInput); got != tc.message {
+				t.Errorf("message = %q, want %q", got, tc.message)
+			}
+		})
+	}
+}
+
+func TestDecodeStrictAcceptsValidBody(t *testing.T) {
+	var dst decodeTarget
+	body := `{"name":"x","count":2,"on":true,"tags":["a"],"inner":{"code":"BILLS"}}` + "\n"
+	if err := DecodeStrict(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "x" || dst.Count != 2 || !dst.On || len(dst.Tags) != 1 || dst.Inner.Code != "BILLS" {
+		t.Errorf("decoded %+v", dst)
+	}
+}
+
+func TestDecodeStrictTooLarge(t *testing.T) {
+	for name, body := range map[string]string{
+		"first value":    `{"name":"` + strings.Repeat("x", 100) + `"}`,
+		"trailing value": `{"name":"x"}` + strings.Repeat(" ", 100) + "{}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Body = http.MaxBytesReader(rec, req.Body, 20)
+			var dst decodeTarget
+			if err := DecodeStrict(req, &dst); !errors.Is(err, apperr.ErrTooLarge) {
+				t.Errorf("err = %v, want ErrTooLarge", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package httpjson
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"go.uber.org/zap"
+)
+
+// errorKinds maps each apperr kind to its status and envelope code, in the
+// order they are checked.
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{apperr.ErrInvalidInput, http.StatusBadRequest, CodeBadRequest},
+	{apperr.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+	{apperr.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{apperr.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{apperr.ErrNotAcceptable, http.StatusNotAcceptable, CodeNotAcceptable},
+	{apperr.ErrConflict, http.StatusConflict, CodeConflict},
+	{apperr.ErrGone, http.StatusGone, CodeGone},
+	{apperr.ErrTooLarge, http.StatusRequestEntityTooLarge, CodeTooLarge},
+	{apperr.ErrUpstream, http.StatusBadGateway, CodeUpstream},
+	{apperr.ErrUnavailable, http.StatusServiceUnavailable, CodeUnavailable},
+	{apperr.ErrTimeout, http.StatusGatewayTimeout, CodeTimeout},
+}
+
+// retryAfterer is implemented by errors that know when a retry may work.
+type retryAfterer interface {
+	RetryAfter() time.Duration
+}
+
+// WriteErr writes the error envelope for err. Errors of a known apperr
+// kind get that kind's status and their client-facing message; anything
+// else is a 500 with a generic message. The full error is always logged,
+// at Error for 5xx and Debug for 4xx.
+func WriteErr(w http.ResponseWriter, logger *zap.Logger, err error) {
+	status, code, message := http.StatusInternalServerError, CodeInternal, "internal server error"
+
+	// The outermost apperr.Error decides the kind, so a handler can
+	// re-classify a cause that carries a kind of its own. The exception
+	// is an unavailable dependency anywhere in the chain: clients should
+	// see 503 and back off rather than get a generic upstream error.
+	var kind error
+	var ae *apperr.Error
+	if errors.Is(err, apperr.ErrUnavailable) {
+		kind = apperr.ErrUnavailable
+	} else if errors.As(err, &ae) {
+		kind = ae.Kind
+	}
+
+	var ra retryAfterer
+	if errors.As(err, &ra) {
+		if secs := int(math.Ceil(ra.RetryAfter().Seconds())); secs > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+		}
+	}
+	for _, k := range errorKinds {
+		if k.kind == kind || (kind == nil && errors.Is(err, k.kind)) {
+			status, code, message = k.status, k.code, apperr.Message(err, k.kind)
+			break
+		}
+	}
+
+	if status >= 500 {
+		logger.Error("request failed", zap.Int("status", status), zap.Error(err))
+	} else {
+		logger.Debug("request rejected", zap.Int("status", status), zap.Error(err))
+	}
+	WriteError(w, status, code, message)
+}
@@ -0,0 +1,108 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+)
+
+func writeErr(t *testing.T, err error) (*httptest.ResponseRecorder, ErrorBody, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	rec := httptest.NewRecorder()
+	WriteErr(rec, zap.New(core), err)
+
+	var env errorEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+		t.Fatalf("body %q: %v", rec.Body, err)
+	}
+	return rec, env.Error, logs
+}
+
+func TestWriteErrMapsKinds(t *testing.T) {
+	for _, k := range errorKinds {
+		for name, err := range map[string]error{
+			"sentinel": k.kind,
+			"new":      apperr.New(k.kind, "custom message"),
+			"wrapped":  fmt.Errorf("handler: %w", apperr.Wrap(k.kind, "custom message", errors.New("cause"))),
+		} {
+			t.Run(k.code+"/"+name, func(t *testing.T) {
+				rec, body, _ := writeErr(t, err)
+				if rec.Code != k.status || body.Code != k.code {
+					t.Errorf("got %d %s, want %d %s", rec.Code, body.Code, k.status, k.code)
+				}
+			})
+		}
+	}
+}
+
+func TestWriteErrRedactsCause(t *testing.T) {
+	err := apperr.Wrap(apperr.ErrUpstream, "GovInfo is unavailable", errors.New("dial tcp 10.0.0.1: secret detail"))
+	rec, body, logs := writeErr(t, fmt.Errorf("get package: %w", err))
+
+	if rec.Code != http.StatusBadGateway || body.Message != "GovInfo is unavailable" {
+		t.Errorf("got %d %q", rec.Code, body.Message)
+	}
+	if strings.Contains(rec.Body.String(), "secret detail") {
+		t.Errorf("cause leaked to the client: %s", rec.Body)
+	}
+	entries := logs.FilterLevelExact(zapcore.ErrorLevel).All()
+	if len(entries) != 1 || !strings.Contains(entries[0].ContextMap()["error"].(string), "secret detail") {
+		t.Errorf("cause not logged in full: %+v", entries)
+	}
+}
+
+func TestWriteErrUnknownIs500(t *testing.T) {
+	rec, body, logs := writeErr(t, errors.New("pq: relation does not exist"))
+	if rec.Code != http.StatusInternalServerError || body.Code != CodeInternal || body.Message != "internal server error" {
+		t.Errorf("got %d %s %q", rec.Code, body.Code, body.Message)
+	}
+	if logs.FilterLevelExact(zapcore.ErrorLevel).Len() != 1 {
+		t.Error("500 not logged at error level")
+	}
+}
+
+func TestWriteErrClientErrorsLogAtDebug(t *testing.T) {
+	_, _, logs := writeErr(t, apperr.New(apperr.ErrInvalidInput, "bad limit"))
+	if logs.FilterLevelExact(zapcore.DebugLevel).Len() != 1 || logs.FilterLevelExact(zapcore.ErrorLevel).Len() != 0 {
+		t.Errorf("4xx logs = %+v, want one debug entry", logs.All())
+	}
+}
+
+func TestWriteErrOuterKindWins(t *testing.T) {
+	inner := apperr.New(apperr.ErrNotFound, "package not found")
+	rec, body, _ := writeErr(t, apperr.Wrap(apperr.ErrInvalidInput, "unknown package in request", inner))
+	if rec.Code != http.StatusBadRequest || body.Message != "unknown package in request" {
+		t.Errorf("got %d %q, want the outer kind", rec.Code, body.Message)
+	}
+
+	// An unavailable dependency anywhere in the chain is still a 503.
+	unavailable := apperr.New(apperr.ErrUnavailable, "breaker open")
+	if rec, _, _ := writeErr(t, apperr.Wrap(apperr.ErrUpstream, "search failed", unavailable)); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", rec.Code)
+	}
+}
+
+type retryLater struct{ after time.Duration }
+
+func (e retryLater) Error() string             { return "retry later" }
+func (e retryLater) RetryAfter() time.Duration { return e.after }
+func (e retryLater) Is(target error) bool      { return target == apperr.ErrUnavailable }
+
+func TestWriteErrSetsRetryAfter(t *testing.T) {
+	rec, _, _ := writeErr(t, retryLater{after: 1500 * time.Millisecond})
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("got %d, Retry-After %q; want 503 and 2", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
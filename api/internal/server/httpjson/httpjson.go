@@ -0,0 +1,104 @@
+// Package httpjson writes JSON responses and the API's error envelope:
+//
+//	{"error":{"code":"not_found","message":"package not found","requestId":"..."}}
+//
+// Response fields are camelCase, matching GovInfo's own API, so packages
+// pass through without renaming. Error codes, which are values rather than
+// fields, stay snake_case.
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Error codes used in the envelope's code field.
+const (
+	CodeBadRequest    = "bad_request"
+	CodeUnauthorized  = "unauthorized"
+	CodeForbidden     = "forbidden"
+	CodeNotFound      = "not_found"
+	CodeNotAcceptable = "not_acceptable"
+	CodeConflict      = "conflict"
+	CodeGone          = "gone"
+	CodeTooLarge      = "payload_too_large"
+	CodeRateLimited   = "rate_limited"
+	CodeUpstream      = "upstream_error"
+	CodeInternal      = "internal_error"
+	CodeUnavailable   = "unavailable"
+	CodeTimeout       = "timeout"
+)
+
+// requestIDHeader is set on the response by server.RequestID before any
+// handler runs, which lets WriteError pick the ID up without a context.
+const requestIDHeader = "X-Request-ID"
+
+// ErrorBody is the payload inside the error envelope.
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+type errorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// WriteJSON encodes v as the response body with the given status,
+// indented when the request went through Pretty with pretty=true.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	if isPretty(w) {
+		enc.SetIndent("", "  ")
+	}
+	enc.Encode(v)
+}
+
+// Pretty makes WriteJSON indent its output for requests with a true
+// pretty query parameter, for reading responses by hand.
+func Pretty(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty")); pretty {
+			w = prettyWriter{w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// prettyWriter marks a response for indented JSON.
+type prettyWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (p prettyWriter) Unwrap() http.ResponseWriter {
+	return p.ResponseWriter
+}
+
+// isPretty looks for a prettyWriter anywhere in w's wrapping chain, since
+// middleware inside Pretty may wrap the writer again.
+func isPretty(w http.ResponseWriter) bool {
+	for {
+		switch rw := w.(type) {
+		case prettyWriter:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// WriteError writes the error envelope, including the request ID when one
+// has been assigned.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	WriteJSON(w, status, errorEnvelope{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: w.Header().Get(requestIDHeader),
+	}})
+}
@@ -0,0 +1,60 @@
+package httpjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type samplePayload struct {
+	PackageID string   `json:"packageId"`
+	Tags      []string `json:"tags"`
+}
+
+// wrappingWriter stands in for middleware that wraps the writer inside
+// Pretty.
+type wrappingWriter struct{ http.ResponseWriter }
+
+func (w wrappingWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestWriteJSONCompactAndPretty(t *testing.T) {
+	payload := samplePayload{PackageID: "BILLS-1", Tags: []string{"a"}}
+	for _, tc := range []struct {
+		query string
+		wrap  bool
+		want  string
+	}{
+		{"", false, `{"packageId":"BILLS-1","tags":["a"]}` + "\n"},
+		{"?pretty=false", false, `{"packageId":"BILLS-1","tags":["a"]}` + "\n"},
+		{"?pretty=nope", false, `{"packageId":"BILLS-1","tags":["a"]}` + "\n"},
+		{"?pretty=true", false, "{\n  \"packageId\": \"BILLS-1\",\n  \"tags\": [\n    \"a\"\n  ]\n}\n"},
+		{"?pretty=1", true, "{\n  \"packageId\": \"BILLS-1\",\n  \"tags\": [\n    \"a\"\n  ]\n}\n"},
+	} {
+		h := Pretty(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tc.wrap {
+				w = wrappingWriter{w}
+			}
+			WriteJSON(w, http.StatusCreated, payload)
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+tc.query, nil))
+
+		if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%q: %d %s", tc.query, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if rec.Body.String() != tc.want {
+			t.Errorf("%q: body\n%s\nwant\n%s", tc.query, rec.Body, tc.want)
+		}
+	}
+}
+
+func TestWriteErrorIncludesRequestID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set(requestIDHeader, "req-1")
+	WriteError(rec, http.StatusNotFound, CodeNotFound, "package not found")
+
+	want := `{"error":{"code":"not_found","message":"package not found","requestId":"req-1"}}` + "\n"
+	if rec.Body.String() != want {
+		t.Errorf("body = %s, want %s", rec.Body, want)
+	}
+}
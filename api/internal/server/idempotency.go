@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header; UUIDs and
+// similar random tokens fit comfortably.
+const maxIdempotencyKeyLen = 255
+
+// idempotentCreate is the part of a create handler that runs at most once
+// per Idempotency-Key. It returns the new row's ID and the response body.
+type idempotentCreate func() (id string, resp any, err error)
+
+// writeIdempotent runs create and writes its response with status, unless
+// the request carries an Idempotency-Key already used in scope: then the
+// stored response is replayed with an Idempotent-Replayed header instead.
+// payload is the decoded request; reusing a key with a different payload
+// is rejected. Without the header create simply runs.
+//
+// Keys are also scoped to the caller's API key, so one caller can never
+// be replayed another's response.
+func writeIdempotent(w http.ResponseWriter, r *http.Request, keys *db.IdempotencyRepo, ttl time.Duration, scope string, payload any, status int, create idempotentCreate) error {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		_, resp, err := create()
+		if err != nil {
+			return err
+		}
+		httpjson.WriteJSON(w, status, resp)
+		return nil
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLen))
+	}
+
+	fingerprint, err := requestHash(payload)
+	if err != nil {
+		return err
+	}
+	scope = callerScope(r, scope)
+	existing, reserved, err := keys.Reserve(r.Context(), scope, key, fingerprint, ttl)
+	if errors.Is(err, db.ErrNotFound) {
+		return apperr.New(apperr.ErrConflict, "a request with this Idempotency-Key is still in progress")
+	}
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		switch {
+		case existing.RequestHash != fingerprint:
+			return apperr.New(apperr.ErrInvalidInput, "Idempotency-Key was already used with a different request")
+		case !existing.Done():
+			return apperr.New(apperr.ErrConflict, "a request with this Idempotency-Key is still in progress")
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		httpjson.WriteJSON(w, existing.Status, json.RawMessage(existing.Response))
+		return nil
+	}
+
+	// The reservation must be settled even if the client has gone.
+	settle := context.WithoutCancel(r.Context())
+	logger := LoggerFromContext(r.Context())
+
+	id, resp, err := create()
+	if err != nil {
+		if relErr := keys.Release(settle, scope, key); relErr != nil {
+			logger.Error("could not release idempotency key", zap.Error(relErr))
+		}
+		return err
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encode response: %w", err)
+	}
+	// The row exists now, so answer even if the key can't be completed;
+	// a retry then sees it as in progress until the key expires.
+	if err := keys.Complete(settle, scope, key, id, status, body); err != nil {
+		logger.Error("could not store idempotent response", zap.Error(err))
+	}
+	httpjson.WriteJSON(w, status, json.RawMessage(body))
+	return nil
+}
+
+// requestHash fingerprints a decoded request body, so formatting
+// differences between retries don't matter.
+func requestHash(payload any) (string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("fingerprint request: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// callerScope appends the authenticated key's name to scope.
+func callerScope(r *http.Request, scope string) string {
+	id, _ := IdentityFromContext(r.Context())
+	return scope + " " + id.Name
+}
@@ -0,0 +1,120 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/db/migrate"
+)
+
+const webhookSubscription = `{"collectionCode":"BILLS","channels":["webhook"],"webhookUrl":"https://hooks.example.com/bills"}`
+
+// dbTestEnv is a test environment on the database named by
+// TEST_DATABASE_URL, migrated and with no subscriptions or keys.
+func dbTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	cfg := testConfig(t)
+	cfg.DBUrl = url
+	env := newTestEnv(t, cfg, nil)
+
+	ctx := context.Background()
+	if _, err := migrate.Up(ctx, env.deps.Pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := env.deps.Pool.Exec(ctx, `TRUNCATE subscriptions, idempotency_keys RESTART IDENTITY CASCADE`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return env
+}
+
+// createWithKey posts a webhook subscription with Idempotency-Key key.
+func createWithKey(t *testing.T, env *testEnv, s *Server, key string) (rec *httptest.ResponseRecorder, id string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(webhookSubscription))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(APIKeyHeader, "admin-key")
+	req.Header.Set("Idempotency-Key", key)
+	rec = env.do(s, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /v1/subscriptions = %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" {
+		t.Fatalf("response %s has no id: %v", rec.Body, err)
+	}
+	return rec, created.ID
+}
+
+func countSubscriptions(t *testing.T, env *testEnv) int {
+	t.Helper()
+	var n int
+	if err := env.deps.Pool.QueryRow(context.Background(), `SELECT count(*) FROM subscriptions`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestIdempotencyKeyReplaysTheFirstResponse(t *testing.T) {
+	env := dbTestEnv(t)
+	s := env.server()
+
+	first, firstID := createWithKey(t, env, s, "retry-1")
+	again, againID := createWithKey(t, env, s, "retry-1")
+
+	if again.Body.String() != first.Body.String() || againID != firstID {
+		t.Errorf("replay = %s, want the first response %s", again.Body, first.Body)
+	}
+	if again.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Idempotent-Replayed = %q then %q, want it only on the replay",
+			first.Header().Get("Idempotent-Replayed"), again.Header().Get("Idempotent-Replayed"))
+	}
+	if n := countSubscriptions(t, env); n != 1 {
+		t.Errorf("%d subscriptions, want 1", n)
+	}
+}
+
+func TestDifferentIdempotencyKeysCreateDistinctRows(t *testing.T) {
+	env := dbTestEnv(t)
+	s := env.server()
+
+	_, a := createWithKey(t, env, s, "key-a")
+	_, b := createWithKey(t, env, s, "key-b")
+	if a == b {
+		t.Errorf("both keys created subscription %s", a)
+	}
+	if n := countSubscriptions(t, env); n != 2 {
+		t.Errorf("%d subscriptions, want 2", n)
+	}
+}
+
+func TestExpiredIdempotencyKeyCreatesANewRow(t *testing.T) {
+	env := dbTestEnv(t)
+	s := env.server()
+
+	_, first := createWithKey(t, env, s, "retry-1")
+	if _, err := env.deps.Pool.Exec(context.Background(),
+		`UPDATE idempotency_keys SET expires_at = now() - interval '1 second'`); err != nil {
+		t.Fatal(err)
+	}
+	rec, second := createWithKey(t, env, s, "retry-1")
+
+	if second == first || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("after expiry got %s (replayed %q), want a new subscription", second, rec.Header().Get("Idempotent-Replayed"))
+	}
+	if n := countSubscriptions(t, env); n != 2 {
+		t.Errorf("%d subscriptions, want 2", n)
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+)
+
+func TestCallerScopeSeparatesKeys(t *testing.T) {
+	keys := []config.APIKey{
+		{Name: "alice", Key: "alice-key", Scopes: []string{config.ScopeWrite}},
+		{Name: "bob", Key: "bob-key", Scopes: []string{config.ScopeWrite}},
+	}
+	var got string
+	h := RequireAPIKey(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = callerScope(r, "POST /subscriptions")
+	}))
+
+	scopes := map[string]string{}
+	for _, k := range keys {
+		req := httptest.NewRequest(http.MethodPost, "/subscriptions", nil)
+		req.Header.Set("X-API-Key", k.Key)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		scopes[k.Name] = got
+	}
+
+	if scopes["alice"] != "POST /subscriptions alice" {
+		t.Errorf("alice scope = %q", scopes["alice"])
+	}
+	if scopes["alice"] == scopes["bob"] {
+		t.Errorf("alice and bob share scope %q", scopes["alice"])
+	}
+}
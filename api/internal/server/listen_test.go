@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunFailsFastWhenPortInUse(t *testing.T) {
+	first := newTestEnv(t, testConfig(t), nil)
+	url, _ := first.start(t, first.server())
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port == "0" {
+		t.Fatal("logged address kept port 0 instead of the bound port")
+	}
+
+	cfg := testConfig(t)
+	cfg.Port = port
+	second := newTestEnv(t, cfg, nil)
+	errc := make(chan error, 1)
+	go func() { errc <- second.server().Run(context.Background()) }()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, syscall.EADDRINUSE) || !strings.Contains(err.Error(), "listen on :"+port) {
+			t.Errorf("Run = %v, want a listen error for port %s", err, port)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second server did not fail to bind")
+	}
+	if n := second.logs.FilterMessage("Server listening").Len(); n != 0 {
+		t.Error("second server logged that it was listening")
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+type localPackagePage struct {
+	Packages []db.Package `json:"packages"`
+}
+
+// handleSearchLocalPackages searches the packages stored by the poller.
+// It never calls GovInfo, so the collection isn't checked against its
+// list and results are only as fresh as the last poll.
+func handleSearchLocalPackages(repo *db.PackageRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		params := r.URL.Query()
+
+		from, err := parseTimeParam(params, "from")
+		if err != nil {
+			return err
+		}
+		to, err := parseTimeParam(params, "to")
+		if err != nil {
+			return err
+		}
+		if !from.IsZero() && !to.IsZero() && from.After(to) {
+			return apperr.New(apperr.ErrInvalidInput, "from must not be after to")
+		}
+
+		q := db.PackageQuery{
+			CollectionCode: params.Get("collection"),
+			Title:          params.Get("q"),
+			From:           from,
+			To:             to,
+			Limit:          defaultListLimit,
+		}
+		if v := params.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxListLimit {
+				return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			}
+			q.Limit = n
+		}
+		if v := params.Get("offset"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return apperr.New(apperr.ErrInvalidInput, "offset must be a non-negative integer")
+			}
+			q.Offset = n
+		}
+
+		pkgs, err := repo.SearchPackages(r.Context(), q)
+		if err != nil {
+			return err
+		}
+		if pkgs == nil {
+			pkgs = []db.Package{}
+		}
+		httpjson.WriteJSON(w, http.StatusOK, localPackagePage{Packages: pkgs})
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /openapi.json = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec does not parse: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+
+	// Routes mounted with Handle answer every method; they are
+	// documented as GET.
+	methods := map[string][]string{}
+	err := chi.Walk(s.routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		methods[route] = append(methods[route], strings.ToLower(method))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered := map[string]bool{}
+	for route, ms := range methods {
+		if len(ms) == len(allMethods) {
+			ms = []string{"get"}
+		}
+		for _, m := range ms {
+			registered[m+" "+route] = true
+			if _, ok := spec.Paths[route][m]; !ok {
+				t.Errorf("%s %s is not in openapi.json", strings.ToUpper(m), route)
+			}
+		}
+	}
+
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("openapi.json documents %s %s, which is not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+var allMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeXML  = "application/xml"
+)
+
+// handleGetPackageSummary serves the normalized JSON summary by default,
+// or GovInfo's MODS document when the client asks for XML. fields trims
+// the JSON summary to the named fields.
+func handleGetPackageSummary(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		packageID := chi.URLParam(r, "packageID")
+
+		w.Header().Add("Vary", "Accept")
+		switch negotiate(r.Header.Get("Accept"), mediaTypeJSON, mediaTypeXML) {
+		case mediaTypeJSON:
+		case mediaTypeXML:
+			return writePackageMetadata(w, r, gov.GetPackageMODS, packageID, "MODS")
+		default:
+			return apperr.New(apperr.ErrNotAcceptable,
+				"supported media types are "+mediaTypeJSON+" and "+mediaTypeXML)
+		}
+
+		fields, err := parseFields(r.URL.Query(), packageSummaryFields)
+		if err != nil {
+			return err
+		}
+
+		summary, err := gov.GetPackageSummary(r.Context(), packageID)
+		if errors.Is(err, govinfo.ErrPackageNotFound) {
+			return apperr.New(apperr.ErrNotFound, "package not found")
+		}
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "failed to fetch package summary",
+				fmt.Errorf("package %s: %w", packageID, err))
+		}
+
+		body, err := project(summary, fields)
+		if err != nil {
+			return err
+		}
+		// ConditionalGET prefers GovInfo's own date to first-seen.
+		if t, err := time.Parse(time.RFC3339, summary.LastModified); err == nil {
+			w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+		}
+		httpjson.WriteJSON(w, http.StatusOK, body)
+		return nil
+	}
+}
+
+// metadataOpener streams one of GovInfo's XML metadata records for a
+// package, such as (*govinfo.Client).GetPackageMODS.
+type metadataOpener func(ctx context.Context, packageID string) (io.ReadCloser, error)
+
+// handleGetPackageMetadata serves the XML metadata record open fetches.
+// name labels the record in errors and logs.
+func handleGetPackageMetadata(open metadataOpener, name string) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return writePackageMetadata(w, r, open, chi.URLParam(r, "packageID"), name)
+	}
+}
+
+func writePackageMetadata(w http.ResponseWriter, r *http.Request, open metadataOpener, packageID, name string) error {
+	body, err := open(r.Context(), packageID)
+	if errors.Is(err, govinfo.ErrPackageNotFound) {
+		return apperr.New(apperr.ErrNotFound, "package not found")
+	}
+	if err != nil {
+		return apperr.Wrap(apperr.ErrUpstream, "failed to fetch package "+name,
+			fmt.Errorf("package %s: %w", packageID, err))
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", mediaTypeXML+"; charset=utf-8")
+	if _, err := io.Copy(w, body); err != nil {
+		LoggerFromContext(r.Context()).Warn(name+" stream interrupted",
+			zap.String("package_id", packageID), zap.Error(err))
+	}
+	return nil
+}
+
+type relatedPage struct {
+	Related []govinfo.RelatedPackage `json:"related"`
+}
+
+// handleGetRelatedPackages lists the packages GovInfo links to packageID.
+// No relations is an empty list, not a 404.
+func handleGetRelatedPackages(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		packageID := chi.URLParam(r, "packageID")
+
+		related, err := gov.GetRelatedPackages(r.Context(), packageID)
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "failed to fetch related packages",
+				fmt.Errorf("package %s: %w", packageID, err))
+		}
+		httpjson.WriteJSON(w, http.StatusOK, relatedPage{Related: related})
+		return nil
+	}
+}
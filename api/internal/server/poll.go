@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/poller"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+// adminPollTimeout bounds a poll triggered through POST /admin/poll.
+const adminPollTimeout = 2 * time.Minute
+
+type pollResultBody struct {
+	CollectionCode string `json:"collectionCode"`
+	Found          int    `json:"found"`
+	Error          string `json:"error,omitempty"`
+	// Skipped marks a collection backed off after repeated failures.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+type pollReport struct {
+	Found       int              `json:"found"`
+	Collections []pollResultBody `json:"collections"`
+}
+
+// handleTriggerPoll polls one collection, or every subscribed collection
+// when none is given, without waiting for the next scheduled run. Found
+// counts the new packages dispatched. A collection outside a non-empty
+// enabled is rejected, as the scheduled poll would skip it.
+func handleTriggerPoll(poll *poller.Poller, gov *govinfo.Client, enabled []string) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		logger := LoggerFromContext(r.Context())
+		code := r.URL.Query().Get("collection")
+		if code != "" {
+			if err := validateCollections(r, gov, code); err != nil {
+				return err
+			}
+			if err := requireEnabledCollection(enabled, code); err != nil {
+				return err
+			}
+		}
+
+		// A full poll can outlast the server's WriteTimeout; the context
+		// below bounds it instead.
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			logger.Debug("could not clear write deadline", zap.Error(err))
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), adminPollTimeout)
+		defer cancel()
+
+		var results []poller.Result
+		if code != "" {
+			n, err := poll.PollCollection(ctx, code)
+			if err != nil {
+				return apperr.Wrap(apperr.ErrUpstream, "poll failed", err)
+			}
+			results = []poller.Result{{CollectionCode: code, Found: n}}
+		} else {
+			var err error
+			if results, err = poll.PollAll(ctx); err != nil && len(results) == 0 {
+				return apperr.Wrap(apperr.ErrUpstream, "poll failed", err)
+			}
+		}
+
+		report := pollReport{Collections: make([]pollResultBody, 0, len(results))}
+		for _, res := range results {
+			body := pollResultBody{CollectionCode: res.CollectionCode, Found: res.Found, Skipped: res.Skipped}
+			if res.Err != nil {
+				body.Error = res.Err.Error()
+			}
+			report.Found += res.Found
+			report.Collections = append(report.Collections, body)
+		}
+		logger.Info("Manual poll finished", zap.String("collection", code), zap.Int("found", report.Found))
+
+		httpjson.WriteJSON(w, http.StatusOK, report)
+		return nil
+	}
+}
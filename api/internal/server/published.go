@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+type publishedPage struct {
+	Count    int               `json:"count"`
+	Packages []govinfo.Package `json:"packages"`
+	// NextOffsetMark is passed back as offsetMark for the next page.
+	NextOffsetMark string `json:"nextOffsetMark,omitempty"`
+}
+
+// handleListPublished returns one page of packages issued between start
+// and end in the given collections.
+func handleListPublished(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		params := r.URL.Query()
+
+		start, err := parseTimeParam(params, "start")
+		if err != nil {
+			return err
+		}
+		end, err := parseTimeParam(params, "end")
+		if err != nil {
+			return err
+		}
+		if start.IsZero() || end.IsZero() {
+			return apperr.New(apperr.ErrInvalidInput, "start and end are required")
+		}
+		if start.After(end) {
+			return apperr.New(apperr.ErrInvalidInput, "start must not be after end")
+		}
+
+		collections := splitList(params["collection"])
+		if len(collections) == 0 {
+			return apperr.New(apperr.ErrInvalidInput, "at least one collection is required")
+		}
+		if err := validateCollections(r, gov, collections...); err != nil {
+			return err
+		}
+
+		pageSize := govinfo.DefaultListPageSize
+		if v := params.Get("pageSize"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSearchPageSize {
+				return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("pageSize must be between 1 and %d", maxSearchPageSize))
+			}
+			pageSize = n
+		}
+
+		res, err := gov.ListPublished(r.Context(), start, end, collections, pageSize, params.Get("offsetMark"))
+		if errors.Is(err, govinfo.ErrCursorExpired) {
+			return errCursorExpired(err)
+		}
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "failed to list published packages", err)
+		}
+
+		page := publishedPage{Count: res.Count, Packages: res.Packages, NextOffsetMark: res.NextOffsetMark()}
+		if page.Packages == nil {
+			page.Packages = []govinfo.Package{}
+		}
+		httpjson.WriteJSON(w, http.StatusOK, page)
+		return nil
+	}
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or YYYY-MM-DD dates.
+func parseTimeParam(params url.Values, name string) (time.Time, error) {
+	v := params.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, apperr.New(apperr.ErrInvalidInput, name+" must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+}
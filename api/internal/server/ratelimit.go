@@ -0,0 +1,127 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"golang.org/x/time/rate"
+)
+
+// clientIdleTTL is how long a client's limiter is kept after its last
+// request before it is evicted.
+const clientIdleTTL = 10 * time.Minute
+
+// RateLimiter throttles requests per client IP with a token bucket.
+type RateLimiter struct {
+	limit      rate.Limit
+	burst      int
+	trustProxy bool
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter allows each client rps requests per second with bursts of
+// up to burst. When trustProxy is set the client IP is taken from
+// X-Forwarded-For instead of the connection's remote address.
+func NewRateLimiter(rps float64, burst int, trustProxy bool) *RateLimiter {
+	return &RateLimiter{
+		limit:      rate.Limit(rps),
+		burst:      burst,
+		trustProxy: trustProxy,
+		clients:    make(map[string]*clientLimiter),
+		lastSweep:  time.Now(),
+		now:        time.Now,
+	}
+}
+
+// Middleware rejects over-limit requests with 429 and a Retry-After header.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := rl.now()
+		lim := rl.limiterFor(clientIP(r, rl.trustProxy), now)
+
+		res := lim.ReserveN(now, 1)
+		if delay := res.DelayFrom(now); !res.OK() || delay > 0 {
+			res.CancelAt(now)
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			httpjson.WriteError(w, http.StatusTooManyRequests, httpjson.CodeRateLimited, "rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetLimit changes the rate and burst for new and existing clients.
+func (rl *RateLimiter) SetLimit(rps float64, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.limit = rate.Limit(rps)
+	rl.burst = burst
+	now := rl.now()
+	for _, c := range rl.clients {
+		c.limiter.SetLimitAt(now, rl.limit)
+		c.limiter.SetBurstAt(now, burst)
+	}
+}
+
+func (rl *RateLimiter) limiterFor(ip string, now time.Time) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Sweep idle clients inline, at most once per TTL, rather than
+	// running a janitor goroutine that would need its own lifecycle.
+	if now.Sub(rl.lastSweep) >= clientIdleTTL {
+		for k, c := range rl.clients {
+			if now.Sub(c.lastSeen) >= clientIdleTTL {
+				delete(rl.clients, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	c, ok := rl.clients[ip]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.clients[ip] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+// clientIP returns the caller's IP. X-Forwarded-For is only consulted when
+// trustProxy is set, since clients can put anything in it.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+// burst sends n requests for /healthz from ip and returns how many got 429.
+func burst(env *testEnv, s *Server, ip string, n int) (limited int) {
+	for range n {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.RemoteAddr = ip + ":1234"
+		if env.do(s, req).Code == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+	return limited
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	for name, tweak := range map[string]func(*config.Config){
+		"RATE_LIMIT_RPS=0": func(c *config.Config) { c.RateLimitRPS = 0 },
+		"ENV=development": func(c *config.Config) {
+			c.RateLimitRPS, c.RateLimitBurst, c.Env = 1, 1, config.EnvDevelopment
+		},
+	} {
+		cfg := testConfig(t)
+		tweak(cfg)
+		env := newTestEnv(t, cfg, nil)
+		s := env.server()
+
+		if s.limiter != nil {
+			t.Errorf("%s: limiter installed", name)
+		}
+		if n := burst(env, s, "192.0.2.1", 50); n != 0 {
+			t.Errorf("%s: %d of 50 requests limited, want none", name, n)
+		}
+	}
+}
+
+func TestRateLimitEnabled(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.RateLimitRPS, cfg.RateLimitBurst, cfg.Env = 0.001, 3, config.EnvProduction
+	env := newTestEnv(t, cfg, nil)
+	s := env.server()
+
+	if n := burst(env, s, "192.0.2.1", 5); n != 2 {
+		t.Errorf("%d of 5 requests limited, want the 2 past the burst of 3", n)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := env.do(s, req)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("over-limit request = %d with Retry-After %q, want 429 with one", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if code := decodeErrorCode(t, rec.Body.String()); code != httpjson.CodeRateLimited {
+		t.Errorf("error code = %q, want %q", code, httpjson.CodeRateLimited)
+	}
+
+	// Each client has its own bucket.
+	if n := burst(env, s, "192.0.2.2", 3); n != 0 {
+		t.Errorf("another client had %d requests limited, want none", n)
+	}
+}
+
+func TestRateLimitDisabledIsLoggedOnce(t *testing.T) {
+	cfg := testConfig(t)
+	env := newTestEnv(t, cfg, nil)
+	env.start(t, env.server())
+	if n := env.logs.FilterMessage("Rate limiting is disabled").Len(); n != 1 {
+		t.Errorf("logged the disabled limiter %d times, want once", n)
+	}
+
+	cfg = testConfig(t)
+	cfg.RateLimitRPS = 10
+	env = newTestEnv(t, cfg, nil)
+	env.start(t, env.server())
+	if n := env.logs.FilterMessage("Rate limiting is disabled").Len(); n != 0 {
+		t.Errorf("logged a disabled limiter %d times with limiting on", n)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+// Recover turns a handler panic into a logged 500 response. It sits first
+// in the chain, outside RequestID, so the request ID is read back from the
+// response header rather than the context.
+func Recover(logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// net/http uses ErrAbortHandler to abort a response on
+				// purpose; it must keep propagating.
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				logger.Error("panic recovered",
+					zap.String("request_id", w.Header().Get(RequestIDHeader)),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Any("panic", rec),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				httpjson.WriteError(w, http.StatusInternalServerError, httpjson.CodeInternal, "internal server error")
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
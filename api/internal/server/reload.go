@@ -0,0 +1,45 @@
+package server
+
+import (
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/poller"
+	"go.uber.org/zap"
+)
+
+// reload re-reads the config and applies the settings that can change
+// while running. On error the current settings are kept. limiter is nil
+// when rate limiting is disabled; turning it on or off needs a restart.
+func reload(live *config.Live, logger *zap.Logger, level zap.AtomicLevel, limiter *RateLimiter, poll *poller.Poller) {
+	cfg, ignored, err := live.Reload()
+	if err != nil {
+		logger.Error("Config reload failed, keeping current settings", zap.Error(err))
+		return
+	}
+	logWarnings(logger, cfg.Warnings)
+	if len(ignored) > 0 {
+		logger.Warn("Config changes need a restart and were ignored", zap.Strings("keys", ignored))
+	}
+
+	level.SetLevel(cfg.LogLevel)
+	if (limiter != nil) != cfg.RateLimitEnabled() {
+		logger.Warn("Enabling or disabling rate limiting needs a restart; keeping the current limit")
+	} else if limiter != nil {
+		limiter.SetLimit(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	}
+	poll.SetInterval(cfg.PollInterval)
+
+	logger.Info("Config reloaded",
+		zap.Stringer("log_level", cfg.LogLevel),
+		zap.Float64("rate_limit_rps", cfg.RateLimitRPS),
+		zap.Int("rate_limit_burst", cfg.RateLimitBurst),
+		zap.Duration("poll_interval", cfg.PollInterval))
+}
+
+func logWarnings(logger *zap.Logger, warnings []config.Warning) {
+	for _, w := range warnings {
+		logger.Warn("Invalid config value, using default",
+			zap.String("key", w.Key),
+			zap.String("value", w.Value),
+			zap.String("default", w.Default))
+	}
+}
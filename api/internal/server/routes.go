@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/events"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+	"github.com/tingeytime/govinfo/api/internal/poller"
+	"go.uber.org/zap"
+)
+
+// apiVersionPrefix is where Register mounts the API.
+const apiVersionPrefix = "/v1"
+
+// Deps are the services the server's routes and background work use.
+// They are built by the caller, which also owns the pool.
+type Deps struct {
+	Logger          *zap.Logger
+	Pool            *pgxpool.Pool
+	Gov             *govinfo.Client
+	Subs            *db.SubscriptionRepo
+	IdempotencyKeys *db.IdempotencyRepo
+	Hooks           *db.WebhookRepo
+	Packages        *db.PackageRepo
+	CollectionState *db.CollectionStateRepo
+	Dispatcher      *notify.Dispatcher
+	Outbox          *db.OutboxRepo
+	Notifications   *db.NotificationRepo
+	OutboxWorker    *notify.OutboxWorker
+	Poller          *poller.Poller
+	Events          *events.Hub
+	SMS             notify.SMSSender
+	// Email is nil when no SMTP relay is configured.
+	Email notify.EmailSender
+}
+
+// Register mounts the API under /v1 on r. Probes, metrics and other
+// operational routes are not versioned and are mounted by NewServer.
+//
+// Every route is bounded by REQUEST_TIMEOUT except those that run as long
+// as the client keeps reading, and admin polls, which have their own
+// longer bound.
+func (s *Server) Register(r chi.Router) {
+	cfg, deps, gov := s.cfg, s.deps, s.deps.Gov
+	keys := cfg.AllAPIKeys()
+	r.Route(apiVersionPrefix, func(r chi.Router) {
+		r.Method(http.MethodGet, "/packages/{packageID}/download", handleDownloadPackage(gov))
+		r.Method(http.MethodGet, "/packages/{packageID}/bundle", handleDownloadBundle(gov))
+		r.Method(http.MethodGet, "/search/all", handleSearchAll(gov))
+		r.Method(http.MethodGet, "/stream/packages", handleStreamPackages(deps.Events, gov, cfg.StreamMaxConnections))
+		r.With(RequireAPIKey(keys), RequireScope(config.ScopeAdmin)).Method(http.MethodPost, "/admin/poll", handleTriggerPoll(deps.Poller, gov, cfg.EnabledCollections))
+
+		r.Group(func(r chi.Router) {
+			r.Use(Timeout(cfg.RequestTimeout))
+
+			// XML metadata is streamed as GovInfo sends it, so it stays
+			// out of ConditionalGET.
+			r.Method(http.MethodGet, "/packages/{packageID}/mods", handleGetPackageMetadata(gov.GetPackageMODS, "MODS"))
+			r.Method(http.MethodGet, "/packages/{packageID}/premis", handleGetPackageMetadata(gov.GetPackagePREMIS, "PREMIS"))
+
+			// Public reads answer conditional requests.
+			r.Group(func(r chi.Router) {
+				r.Use(ConditionalGET())
+				r.Method(http.MethodGet, "/collections", handleListCollections(gov))
+				r.Method(http.MethodGet, "/collections/{code}/subscribers/count", handleCountSubscribers(gov, deps.Subs))
+				r.Method(http.MethodGet, "/collections/{code}/status", handleGetCollectionStatus(deps.CollectionState))
+				r.Method(http.MethodGet, "/packages/local", handleSearchLocalPackages(deps.Packages))
+				r.Method(http.MethodGet, "/packages/{packageID}/summary", handleGetPackageSummary(gov))
+				r.Method(http.MethodGet, "/packages/{packageID}/related", handleGetRelatedPackages(gov))
+				r.Method(http.MethodGet, "/packages/{packageID}/granules", handleListGranules(gov))
+				r.Method(http.MethodGet, "/packages/{packageID}/granules/{granuleID}/summary", handleGetGranuleSummary(gov))
+				r.Method(http.MethodGet, "/search", handleSearch(gov))
+				r.Method(http.MethodGet, "/published", handleListPublished(gov))
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(RequireAPIKey(keys))
+				r.Method(http.MethodGet, "/whoami", apiHandler(handleWhoAmI))
+				r.With(RequireScope(config.ScopeRead)).Method(http.MethodGet, "/subscriptions", handleListSubscriptions(deps.Subs))
+
+				r.Group(func(r chi.Router) {
+					r.Use(RequireScope(config.ScopeWrite))
+					r.Method(http.MethodPost, "/subscriptions", handleCreateSubscription(deps.Subs, deps.IdempotencyKeys, gov, cfg.EnabledCollections, deps.SMS, deps.Email, cfg.ConfirmationTTL, cfg.IdempotencyKeyTTL))
+					r.Method(http.MethodPost, "/subscriptions/confirm", handleConfirmSubscription(deps.Subs))
+					r.Method(http.MethodPost, "/subscriptions/bulk", handleBulkCreateSubscriptions(deps.Subs, gov, cfg.EnabledCollections))
+					r.Method(http.MethodDelete, "/subscriptions/{id}", handleDeleteSubscription(deps.Subs))
+
+					r.Method(http.MethodPost, "/webhooks", handleCreateWebhook(deps.Hooks, gov, cfg.EnabledCollections))
+					r.Method(http.MethodDelete, "/webhooks/{id}", handleDeleteWebhook(deps.Hooks))
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(RequireScope(config.ScopeAdmin))
+					r.Method(http.MethodGet, "/admin/config", handleGetConfig(s.live))
+					r.Method(http.MethodGet, "/admin/outbox", handleListOutbox(deps.Outbox))
+					r.Method(http.MethodGet, "/admin/outbox/stats", handleOutboxStats(deps.Outbox))
+					r.Method(http.MethodPost, "/admin/outbox/{id}/retry", handleRetryOutbox(deps.Outbox))
+					r.Method(http.MethodGet, "/admin/notifications", handleListNotifications(deps.Notifications))
+					r.Method(http.MethodGet, "/admin/loglevel", handleGetLogLevel(cfg.AtomicLevel()))
+					r.Method(http.MethodPut, "/admin/loglevel", handleSetLogLevel(cfg.AtomicLevel()))
+				})
+			})
+		})
+	})
+}
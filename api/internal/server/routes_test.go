@@ -0,0 +1,36 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIIsMountedUnderV1(t *testing.T) {
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"collections":[{"collectionCode":"BILLS","collectionName":"Congressional Bills"}]}`)
+	}
+	env := newTestEnv(t, testConfig(t), gov)
+	s := env.server()
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/collections", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "BILLS") {
+		t.Errorf("GET /v1/collections = %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := env.do(s, httptest.NewRequest(http.MethodGet, "/collections", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /collections = %d, want 404", rec.Code)
+	}
+
+	for _, path := range []string{"/healthz", "/metrics", "/version", "/openapi.json"} {
+		if rec := env.do(s, httptest.NewRequest(http.MethodGet, path, nil)); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want it unversioned", path, rec.Code)
+		}
+		if rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1"+path, nil)); rec.Code != http.StatusNotFound {
+			t.Errorf("GET /v1%s = %d, want 404", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+	"go.uber.org/zap"
+)
+
+// maxSearchPageSize is the largest page GovInfo will return.
+const maxSearchPageSize = 1000
+
+// projectedSearchResults is a page of search results trimmed by fields.
+type projectedSearchResults struct {
+	Count      int    `json:"count"`
+	OffsetMark string `json:"offsetMark"`
+	Results    []any  `json:"results"`
+}
+
+// handleSearch returns one page of search results. fields trims each
+// result to the named fields.
+func handleSearch(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query, err := parseSearchQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+		fields, err := parseFields(r.URL.Query(), packageFields)
+		if err != nil {
+			return err
+		}
+		if err := validateCollections(r, gov, query.Collections...); err != nil {
+			return err
+		}
+
+		results, err := gov.Search(r.Context(), query)
+		if errors.Is(err, govinfo.ErrCursorExpired) {
+			return errCursorExpired(err)
+		}
+		if err != nil {
+			return apperr.Wrap(apperr.ErrUpstream, "search failed", fmt.Errorf("query %q: %w", query.Query, err))
+		}
+		if len(fields) == 0 {
+			httpjson.WriteJSON(w, http.StatusOK, results)
+			return nil
+		}
+
+		page := projectedSearchResults{Count: results.Count, OffsetMark: results.OffsetMark, Results: make([]any, len(results.Results))}
+		for i, pkg := range results.Results {
+			if page.Results[i], err = project(pkg, fields); err != nil {
+				return err
+			}
+		}
+		httpjson.WriteJSON(w, http.StatusOK, page)
+		return nil
+	}
+}
+
+// errCursorExpired tells the client its offsetMark is no longer valid and
+// the listing has to be started again.
+func errCursorExpired(err error) error {
+	return apperr.Wrap(apperr.ErrGone, "offsetMark has expired; restart from the first page without it", err)
+}
+
+// parseSearchQuery reads q, collection, from, to, pageSize and offsetMark.
+// collection may be repeated or comma-separated; dates are YYYY-MM-DD.
+// Errors are apperr.ErrInvalidInput with a message for the client.
+func parseSearchQuery(params url.Values) (govinfo.SearchQuery, error) {
+	q := govinfo.SearchQuery{
+		Query:      strings.TrimSpace(params.Get("q")),
+		OffsetMark: params.Get("offsetMark"),
+	}
+	if q.Query == "" {
+		return q, apperr.New(apperr.ErrInvalidInput, "query parameter q is required")
+	}
+
+	q.Collections = splitList(params["collection"])
+
+	var err error
+	if q.From, err = parseDateParam(params, "from"); err != nil {
+		return q, err
+	}
+	if q.To, err = parseDateParam(params, "to"); err != nil {
+		return q, err
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.From.After(q.To) {
+		return q, apperr.New(apperr.ErrInvalidInput, "from must not be after to")
+	}
+
+	if v := params.Get("pageSize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchPageSize {
+			return q, apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("pageSize must be between 1 and %d", maxSearchPageSize))
+		}
+		q.PageSize = n
+	}
+
+	return q, nil
+}
+
+func parseDateParam(params url.Values, name string) (time.Time, error) {
+	v := params.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, apperr.New(apperr.ErrInvalidInput, name+" must be a date in YYYY-MM-DD format")
+	}
+	return t, nil
+}
+
+// splitList flattens repeated and comma-separated values, dropping blanks.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// handleSearchAll walks every page of a search and writes each result as a
+// line of JSON, so large result sets never sit in memory at once. Each page
+// is flushed as soon as it is written, and paging stops once the client
+// disconnects. fields trims each line as it does for /search.
+func handleSearchAll(gov *govinfo.Client) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		ctx := r.Context()
+		logger := LoggerFromContext(ctx)
+
+		query, err := parseSearchQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+		fields, err := parseFields(r.URL.Query(), packageFields)
+		if err != nil {
+			return err
+		}
+		if err := validateCollections(r, gov, query.Collections...); err != nil {
+			return err
+		}
+
+		// The whole walk can take far longer than WRITE_TIMEOUT; a client
+		// that stops reading is noticed through ctx instead.
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			logger.Debug("could not clear write deadline", zap.Error(err))
+		}
+
+		enc := json.NewEncoder(w)
+		started := false
+
+		pages := gov.NewSearchPaginator(query)
+		for {
+			pkgs, ok, err := pages.Next(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					// The client went away; there is no one to tell.
+					return nil
+				}
+				if !started {
+					if errors.Is(err, govinfo.ErrCursorExpired) {
+						return errCursorExpired(err)
+					}
+					return apperr.Wrap(apperr.ErrUpstream, "search failed", fmt.Errorf("query %q: %w", query.Query, err))
+				}
+				// Once results are flowing the status is already sent,
+				// so the stream just ends early.
+				logger.Error("search page failed", zap.String("query", query.Query), zap.Error(err))
+				return nil
+			}
+			if !started {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				started = true
+			}
+			if !ok {
+				return nil
+			}
+			for _, pkg := range pkgs {
+				line, err := project(pkg, fields)
+				if err != nil {
+					logger.Error("project search result failed", zap.Error(err))
+					return nil
+				}
+				if err := enc.Encode(line); err != nil {
+					return nil
+				}
+			}
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return nil
+			}
+		}
+	}
+}
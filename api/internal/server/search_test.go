@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+// endlessSearch serves GovInfo search pages that always point to a next
+// page, counting the pages asked for.
+func endlessSearch(pages *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			OffsetMark string `json:"offsetMark"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		n := pages.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(govinfo.SearchResults{
+			Count:      2,
+			OffsetMark: fmt.Sprintf("page-%d", n),
+			Results: []govinfo.Package{
+				{PackageID: fmt.Sprintf("PKG-%d-a", n), Title: "a"},
+				{PackageID: fmt.Sprintf("PKG-%d-b", n), Title: "b"},
+			},
+		})
+	}
+}
+
+func TestSearchAllStopsPagingWhenClientLeaves(t *testing.T) {
+	var pages atomic.Int32
+	env := newTestEnv(t, testConfig(t), endlessSearch(&pages))
+	url, _ := env.start(t, env.server())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/v1/search/all?q=climate", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+
+	// Results arrive as each page does, one JSON object per line.
+	lines := bufio.NewScanner(resp.Body)
+	for i := range 5 {
+		if !lines.Scan() {
+			t.Fatalf("stream ended after %d lines: %v", i, lines.Err())
+		}
+		var pkg map[string]any
+		if err := json.Unmarshal(lines.Bytes(), &pkg); err != nil {
+			t.Fatalf("line %d = %q: %v", i, lines.Text(), err)
+		}
+		if pkg["packageId"] == nil {
+			t.Errorf("line %d has no packageId: %s", i, lines.Text())
+		}
+	}
+	cancel()
+
+	// Once the client is gone the paging stops.
+	var settled int32
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		before := pages.Load()
+		time.Sleep(100 * time.Millisecond)
+		if settled = pages.Load(); settled == before {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream still paging (%d pages) after the client left", settled)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := pages.Load(); n != settled {
+		t.Errorf("upstream paged on from %d to %d after the client left", settled, n)
+	}
+}
+
+func TestSearchExpiredCursorIsGone(t *testing.T) {
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"invalid offsetMark"}`, http.StatusBadRequest)
+	}
+	env := newTestEnv(t, testConfig(t), gov)
+	s := env.server()
+
+	for _, path := range []string{"/v1/search?q=climate&offsetMark=stale", "/v1/search/all?q=climate&offsetMark=stale"} {
+		rec := env.do(s, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusGone {
+			t.Errorf("GET %s = %d %s, want 410", path, rec.Code, rec.Body)
+			continue
+		}
+		if code := decodeErrorCode(t, rec.Body.String()); code != httpjson.CodeGone {
+			t.Errorf("GET %s error code = %q, want %q", path, code, httpjson.CodeGone)
+		}
+	}
+
+	// Without a mark the same upstream 400 is not a cursor problem.
+	if rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/search?q=climate", nil)); rec.Code == http.StatusGone {
+		t.Errorf("GET /v1/search without offsetMark = 410, want an upstream error")
+	}
+}
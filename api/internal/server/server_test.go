@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/events"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+	"github.com/tingeytime/govinfo/api/internal/poller"
+)
+
+// testConfig loads the defaults with the settings Validate requires, the
+// admin key "admin-key", and rate limiting off. Tests adjust the result.
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	for k, v := range map[string]string{
+		"CONFIG_FILE":    "",
+		"PORT":           "0",
+		"ENV":            "",
+		"DATABASE_URL":   "postgres://127.0.0.1:1/govinfo_test?connect_timeout=1",
+		"TWILIO_SID":     "",
+		"TWILIO_TOKEN":   "token",
+		"TWILIO_FROM":    "+12025550100",
+		"API_KEY":        "admin-key",
+		"API_KEYS":       "",
+		"RATE_LIMIT_RPS": "0",
+	} {
+		t.Setenv(k, v)
+	}
+	return config.Load()
+}
+
+// testEnv is a Server wired to a test GovInfo and in-memory fakes. Its
+// database pool never connects, so routes that reach the database fail.
+type testEnv struct {
+	cfg  *config.Config
+	deps Deps
+	logs *observer.ObservedLogs
+}
+
+// newTestEnv builds deps for cfg with GovInfo served by gov, which may be
+// nil. Poller collections come from collections.
+func newTestEnv(t *testing.T, cfg *config.Config, gov http.HandlerFunc, collections ...string) *testEnv {
+	t.Helper()
+	if gov == nil {
+		gov = http.NotFound
+	}
+	upstream := httptest.NewServer(gov)
+	t.Cleanup(upstream.Close)
+	client, err := govinfo.NewClient("test-key", upstream.Client(), govinfo.WithBaseURL(upstream.URL), govinfo.WithRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := db.Connect(context.Background(), cfg.DBUrl, db.PoolConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	dispatcher := notify.NewDispatcher(nil, nil, nil, nil, 1, time.Second, logger)
+	hub := events.NewHub(0)
+	return &testEnv{
+		cfg:  cfg,
+		logs: logs,
+		deps: Deps{
+			Logger:          logger,
+			Pool:            pool,
+			Gov:             client,
+			Subs:            db.NewSubscriptionRepo(pool),
+			IdempotencyKeys: db.NewIdempotencyRepo(pool),
+			Hooks:           db.NewWebhookRepo(pool),
+			Packages:        db.NewPackageRepo(pool),
+			CollectionState: db.NewCollectionStateRepo(pool),
+			Dispatcher:      dispatcher,
+			Outbox:          db.NewOutboxRepo(pool),
+			Notifications:   db.NewNotificationRepo(pool),
+			OutboxWorker:    notify.NewOutboxWorker(emptyOutbox{}, nil, 1, 1, logger),
+			Poller:          poller.New(client, staticCollections(collections), newMemState(), dispatcher, nil, hub, time.Hour, logger),
+			Events:          hub,
+		},
+	}
+}
+
+func (e *testEnv) server() *Server {
+	return NewServer(e.cfg, e.deps)
+}
+
+// do serves one request through the full router.
+func (e *testEnv) do(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// start runs s until the test ends and returns its base URL.
+func (e *testEnv) start(t *testing.T, s *Server) (url string, stop func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.Run(ctx) }()
+
+	var once sync.Once
+	var runErr error
+	stop = func() error {
+		once.Do(func() {
+			cancel()
+			select {
+			case runErr = <-errc:
+			case <-time.After(10 * time.Second):
+				t.Error("Run did not return after cancel")
+			}
+		})
+		return runErr
+	}
+	t.Cleanup(func() { stop() })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if entries := e.logs.FilterMessage("Server listening").All(); len(entries) > 0 {
+			return "http://" + entries[0].ContextMap()["addr"].(string), stop
+		}
+		select {
+		case err := <-errc:
+			t.Fatalf("Run returned before listening: %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server never started listening")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// get fetches url and returns the status and body.
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+// emptyOutbox is an OutboxStore with nothing to send.
+type emptyOutbox struct{}
+
+func (emptyOutbox) Enqueue(context.Context, db.OutboxMessage) error { return nil }
+func (emptyOutbox) Claim(context.Context, time.Duration) (db.OutboxMessage, bool, error) {
+	return db.OutboxMessage{}, false, nil
+}
+func (emptyOutbox) MarkSent(context.Context, int64) error                     { return nil }
+func (emptyOutbox) MarkRetry(context.Context, int64, string, time.Time) error { return nil }
+func (emptyOutbox) MarkFailed(context.Context, int64, string) error           { return nil }
+
+type staticCollections []string
+
+func (s staticCollections) ListCollections(context.Context) ([]string, error) {
+	return append([]string(nil), s...), nil
+}
+
+// memState is an in-memory poller.StateStore.
+type memState struct {
+	mu         sync.Mutex
+	watermarks map[string]time.Time
+}
+
+func newMemState() *memState {
+	return &memState{watermarks: map[string]time.Time{}}
+}
+
+func (s *memState) Watermark(_ context.Context, code string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wm, ok := s.watermarks[code]
+	return wm, ok, nil
+}
+
+func (s *memState) SetWatermark(_ context.Context, code string, wm time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watermarks[code] = wm
+	return nil
+}
+
+func (s *memState) RecordPoll(context.Context, string, int, error) error { return nil }
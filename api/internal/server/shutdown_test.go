@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+	"github.com/tingeytime/govinfo/api/internal/poller"
+)
+
+type staticSubscribers []db.Subscription
+
+func (s staticSubscribers) ListByCollection(context.Context, string) ([]db.Subscription, error) {
+	return s, nil
+}
+
+// blockingNotifier reports each send on started and holds it until
+// release is closed, then reports the send's context error on done.
+type blockingNotifier struct {
+	started chan struct{}
+	release chan struct{}
+	done    chan error
+}
+
+func (n blockingNotifier) Notify(ctx context.Context, _ db.Subscription, _ govinfo.Package) (string, error) {
+	n.started <- struct{}{}
+	select {
+	case <-n.release:
+	case <-ctx.Done():
+	}
+	n.done <- ctx.Err()
+	return "msg-1", ctx.Err()
+}
+
+// TestRunDrainsInFlightSendsOnShutdown stops the server while the poller
+// is part way through a dispatch. The poller must be stopped before the
+// dispatcher closes, and the send must be allowed to finish.
+func TestRunDrainsInFlightSendsOnShutdown(t *testing.T) {
+	watermark := time.Now().Add(-time.Hour).UTC()
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(govinfo.PackageList{Count: 1, Packages: []govinfo.Package{{
+			PackageID:      "BILLS-1",
+			CollectionCode: "BILLS",
+			LastModified:   watermark.Add(time.Minute).Format(time.RFC3339),
+		}}})
+	}
+	cfg := testConfig(t)
+	cfg.ShutdownTimeout = 5 * time.Second
+	env := newTestEnv(t, cfg, gov)
+
+	send := blockingNotifier{started: make(chan struct{}, 1), release: make(chan struct{}), done: make(chan error, 1)}
+	env.deps.Dispatcher = notify.NewDispatcher(
+		staticSubscribers{{ID: "1", PhoneNumber: "+12025550101", Channels: []string{db.ChannelSMS}}},
+		map[string]notify.Notifier{db.ChannelSMS: send}, nil, nil, 1, 5*time.Second, env.deps.Logger)
+	state := newMemState()
+	state.watermarks["BILLS"] = watermark
+	env.deps.Poller = poller.New(env.deps.Gov, staticCollections{"BILLS"}, state, env.deps.Dispatcher, nil, env.deps.Events, time.Hour, env.deps.Logger)
+
+	_, stop := env.start(t, env.server())
+	select {
+	case <-send.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller never dispatched")
+	}
+	if len(serviceGoroutines()) == 0 {
+		t.Fatal("found no service goroutines while running")
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- stop() }()
+	select {
+	case err := <-stopped:
+		t.Fatalf("Run returned %v with a send still in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(send.release)
+
+	if err := <-stopped; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if err := <-send.done; err != nil {
+		t.Errorf("in-flight send was cut short: %v", err)
+	}
+	for _, msg := range []string{"Poller did not stop in time", "Dispatcher did not drain in time"} {
+		if env.logs.FilterMessage(msg).Len() > 0 {
+			t.Errorf("logged %q", msg)
+		}
+	}
+	if _, err := env.deps.Dispatcher.DispatchPackage(context.Background(), govinfo.Package{}); err != notify.ErrDispatcherClosed {
+		t.Errorf("dispatch after shutdown = %v, want ErrDispatcherClosed", err)
+	}
+
+	// Everything Run started has exited.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		left := serviceGoroutines()
+		if len(left) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left after shutdown:\n\n%s", len(left), strings.Join(left, "\n\n"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// serviceGoroutines returns the stacks of goroutines running this
+// module's code, other than tests. Idle HTTP connections and the
+// process-wide signal watcher don't count.
+func serviceGoroutines() []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	var left []string
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, "tingeytime/govinfo/api/internal/") && !strings.Contains(g, "testing.tRunner") {
+			left = append(left, g)
+		}
+	}
+	return left
+}
+
+// TestRunStopsWorkersWhenDrainTimesOut holds a request open past
+// SHUTDOWN_TIMEOUT. Run must still close the background workers and
+// report the failed drain.
+func TestRunStopsWorkersWhenDrainTimesOut(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShutdownTimeout = 100 * time.Millisecond
+	env := newTestEnv(t, cfg, nil)
+	url, stop := env.start(t, env.server())
+
+	// A request whose headers never finish keeps its connection active.
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "GET /v1/collections HTTP/1.1\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	err = stop()
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "server shutdown") {
+		t.Fatalf("Run = %v, want the timed-out server shutdown", err)
+	}
+	if _, err := env.deps.Dispatcher.DispatchPackage(context.Background(), govinfo.Package{PackageID: "BILLS-1"}); !errors.Is(err, notify.ErrDispatcherClosed) {
+		t.Errorf("dispatch after Run = %v, want ErrDispatcherClosed", err)
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/events"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"go.uber.org/zap"
+)
+
+// streamKeepAlive is how often an idle stream gets a comment line, so
+// proxies don't time it out.
+const streamKeepAlive = 30 * time.Second
+
+// handleStreamPackages pushes a Server-Sent Event for every new package
+// the poller dispatches in collection, until the client disconnects or
+// the server shuts down. At most maxConns streams are open at once.
+func handleStreamPackages(hub *events.Hub, gov *govinfo.Client, maxConns int) apiHandler {
+	slots := make(chan struct{}, maxConns)
+	return func(w http.ResponseWriter, r *http.Request) error {
+		logger := LoggerFromContext(r.Context())
+
+		code := r.URL.Query().Get("collection")
+		if code == "" {
+			return apperr.New(apperr.ErrInvalidInput, "collection is required")
+		}
+		if err := validateCollections(r, gov, code); err != nil {
+			return err
+		}
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		default:
+			return apperr.New(apperr.ErrUnavailable, "too many open streams; try again later")
+		}
+
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			logger.Debug("could not clear write deadline", zap.Error(err))
+		}
+
+		pkgs, cancel := hub.Subscribe(code)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		// The status is sent from here on, so failures just end the stream.
+		if err := rc.Flush(); err != nil {
+			logger.Error("stream cannot flush", zap.Error(err))
+			return nil
+		}
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return nil
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return nil
+				}
+			case pkg, ok := <-pkgs:
+				if !ok {
+					return nil
+				}
+				data, err := json.Marshal(pkg)
+				if err != nil {
+					logger.Error("stream encode failed", zap.String("package_id", pkg.PackageID), zap.Error(err))
+					return nil
+				}
+				if _, err := fmt.Fprintf(w, "event: package\nid: %s\ndata: %s\n\n", pkg.PackageID, data); err != nil {
+					return nil
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+	"github.com/tingeytime/govinfo/api/internal/phone"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+// Page sizes for GET /subscriptions.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type subscriptionPage struct {
+	Subscriptions []db.Subscription `json:"subscriptions"`
+	NextCursor    string            `json:"nextCursor,omitempty"`
+}
+
+type createSubscriptionRequest struct {
+	PhoneNumber    string `json:"phoneNumber"`
+	Email          string `json:"email"`
+	WebhookURL     string `json:"webhookUrl"`
+	CollectionCode string `json:"collectionCode"`
+	// Channels defaults to sms alone, as before channels existed.
+	Channels []string `json:"channels"`
+}
+
+// createdSubscription is the only response that includes the webhook
+// channel's signing secret.
+type createdSubscription struct {
+	db.Subscription
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+}
+
+type confirmSubscriptionRequest struct {
+	PhoneNumber string `json:"phoneNumber"`
+	Email       string `json:"email"`
+	Code        string `json:"code"`
+}
+
+// handleCreateSubscription stores a subscription for the requested
+// channels. When it includes sms or email, the subscription is pending and
+// a confirmation code is sent over that channel (sms first); alerts start
+// once the code is confirmed. Webhook-only subscriptions are active at
+// once. email may be nil when no SMTP relay is configured.
+//
+// Collections outside enabled, when it is non-empty, are rejected.
+//
+// A request repeated with the same Idempotency-Key within keyTTL gets the
+// first response back instead of creating or texting again.
+func handleCreateSubscription(repo *db.SubscriptionRepo, keys *db.IdempotencyRepo, gov *govinfo.Client, enabled []string, sms notify.SMSSender, email notify.EmailSender, confirmTTL, keyTTL time.Duration) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req createSubscriptionRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			return err
+		}
+		sub, err := newSubscription(req, email != nil)
+		if err != nil {
+			return err
+		}
+		if err := validateCollections(r, gov, sub.CollectionCode); err != nil {
+			return err
+		}
+		if err := requireEnabledCollection(enabled, sub.CollectionCode); err != nil {
+			return err
+		}
+
+		return writeIdempotent(w, r, keys, keyTTL, "POST /subscriptions", req, http.StatusCreated, func() (string, any, error) {
+			created, err := createSubscription(r, repo, sub, sms, email, confirmTTL)
+			if err != nil {
+				return "", nil, err
+			}
+			return created.ID, createdSubscription{Subscription: created, WebhookSecret: created.WebhookSecret}, nil
+		})
+	}
+}
+
+// createSubscription stores sub and sends its confirmation code, if its
+// channels need one.
+func createSubscription(r *http.Request, repo *db.SubscriptionRepo, sub db.Subscription, sms notify.SMSSender, email notify.EmailSender, confirmTTL time.Duration) (db.Subscription, error) {
+	var err error
+	if sub.HasChannel(db.ChannelWebhook) {
+		if sub.WebhookSecret, err = newWebhookSecret(); err != nil {
+			return db.Subscription{}, fmt.Errorf("generate webhook secret: %w", err)
+		}
+	}
+
+	var code string
+	if sub.HasChannel(db.ChannelSMS) || sub.HasChannel(db.ChannelEmail) {
+		if code, err = newConfirmationCode(); err != nil {
+			return db.Subscription{}, fmt.Errorf("generate confirmation code: %w", err)
+		}
+	}
+
+	created, err := repo.Create(r.Context(), sub, code, time.Now().Add(confirmTTL))
+	if errors.Is(err, db.ErrAlreadyExists) {
+		return db.Subscription{}, apperr.New(apperr.ErrConflict, alreadySubscribedMessage(err))
+	}
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
+	// The row stays pending if the code can't be sent; creating it
+	// again issues a fresh code.
+	if code != "" {
+		if err := sendConfirmation(r, created, sms, email, code, confirmTTL); err != nil {
+			return db.Subscription{}, apperr.Wrap(apperr.ErrUpstream, "failed to send confirmation code",
+				fmt.Errorf("subscription %s: %w", created.ID, err))
+		}
+	}
+	return created, nil
+}
+
+// newSubscription validates req's channels and the contact details each
+// needs. Contact details for channels that weren't picked are dropped.
+func newSubscription(req createSubscriptionRequest, emailEnabled bool) (db.Subscription, error) {
+	if req.CollectionCode == "" {
+		return db.Subscription{}, apperr.New(apperr.ErrInvalidInput, "collectionCode is required")
+	}
+	channels := req.Channels
+	if channels == nil {
+		channels = []string{db.ChannelSMS}
+	}
+	if len(channels) == 0 {
+		return db.Subscription{}, apperr.New(apperr.ErrInvalidInput, "at least one channel is required")
+	}
+
+	sub := db.Subscription{CollectionCode: req.CollectionCode}
+	for _, channel := range channels {
+		if sub.HasChannel(channel) {
+			continue
+		}
+		switch channel {
+		case db.ChannelSMS:
+			number, err := phone.Normalize(req.PhoneNumber)
+			if err != nil {
+				return db.Subscription{}, apperr.Wrap(apperr.ErrInvalidInput,
+					"phoneNumber must be a valid US phone number for the sms channel", err)
+			}
+			sub.PhoneNumber = number
+		case db.ChannelEmail:
+			if !emailEnabled {
+				return db.Subscription{}, apperr.New(apperr.ErrInvalidInput, "the email channel is not enabled")
+			}
+			addr, err := mail.ParseAddress(req.Email)
+			if err != nil || addr.Name != "" {
+				return db.Subscription{}, apperr.New(apperr.ErrInvalidInput,
+					"email must be a valid email address for the email channel")
+			}
+			sub.Email = addr.Address
+		case db.ChannelWebhook:
+			hookURL, err := parseWebhookURL(req.WebhookURL)
+			if err != nil {
+				return db.Subscription{}, apperr.Wrap(apperr.ErrInvalidInput,
+					"webhookUrl must be an absolute http or https URL for the webhook channel", err)
+			}
+			sub.WebhookURL = hookURL
+		default:
+			return db.Subscription{}, apperr.New(apperr.ErrInvalidInput,
+				fmt.Sprintf("unknown channel %q; use sms, email or webhook", channel))
+		}
+		sub.Channels = append(sub.Channels, channel)
+	}
+	return sub, nil
+}
+
+// sendConfirmation sends code by SMS if sub has that channel, otherwise by
+// email.
+func sendConfirmation(r *http.Request, sub db.Subscription, sms notify.SMSSender, email notify.EmailSender, code string, ttl time.Duration) error {
+	msg := confirmationMessage(sub.CollectionCode, code, ttl)
+	if sub.HasChannel(db.ChannelSMS) {
+		_, err := sms.SendSMS(r.Context(), sub.PhoneNumber, msg)
+		return err
+	}
+	subject := fmt.Sprintf("Confirm your GovInfo %s alerts", sub.CollectionCode)
+	_, err := email.SendEmail(r.Context(), sub.Email, subject, msg)
+	return err
+}
+
+func handleConfirmSubscription(repo *db.SubscriptionRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req confirmSubscriptionRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			return err
+		}
+		invalid := apperr.New(apperr.ErrInvalidInput, "a valid phoneNumber or email, and a code, are required")
+		if req.Code == "" || (req.PhoneNumber == "") == (req.Email == "") {
+			return invalid
+		}
+
+		var number, address string
+		if req.PhoneNumber != "" {
+			n, err := phone.Normalize(req.PhoneNumber)
+			if err != nil {
+				return invalid
+			}
+			number = n
+		} else {
+			addr, err := mail.ParseAddress(req.Email)
+			if err != nil {
+				return invalid
+			}
+			address = addr.Address
+		}
+
+		sub, err := repo.Confirm(r.Context(), number, address, req.Code)
+		if errors.Is(err, db.ErrNotFound) {
+			return apperr.New(apperr.ErrInvalidInput, "invalid or expired confirmation code")
+		}
+		if errors.Is(err, db.ErrAlreadyExists) {
+			return apperr.New(apperr.ErrConflict, alreadySubscribedMessage(err))
+		}
+		if err != nil {
+			return err
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, sub)
+		return nil
+	}
+}
+
+// alreadySubscribedMessage names the contact detail a duplicate
+// subscription error is about.
+func alreadySubscribedMessage(err error) string {
+	var dup *db.DuplicateError
+	if errors.As(err, &dup) {
+		switch dup.Channel {
+		case db.ChannelSMS:
+			return "phone number is already subscribed to this collection"
+		case db.ChannelEmail:
+			return "email address is already subscribed to this collection"
+		}
+	}
+	return "already subscribed to this collection"
+}
+
+// newConfirmationCode returns a random six-digit code.
+func newConfirmationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func confirmationMessage(collection, code string, ttl time.Duration) string {
+	return fmt.Sprintf("Your GovInfo %s alerts code is %s. It expires in %s.",
+		collection, code, ttl.Round(time.Minute))
+}
+
+// handleListSubscriptions pages through every subscription, oldest first.
+// Pass the returned nextCursor as cursor to get the following page.
+func handleListSubscriptions(repo *db.SubscriptionRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		limit := defaultListLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxListLimit {
+				return apperr.New(apperr.ErrInvalidInput, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			}
+			limit = n
+		}
+
+		subs, next, err := repo.List(r.Context(), limit, r.URL.Query().Get("cursor"))
+		if errors.Is(err, db.ErrInvalidCursor) {
+			return apperr.New(apperr.ErrInvalidInput, "invalid cursor")
+		}
+		if err != nil {
+			return err
+		}
+		if subs == nil {
+			subs = []db.Subscription{}
+		}
+
+		httpjson.WriteJSON(w, http.StatusOK, subscriptionPage{Subscriptions: subs, NextCursor: next})
+		return nil
+	}
+}
+
+func handleDeleteSubscription(repo *db.SubscriptionRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := chi.URLParam(r, "id")
+
+		err := repo.Delete(r.Context(), id)
+		if errors.Is(err, db.ErrNotFound) {
+			return apperr.New(apperr.ErrNotFound, "subscription not found")
+		}
+		if err != nil {
+			return fmt.Errorf("subscription %s: %w", id, err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tingeytime/govinfo/api/internal/db"
+)
+
+func TestListSubscriptionsRejectsBadLimit(t *testing.T) {
+	h := handleListSubscriptions(nil)
+	for _, limit := range []string{"0", "-1", "101", "ten"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/subscriptions?limit="+limit, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s: status %d, want 400", limit, rec.Code)
+		}
+	}
+}
+
+func TestAlreadySubscribedMessage(t *testing.T) {
+	for err, want := range map[error]string{
+		&db.DuplicateError{Channel: db.ChannelSMS}:                                 "phone number is already subscribed to this collection",
+		&db.DuplicateError{Channel: db.ChannelEmail}:                               "email address is already subscribed to this collection",
+		db.ErrAlreadyExists:                                                        "already subscribed to this collection",
+		errors.Join(errors.New("x"), &db.DuplicateError{Channel: db.ChannelEmail}): "email address is already subscribed to this collection",
+	} {
+		if got := alreadySubscribedMessage(err); got != want {
+			t.Errorf("alreadySubscribedMessage(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout gives each request's context a deadline of d. Handlers pass
+// their context to every database and GovInfo call, so once it passes
+// those calls fail and apiHandler answers 504. A zero d disables it.
+//
+// Routes that stream for as long as the client reads, such as downloads
+// and the event stream, must be mounted outside it.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
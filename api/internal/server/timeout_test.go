@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+func decodeErrorCode(t *testing.T, body string) string {
+	t.Helper()
+	var env struct {
+		Error httpjson.ErrorBody `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &env); err != nil {
+		t.Fatalf("error body %q: %v", body, err)
+	}
+	return env.Error.Code
+}
+
+func TestTimeoutCutsOffSlowHandler(t *testing.T) {
+	slow := apiHandler(func(w http.ResponseWriter, r *http.Request) error {
+		select {
+		case <-r.Context().Done():
+			return fmt.Errorf("slow work: %w", r.Context().Err())
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusOK)
+			return nil
+		}
+	})
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	Timeout(50*time.Millisecond)(slow).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v, want it cut off after 50ms", elapsed)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", rec.Code)
+	}
+	if code := decodeErrorCode(t, rec.Body.String()); code != httpjson.CodeTimeout {
+		t.Errorf("error code = %q, want %q", code, httpjson.CodeTimeout)
+	}
+}
+
+func TestTimeoutZeroDisabled(t *testing.T) {
+	var hasDeadline bool
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	})
+	Timeout(0)(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if hasDeadline {
+		t.Error("Timeout(0) set a deadline")
+	}
+}
+
+// slowGovInfo answers package summaries, and serves the PDF they link to
+// only after delay, or once the request is abandoned.
+func slowGovInfo(summaryDelay, pdfDelay time.Duration) http.HandlerFunc {
+	wait := func(r *http.Request, d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-r.Context().Done():
+			return false
+		}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/summary"):
+			if !wait(r, summaryDelay) {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"packageId":"BILLS-1","download":{"pdfLink":"http://%s/packages/BILLS-1/pdf"}}`, r.Host)
+		case strings.HasSuffix(r.URL.Path, "/pdf"):
+			if !wait(r, pdfDelay) {
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			io.WriteString(w, "%PDF-1.7")
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestRequestTimeoutOnRoutes(t *testing.T) {
+	t.Setenv("REQUEST_TIMEOUT", "100ms")
+	env := newTestEnv(t, testConfig(t), slowGovInfo(time.Second, 0))
+	s := env.server()
+
+	start := time.Now()
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/packages/BILLS-1/summary", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("slow summary = %d %s, want 504", rec.Code, rec.Body)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("slow summary took %v, want it cut off at REQUEST_TIMEOUT", elapsed)
+	}
+	if code := decodeErrorCode(t, rec.Body.String()); code != httpjson.CodeTimeout {
+		t.Errorf("error code = %q, want %q", code, httpjson.CodeTimeout)
+	}
+}
+
+func TestRequestTimeoutExemptsDownloads(t *testing.T) {
+	t.Setenv("REQUEST_TIMEOUT", "100ms")
+	env := newTestEnv(t, testConfig(t), slowGovInfo(0, 300*time.Millisecond))
+	s := env.server()
+
+	rec := env.do(s, httptest.NewRequest(http.MethodGet, "/v1/packages/BILLS-1/download", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("slow download = %d %s, want it to outlast REQUEST_TIMEOUT", rec.Code, rec.Body)
+	}
+	if rec.Body.String() != "%PDF-1.7" {
+		t.Errorf("download body = %q", rec.Body)
+	}
+}
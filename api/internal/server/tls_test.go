@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert writes a certificate for 127.0.0.1 and its key to
+// a temp dir, returning their paths and a pool trusting the certificate.
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string, roots *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "govinfo test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots = x509.NewCertPool()
+	roots.AddCert(cert)
+	return certFile, keyFile, roots
+}
+
+// httpsURL starts env's server and returns its base URL on 127.0.0.1,
+// the address the test certificate is issued for.
+func httpsURL(t *testing.T, env *testEnv) string {
+	t.Helper()
+	addr, _ := env.start(t, env.server())
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(addr, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "https://127.0.0.1:" + port
+}
+
+func httpsClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: true},
+	}
+}
+
+func TestRunServesHTTPSWithHTTP2(t *testing.T) {
+	certFile, keyFile, roots := writeSelfSignedCert(t)
+	cfg := testConfig(t)
+	cfg.TLSCertFile, cfg.TLSKeyFile = certFile, keyFile
+	env := newTestEnv(t, cfg, nil)
+	url := httpsURL(t, env)
+
+	resp, err := httpsClient(&tls.Config{RootCAs: roots}).Get(url + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /healthz = %d, want 200", resp.StatusCode)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+	if v := resp.TLS.Version; v < tls.VersionTLS12 {
+		t.Errorf("negotiated %s, want TLS 1.2 or later", tls.VersionName(v))
+	}
+
+	// Plain HTTP on the TLS port is refused.
+	if resp, err := http.Get("http://" + strings.TrimPrefix(url, "https://") + "/healthz"); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Error("plain HTTP served on the TLS port")
+		}
+	}
+}
+
+func TestRunRejectsWeakTLS(t *testing.T) {
+	certFile, keyFile, roots := writeSelfSignedCert(t)
+	cfg := testConfig(t)
+	cfg.TLSCertFile, cfg.TLSKeyFile = certFile, keyFile
+	env := newTestEnv(t, cfg, nil)
+	url := httpsURL(t, env)
+
+	for name, tc := range map[string]*tls.Config{
+		"TLS 1.1":    {RootCAs: roots, MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11},
+		"CBC on 1.2": {RootCAs: roots, MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA}},
+		"RSA kex":    {RootCAs: roots, MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_RSA_WITH_AES_128_GCM_SHA256}},
+	} {
+		if resp, err := httpsClient(tc).Get(url + "/healthz"); err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: handshake succeeded, want it refused", name)
+		}
+	}
+}
+
+func TestRunFailsOnBadCertificate(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.TLSCertFile = filepath.Join(t.TempDir(), "missing.pem")
+	cfg.TLSKeyFile = cfg.TLSCertFile
+	env := newTestEnv(t, cfg, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := env.server().Run(ctx)
+	if err == nil || !strings.Contains(err.Error(), "load TLS certificate") {
+		t.Fatalf("Run = %v, want a certificate load error", err)
+	}
+}
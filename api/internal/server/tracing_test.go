@@ -0,0 +1,120 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.InMemoryExporter
+)
+
+// recordSpans installs an in-memory tracer provider and returns its
+// recorder, emptied. Global tracers only delegate to the first provider
+// set, so every test shares one.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	recorder.Reset()
+	return recorder
+}
+
+func TestTraceSpanPerRequest(t *testing.T) {
+	rec := recordSpans(t)
+
+	var upstreamTraceparent string
+	gov := func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("Traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"collections":[{"collectionCode":"BILLS"}]}`)
+	}
+	env := newTestEnv(t, testConfig(t), gov)
+	s := env.server()
+
+	const (
+		traceID    = "4bf92f3577b34da6a3ce929d0e0e4736"
+		callerSpan = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/v1/collections", nil)
+	req.Header.Set("Traceparent", "00-"+traceID+"-"+callerSpan+"-01")
+	if got := env.do(s, req); got.Code != http.StatusOK {
+		t.Fatalf("GET /v1/collections = %d %s", got.Code, got.Body)
+	}
+
+	var server, client *tracetest.SpanStub
+	spans := rec.GetSpans()
+	for i, span := range spans {
+		switch span.SpanKind {
+		case trace.SpanKindServer:
+			server = &spans[i]
+		case trace.SpanKindClient:
+			client = &spans[i]
+		}
+	}
+	if server == nil {
+		t.Fatal("no server span recorded")
+	}
+	if server.Name != "GET /v1/collections" {
+		t.Errorf("server span name = %q, want the route", server.Name)
+	}
+	route := ""
+	for _, kv := range server.Attributes {
+		if kv.Key == "http.route" {
+			route = kv.Value.AsString()
+		}
+	}
+	if route != "/v1/collections" {
+		t.Errorf("http.route = %q, want /v1/collections", route)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("server span trace = %s, want the caller's %s", got, traceID)
+	}
+	if got := server.Parent.SpanID().String(); got != callerSpan {
+		t.Errorf("server span parent = %s, want the caller's span %s", got, callerSpan)
+	}
+
+	if client == nil {
+		t.Fatal("no GovInfo client span recorded")
+	}
+	if client.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("GovInfo span isn't a child of the server span")
+	}
+	if !strings.Contains(upstreamTraceparent, traceID) {
+		t.Errorf("upstream traceparent = %q, want trace %s", upstreamTraceparent, traceID)
+	}
+}
+
+func TestTraceSkipsProbes(t *testing.T) {
+	rec := recordSpans(t)
+	env := newTestEnv(t, testConfig(t), nil)
+	s := env.server()
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		env.do(s, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	for _, span := range rec.GetSpans() {
+		if span.SpanKind == trace.SpanKindServer {
+			t.Errorf("probe traced as %q", span.Name)
+		}
+	}
+
+	env.do(s, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if n := len(rec.GetSpans()); n != 1 {
+		t.Errorf("recorded %d spans for one request, want 1", n)
+	}
+}
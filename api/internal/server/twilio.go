@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/notify"
+	"github.com/tingeytime/govinfo/api/internal/phone"
+	"go.uber.org/zap"
+)
+
+// stopKeywords are the opt-out words carriers require us to honour.
+var stopKeywords = map[string]bool{
+	"STOP":        true,
+	"STOPALL":     true,
+	"UNSUBSCRIBE": true,
+	"CANCEL":      true,
+	"END":         true,
+	"QUIT":        true,
+}
+
+const emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+
+// handleTwilioInbound receives inbound SMS from Twilio and unsubscribes the
+// sender on a STOP keyword. Requests without a valid X-Twilio-Signature
+// are rejected. webhookURL overrides the URL used to check the signature;
+// when empty it is rebuilt from the request.
+func handleTwilioInbound(repo *db.SubscriptionRepo, authToken, webhookURL string, trustProxy bool) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		logger := LoggerFromContext(r.Context())
+
+		if err := r.ParseForm(); err != nil {
+			return apperr.Wrap(apperr.ErrInvalidInput, "invalid form body", err)
+		}
+
+		fullURL := webhookURL
+		if fullURL == "" {
+			fullURL = requestURL(r, trustProxy)
+		}
+		if !notify.ValidTwilioSignature(authToken, fullURL, r.PostForm, r.Header.Get(notify.TwilioSignatureHeader)) {
+			logger.Warn("Rejected inbound SMS with invalid signature")
+			return apperr.New(apperr.ErrForbidden, "invalid Twilio signature")
+		}
+
+		keyword := strings.ToUpper(strings.TrimSpace(r.PostForm.Get("Body")))
+		if stopKeywords[keyword] {
+			// A non-2xx makes Twilio retry the webhook.
+			if err := unsubscribe(r, repo, r.PostForm.Get("From")); err != nil {
+				return err
+			}
+		}
+
+		// Twilio sends the carrier-mandated STOP confirmation itself, so
+		// we reply with no message of our own.
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(emptyTwiML))
+		return nil
+	}
+}
+
+func unsubscribe(r *http.Request, repo *db.SubscriptionRepo, from string) error {
+	logger := LoggerFromContext(r.Context())
+
+	number, err := phone.Normalize(from)
+	if err != nil {
+		logger.Warn("STOP from unrecognised number", zap.String("from", from))
+		return nil
+	}
+	n, err := repo.DeactivateByPhone(r.Context(), number)
+	if err != nil {
+		return err
+	}
+	logger.Info("Unsubscribed number", zap.Int64("subscriptions", n))
+	return nil
+}
+
+// requestURL rebuilds the absolute URL the client requested.
+func requestURL(r *http.Request, trustProxy bool) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if trustProxy {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tingeytime/govinfo/api/internal/apperr"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+	"github.com/tingeytime/govinfo/api/internal/server/httpjson"
+)
+
+type createWebhookRequest struct {
+	URL            string `json:"url"`
+	CollectionCode string `json:"collectionCode"`
+}
+
+// createdWebhook is the only response that includes the signing secret.
+type createdWebhook struct {
+	db.Webhook
+	Secret string `json:"secret"`
+}
+
+// handleCreateWebhook registers a URL for new-package events on a
+// collection and returns the generated signing secret once.
+func handleCreateWebhook(repo *db.WebhookRepo, gov *govinfo.Client, enabled []string) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req createWebhookRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			return err
+		}
+		if req.URL == "" || req.CollectionCode == "" {
+			return apperr.New(apperr.ErrInvalidInput, "url and collectionCode are required")
+		}
+		if err := validateCollections(r, gov, req.CollectionCode); err != nil {
+			return err
+		}
+		if err := requireEnabledCollection(enabled, req.CollectionCode); err != nil {
+			return err
+		}
+		hookURL, err := parseWebhookURL(req.URL)
+		if err != nil {
+			return apperr.Wrap(apperr.ErrInvalidInput, "url must be an absolute http or https URL", err)
+		}
+
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return fmt.Errorf("generate webhook secret: %w", err)
+		}
+
+		hook, err := repo.Create(r.Context(), hookURL, req.CollectionCode, secret)
+		if err != nil {
+			return err
+		}
+
+		httpjson.WriteJSON(w, http.StatusCreated, createdWebhook{Webhook: hook, Secret: hook.Secret})
+		return nil
+	}
+}
+
+func handleDeleteWebhook(repo *db.WebhookRepo) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := chi.URLParam(r, "id")
+
+		err := repo.Delete(r.Context(), id)
+		if errors.Is(err, db.ErrNotFound) {
+			return apperr.New(apperr.ErrNotFound, "webhook not found")
+		}
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", id, err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// parseWebhookURL accepts absolute http and https URLs.
+func parseWebhookURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("not an absolute http or https URL: %q", s)
+	}
+	return u.String(), nil
+}
+
+// newWebhookSecret returns 32 random bytes, hex encoded.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
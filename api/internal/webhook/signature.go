@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// signaturePrefix names the scheme in SignatureHeader values.
+const signaturePrefix = "sha256="
+
+// Sign returns the SignatureHeader value for body: "sha256=" followed by
+// the lowercase hex HMAC-SHA256 of the raw body, keyed with secret. For
+// example, the body {"event":"package.published"} signed with the secret
+// "secret" gives
+//
+//	sha256=56af87df4686c3d10c7680efd990ab09d055b191fb2a8fb6931047c56af29ce4
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether header, a SignatureHeader value, is
+// Sign(body, secret). Receivers must check the raw body exactly as
+// received, before decoding it. The comparison is constant-time, and an
+// empty secret or header never verifies.
+func VerifySignature(body []byte, header, secret string) bool {
+	if secret == "" || !strings.HasPrefix(header, signaturePrefix) {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(header, signaturePrefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// signatureVectors are checked against an independent HMAC-SHA256; the
+// second is RFC 4231 test case 2.
+var signatureVectors = []struct {
+	body, secret, want string
+}{
+	{`{"event":"package.published"}`, "secret", "sha256=56af87df4686c3d10c7680efd990ab09d055b191fb2a8fb6931047c56af29ce4"},
+	{"what do ya want for nothing?", "Jefe", "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+	{"", "k", "sha256=8bb990c40a7d61cb97597a942125025be50ac8beb74436e3735b98893a7f6620"},
+}
+
+func TestSignVectors(t *testing.T) {
+	for _, v := range signatureVectors {
+		if got := Sign([]byte(v.body), v.secret); got != v.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", v.body, v.secret, got, v.want)
+		}
+		if !VerifySignature([]byte(v.body), v.want, v.secret) {
+			t.Errorf("VerifySignature rejected the vector for %q", v.body)
+		}
+	}
+}
+
+func TestVerifySignatureRejects(t *testing.T) {
+	body := []byte(`{"event":"package.published"}`)
+	good := Sign(body, "secret")
+	for name, tc := range map[string]struct {
+		body           []byte
+		header, secret string
+	}{
+		"tampered body":  {[]byte(`{"event":"package.deleted"}`), good, "secret"},
+		"wrong secret":   {body, good, "other"},
+		"empty secret":   {body, Sign(body, ""), ""},
+		"empty header":   {body, "", "secret"},
+		"missing prefix": {body, strings.TrimPrefix(good, "sha256="), "secret"},
+		"other scheme":   {body, "sha1=" + strings.TrimPrefix(good, "sha256="), "secret"},
+		"truncated":      {body, good[:len(good)-2], "secret"},
+		"not hex":        {body, "sha256=not-hex", "secret"},
+	} {
+		if VerifySignature(tc.body, tc.header, tc.secret) {
+			t.Errorf("%s: VerifySignature accepted it", name)
+		}
+	}
+}
+
+// A delivery verifies on the receiving side exactly as integrators are
+// told to check it.
+func TestDeliveryVerifies(t *testing.T) {
+	const secret = "hook-secret"
+	verified := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified <- VerifySignature(body, r.Header.Get(SignatureHeader), secret)
+	}))
+	defer srv.Close()
+
+	s := NewSender(&config.Config{WebhookMaxAttempts: 1}, zap.NewNop())
+	hook := db.Webhook{ID: "hook-1", URL: srv.URL, Secret: secret}
+	if err := s.DeliverPackage(context.Background(), hook, govinfo.Package{PackageID: "BILLS-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if !<-verified {
+		t.Error("receiver could not verify the delivery's signature")
+	}
+}
@@ -0,0 +1,202 @@
+// Package webhook delivers new-package events to registered HTTP
+// endpoints. Each delivery is a JSON POST signed with the webhook's
+// secret: the SignatureHeader carries "sha256=" followed by the hex
+// HMAC-SHA256 of the raw request body. Sign produces it and receivers can
+// check it with VerifySignature.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tingeytime/govinfo/api/internal/config"
+	"github.com/tingeytime/govinfo/api/internal/db"
+	"github.com/tingeytime/govinfo/api/internal/govinfo"
+)
+
+// Delivery headers.
+const (
+	SignatureHeader = "X-Signature"
+	EventHeader     = "X-Webhook-Event"
+	// DeliveryHeader is the same on every retry of a delivery, so
+	// receivers can drop duplicates.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// EventPackagePublished is sent when the poller finds a new package.
+const EventPackagePublished = "package.published"
+
+const (
+	defaultAttempts = 5
+	defaultTimeout  = 10 * time.Second
+	retryBackoff    = time.Second
+)
+
+// Event is the JSON body of a delivery.
+type Event struct {
+	Event          string `json:"event"`
+	PackageID      string `json:"packageId"`
+	CollectionCode string `json:"collectionCode"`
+	Title          string `json:"title"`
+	DateIssued     string `json:"dateIssued,omitempty"`
+	LastModified   string `json:"lastModified"`
+	URL            string `json:"url"`
+}
+
+// NewPackageEvent builds the event for a newly published package.
+func NewPackageEvent(pkg govinfo.Package) Event {
+	return Event{
+		Event:          EventPackagePublished,
+		PackageID:      pkg.PackageID,
+		CollectionCode: pkg.CollectionCode,
+		Title:          pkg.Title,
+		DateIssued:     pkg.DateIssued,
+		LastModified:   pkg.LastModified,
+		URL:            "https://www.govinfo.gov/app/details/" + pkg.PackageID,
+	}
+}
+
+// StatusError is a non-2xx response from a webhook endpoint.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook: endpoint returned status %d", e.StatusCode)
+}
+
+// Sender POSTs signed events to webhook URLs.
+type Sender struct {
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	logger      *zap.Logger
+}
+
+// NewSender builds a sender from the webhook retry and timeout settings in
+// cfg. Redirects are not followed; a 3xx counts as a failed delivery.
+func NewSender(cfg *config.Config, logger *zap.Logger) *Sender {
+	attempts := cfg.WebhookMaxAttempts
+	if attempts < 1 {
+		attempts = defaultAttempts
+	}
+	timeout := cfg.WebhookTimeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Sender{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: attempts,
+		backoff:     retryBackoff,
+		logger:      logger,
+	}
+}
+
+// DeliverPackage sends the event for pkg to hook. Network errors, 5xx,
+// 408 and 429 responses are retried with exponential backoff up to the
+// configured attempts. A delivery that still fails, or is rejected
+// outright, is logged as dead-lettered and its last error returned.
+func (s *Sender) DeliverPackage(ctx context.Context, hook db.Webhook, pkg govinfo.Package) error {
+	_, err := s.deliverPackage(ctx, hook, pkg)
+	return err
+}
+
+// deliverPackage is DeliverPackage, also returning the delivery ID.
+func (s *Sender) deliverPackage(ctx context.Context, hook db.Webhook, pkg govinfo.Package) (string, error) {
+	event := NewPackageEvent(pkg)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("webhook: encode event: %w", err)
+	}
+	deliveryID := uuid.NewString()
+
+	attempts := 0
+	for attempts < s.maxAttempts {
+		if attempts > 0 {
+			t := time.NewTimer(s.backoff << (attempts - 1))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				err = fmt.Errorf("webhook: deliver to %s: %w (last error: %v)", hook.ID, ctx.Err(), err)
+				return deliveryID, s.deadLetter(hook, event, deliveryID, attempts, err)
+			case <-t.C:
+			}
+		}
+
+		attempts++
+		var retry bool
+		retry, err = s.post(ctx, hook, event.Event, deliveryID, body)
+		if err == nil {
+			return deliveryID, nil
+		}
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return deliveryID, s.deadLetter(hook, event, deliveryID, attempts, err)
+}
+
+// Notify delivers pkg to a subscription's webhook channel, making Sender a
+// notify.Notifier. It returns the delivery ID sent in DeliveryHeader.
+func (s *Sender) Notify(ctx context.Context, sub db.Subscription, pkg govinfo.Package) (string, error) {
+	return s.deliverPackage(ctx, db.Webhook{
+		ID:             sub.ID,
+		URL:            sub.WebhookURL,
+		CollectionCode: sub.CollectionCode,
+		Secret:         sub.WebhookSecret,
+	}, pkg)
+}
+
+func (s *Sender) deadLetter(hook db.Webhook, event Event, deliveryID string, attempts int, err error) error {
+	s.logger.Error("webhook delivery dead-lettered",
+		zap.String("webhook_id", hook.ID),
+		zap.String("url", hook.URL),
+		zap.String("delivery_id", deliveryID),
+		zap.String("event", event.Event),
+		zap.String("package_id", event.PackageID),
+		zap.Int("attempts", attempts),
+		zap.Error(err))
+	return err
+}
+
+// post makes one attempt and reports whether a failure is safe to retry.
+// Deliveries carry a stable ID, so unlike SMS a network error after the
+// request was written is still retried.
+func (s *Sender) post(ctx context.Context, hook db.Webhook, event, deliveryID string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("webhook: build request for %s: %w", hook.ID, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(body, hook.Secret))
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook: deliver to %s: %w", hook.ID, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+	retry = resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return retry, &StatusError{StatusCode: resp.StatusCode}
+}